@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/lmittmann/tint"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/util"
 
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5"
 	"github.com/rancher/charts-build-scripts/pkg/auto"
 	"github.com/rancher/charts-build-scripts/pkg/charts"
@@ -20,6 +22,7 @@ import (
 	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
 	"github.com/rancher/charts-build-scripts/pkg/options"
 	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 	"github.com/rancher/charts-build-scripts/pkg/puller"
 	"github.com/rancher/charts-build-scripts/pkg/registries"
 	"github.com/rancher/charts-build-scripts/pkg/repository"
@@ -84,6 +87,25 @@ const (
 	defaultNewChartVariable = "NEW_CHART"
 	// defaultIsPrimeChartVariable for handling prime charts
 	defaultIsPrimeChartVariable = "IS_PRIME"
+	// GitHub repository options for the automation commands
+	defaultGithubOwnerEnvironmentVariable  = "GH_OWNER"
+	defaultGithubRepoEnvironmentVariable   = "GITHUB_REPO_NAME"
+	defaultGithubAPIURLEnvironmentVariable = "GH_API_URL"
+	defaultUpstreamURLEnvironmentVariable  = "UPSTREAM_URL"
+	// defaultCreatePREnvironmentVariable is the default environment variable that indicates if chart-bump should open the pull request
+	defaultCreatePREnvironmentVariable = "CREATE_PR"
+	// defaultPRDryRunEnvironmentVariable is the default environment variable that indicates if pull requests payloads should only be printed
+	defaultPRDryRunEnvironmentVariable = "PR_DRY_RUN"
+	// defaultDryRunEnvironmentVariable is the default environment variable that indicates if only the plan of the changes should be printed
+	defaultDryRunEnvironmentVariable = "DRY_RUN"
+	// defaultOutputEnvironmentVariable is the default environment variable that indicates the output format of the lifecycle reports
+	defaultOutputEnvironmentVariable = "OUTPUT"
+	// defaultResumeEnvironmentVariable is the default environment variable that indicates if a failed auto-forward-port should be resumed
+	defaultResumeEnvironmentVariable = "RESUME"
+	// defaultPrereleaseIdentifiersEnvironmentVariable is the default environment variable that indicates the comma-separated pre-release identifiers, e.g. rc,alpha,beta,dev
+	defaultPrereleaseIdentifiersEnvironmentVariable = "PRERELEASE_IDENTIFIERS"
+	// defaultBranchPerPackageEnvironmentVariable is the default environment variable that indicates if chart-bump-batch should bump each package on its own branch
+	defaultBranchPerPackageEnvironmentVariable = "BRANCH_PER_PACKAGE"
 )
 
 var (
@@ -153,6 +175,30 @@ var (
 	NewChart bool
 	// IsPrimeChart boolean option
 	IsPrimeChart bool
+	// GithubOwner is the owner of the charts repository on GitHub
+	GithubOwner string
+	// GithubRepo is the name of the charts repository on GitHub
+	GithubRepo string
+	// GithubAPIURL is the base URL of the GitHub API (GitHub Enterprise)
+	GithubAPIURL string
+	// UpstreamURL is the URL of the charts repository configured as a remote in your local git repository
+	UpstreamURL string
+	// CreatePR indicates if chart-bump should push the branch and open the pull request
+	CreatePR bool
+	// PRDryRun indicates that pull requests payloads should be printed instead of opening the pull requests
+	PRDryRun bool
+	// DryRun indicates that auto-forward-port, release and lifecycle-prune should only print the plan of the changes
+	DryRun bool
+	// Resume indicates that auto-forward-port should resume the previous run from its journal
+	Resume bool
+	// BranchPerPackage indicates that chart-bump-batch should bump each package on its own branch
+	BranchPerPackage bool
+	// OutputFormat is the format lifecycle-status, lifecycle-diff and lifecycle-matrix print their results in
+	OutputFormat string
+	// VerifyIndex indicates that index should load every archive of assets/ again instead of only the changed ones
+	VerifyIndex bool
+	// FixIndex indicates that index-check should rebuild the index.yaml from assets/ when it finds violations
+	FixIndex bool
 )
 
 func init() {
@@ -185,6 +231,9 @@ func init() {
 
 func main() {
 	util.InitSoftErrorMode()
+	if identifiers := os.Getenv(defaultPrereleaseIdentifiersEnvironmentVariable); identifiers != "" {
+		prerelease.SetDefault(prerelease.NewPolicy(strings.Split(identifiers, ",")...))
+	}
 
 	app := cli.NewApp()
 	app.Name = "charts-build-scripts"
@@ -411,6 +460,18 @@ func main() {
 		EnvVar:      defaultPRNumberEnvironmentVariable,
 		Destination: &PullRequest,
 	}
+	verifyIndexFlag := cli.BoolFlag{
+		Name:        "verify",
+		Usage:       "Load every archive of assets/ again instead of only the ones changed since index.yaml was generated, fixing the entries that do not match their archive",
+		Required:    false,
+		Destination: &VerifyIndex,
+	}
+	fixIndexFlag := cli.BoolFlag{
+		Name:        "fix",
+		Usage:       "Rebuild the index.yaml from the archives of assets/ if it has violations, keeping the created timestamps of the unchanged archives",
+		Required:    false,
+		Destination: &FixIndex,
+	}
 	skipFlag := cli.BoolFlag{
 		Name:        "skip",
 		Usage:       "Skip the execution and return success",
@@ -453,6 +514,74 @@ func main() {
 		Destination: &IsPrimeChart,
 		EnvVar:      defaultIsPrimeChartVariable,
 	}
+	githubOwnerFlag := cli.StringFlag{
+		Name:        "gh-owner",
+		Usage:       "--gh-owner=rancher || GH_OWNER=rancher; overrides github.owner from the configuration file",
+		Required:    false,
+		EnvVar:      defaultGithubOwnerEnvironmentVariable,
+		Destination: &GithubOwner,
+	}
+	githubRepoFlag := cli.StringFlag{
+		Name:        "gh-repo",
+		Usage:       "--gh-repo=charts || GITHUB_REPO_NAME=charts; overrides github.repo from the configuration file",
+		Required:    false,
+		EnvVar:      defaultGithubRepoEnvironmentVariable,
+		Destination: &GithubRepo,
+	}
+	githubAPIURLFlag := cli.StringFlag{
+		Name:        "gh-api-url",
+		Usage:       "--gh-api-url=https://<host>/api/v3/ || GH_API_URL=<url>; overrides github.apiURL from the configuration file",
+		Required:    false,
+		EnvVar:      defaultGithubAPIURLEnvironmentVariable,
+		Destination: &GithubAPIURL,
+	}
+	upstreamURLFlag := cli.StringFlag{
+		Name:        "upstream-url",
+		Usage:       "--upstream-url=https://github.com/rancher/charts || UPSTREAM_URL=<url>; overrides github.upstreamURL from the configuration file",
+		Required:    false,
+		EnvVar:      defaultUpstreamURLEnvironmentVariable,
+		Destination: &UpstreamURL,
+	}
+	optionalGHTokenFlag := ghTokenFlag
+	optionalGHTokenFlag.Required = false
+	optionalForkFlag := forkFlag
+	optionalForkFlag.Required = false
+	createPRFlag := cli.BoolFlag{
+		Name:        "create-pr",
+		Usage:       "--create-pr || CREATE_PR=true; push the bump branch and open the pull request",
+		EnvVar:      defaultCreatePREnvironmentVariable,
+		Destination: &CreatePR,
+	}
+	prDryRunFlag := cli.BoolFlag{
+		Name:        "pr-dry-run",
+		Usage:       "--pr-dry-run || PR_DRY_RUN=true; print the pull request payload instead of opening it",
+		EnvVar:      defaultPRDryRunEnvironmentVariable,
+		Destination: &PRDryRun,
+	}
+	branchPerPackageFlag := cli.BoolFlag{
+		Name:        "branch-per-package",
+		Usage:       "--branch-per-package || BRANCH_PER_PACKAGE=true; bump each package on its own branch (chart-bump-<package>-<branch version>)",
+		EnvVar:      defaultBranchPerPackageEnvironmentVariable,
+		Destination: &BranchPerPackage,
+	}
+	dryRunFlag := cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "--dry-run || DRY_RUN=true; print the plan of branches, commits and files without touching the working tree or the remotes (--porcelain prints it as JSON)",
+		EnvVar:      defaultDryRunEnvironmentVariable,
+		Destination: &DryRun,
+	}
+	outputFlag := cli.StringFlag{
+		Name:        "output,o",
+		Usage:       "--output=json|yaml|table || OUTPUT=json|yaml|table; print the result in the given format (lifecycle-matrix supports markdown, json and yaml)",
+		EnvVar:      defaultOutputEnvironmentVariable,
+		Destination: &OutputFormat,
+	}
+	resumeFlag := cli.BoolFlag{
+		Name:        "resume",
+		Usage:       "--resume || RESUME=true; resume a failed run from the journal in logs/, skipping the branches already forward-ported",
+		EnvVar:      defaultResumeEnvironmentVariable,
+		Destination: &Resume,
+	}
 
 	// Commands
 	app.Commands = []cli.Command{
@@ -499,6 +628,17 @@ func main() {
 			Name:   "index",
 			Usage:  "Create or update the existing Helm index.yaml at the repository root",
 			Action: createOrUpdateIndex,
+			Flags:  []cli.Flag{verifyIndexFlag},
+		},
+		{
+			Name: "index-check",
+			Usage: `Check the index.yaml against the archives of assets/ without generating the charts, so it can run on any branch.
+				Every entry must point to an archive of assets/ with the entry digest and the name, version, appVersion, annotations
+				and dependencies of its Chart.yaml, versions must be unique and sorted, and every chart archive must be in index.yaml.
+				With --fix, the index.yaml is rebuilt from assets/ when violations are found.
+			`,
+			Action: checkIndex,
+			Flags:  []cli.Flag{fixIndexFlag},
 		},
 		{
 			Name:   "zip",
@@ -575,35 +715,63 @@ func main() {
 		{
 			Name: "lifecycle-status",
 			Usage: `Print the status of the current assets and charts based on the branch version and chart version according to the lifecycle rules.
-			Saves the logs in the logs/ directory.`,
+			Saves the logs in the logs/ directory.
+			With --output the status categories are also printed as json, yaml or a table.`,
 			Action: lifecycleStatus,
-			Flags:  []cli.Flag{branchVersionFlag, chartFlag},
+			Flags:  []cli.Flag{branchVersionFlag, chartFlag, configFlag, upstreamURLFlag, outputFlag},
+		},
+		{
+			Name:      "lifecycle-diff",
+			Usage:     `Compare two lifecycle-status state.json snapshots and print the assets that moved between categories.`,
+			ArgsUsage: "<before-state.json> <after-state.json>",
+			Action:    lifecycleDiff,
+			Flags:     []cli.Flag{outputFlag},
+		},
+		{
+			Name: "lifecycle-prune",
+			Usage: `Remove every asset, unpacked chart and index.yaml entry outside the lifecycle window of the current branch.
+				The removed chart versions are tracked in release.yaml and each chart is committed separately.
+				Chart versions listed in the keep allowlist of the branch in version_rules.json are never removed.
+			`,
+			Action: lifecyclePrune,
+			Flags:  []cli.Flag{branchVersionFlag, chartFlag, configFlag, dryRunFlag, porcelainFlag},
+		},
+		{
+			Name: "lifecycle-matrix",
+			Usage: `Print every chart version across the production and development branches of every branch version in version_rules.json.
+				Flags versions missing from a branch, versions outside the lifecycle of a branch and forward-ports not done yet.
+				Printed as markdown by default, or as json or yaml with --output.
+			`,
+			Action: lifecycleMatrix,
+			Flags:  []cli.Flag{branchVersionFlag, chartFlag, configFlag, upstreamURLFlag, outputFlag},
 		},
 		{
 			Name: "auto-forward-port",
 			Usage: `Execute the forward-port script to forward port a chart or all to the production branch.
 				The charts to be forward ported are listed in the result of lifecycle-status command.
 				It is advised to run make lifecycle-status before running this command.
-				At the end of the execution, the script will create a PR with the changes to each chart.
+				At the end of the execution, the script will open a PR with the changes to each chart (requires GH_TOKEN).
 				At the end of the execution, the script will save the logs in the logs directory,
 				with all assets versions and branches that were pushed to the upstream repository.
+				The progress of each branch is saved in a journal in the logs directory, a failed run can be continued with --resume.
 			`,
 			Action: autoForwardPort,
-			Flags:  []cli.Flag{branchVersionFlag, chartFlag, forkFlag},
+			Flags: []cli.Flag{branchVersionFlag, chartFlag, forkFlag, configFlag,
+				optionalGHTokenFlag, githubOwnerFlag, githubRepoFlag, githubAPIURLFlag, upstreamURLFlag, prDryRunFlag, dryRunFlag, porcelainFlag, resumeFlag},
 		},
 		{
 			Name: "release",
 			Usage: `Execute the release script to release a chart to the production branch.
 			`,
 			Action: release,
-			Flags:  []cli.Flag{branchVersionFlag, chartFlag, chartVersionFlag, forkFlag},
+			Flags:  []cli.Flag{branchVersionFlag, chartFlag, chartVersionFlag, forkFlag, configFlag, upstreamURLFlag, dryRunFlag, porcelainFlag},
 		},
 		{
 			Name: "validate-release-charts",
 			Usage: `Check charts to release in PR.
 			`,
 			Action: validateRelease,
-			Flags:  []cli.Flag{branchFlag, ghTokenFlag, prNumberFlag, skipFlag},
+			Flags:  []cli.Flag{branchFlag, ghTokenFlag, prNumberFlag, skipFlag, configFlag, githubOwnerFlag, githubRepoFlag, githubAPIURLFlag, upstreamURLFlag},
 		},
 		{
 			Name: "compare-index-files",
@@ -612,12 +780,74 @@ func main() {
 			Action: compareIndexFiles,
 			Flags:  []cli.Flag{branchFlag},
 		},
+		{
+			Name: "chart-groups",
+			Usage: `List the chart groups bumped, forward-ported and released together and the charts in index.yaml not covered by any group.
+				Groups are derived from package.yaml (main chart and additionalCharts) and can be declared in the chartGroups of configuration.yaml.
+			`,
+			Action: listChartGroups,
+			Flags:  []cli.Flag{configFlag},
+		},
+		{
+			Name: "validate-upgrade-paths",
+			Usage: `Check the upgrade path of every chart in index.yaml.
+				Versions must follow the 10X.Y.Z+upA.B.C scheme, a newer repo prefix cannot hold an older upstream version,
+				an upstream version cannot be released under two repo prefixes, CRD charts must have the same versions as their main chart
+				and the branch cannot hold versions of a newer branch line.
+				Violations are printed as a table by default, or as json or yaml with --output.
+			`,
+			Action: validateUpgradePaths,
+			Flags:  []cli.Flag{branchVersionFlag, chartFlag, configFlag, outputFlag},
+		},
+		{
+			Name: "validate-annotations",
+			Usage: `Check the catalog.cattle.io annotations of every chart version in charts/, or only of --chart.
+				Checks that the required annotations are set, that the kube-version and rancher-version ranges parse,
+				that auto-install references a chart version in charts/ and that the rancher-version range admits
+				the branch version of version_rules.json holding the chart version and no newer one.
+			`,
+			Action: validateAnnotations,
+			Flags:  []cli.Flag{chartFlag},
+		},
+		{
+			Name: "render-check",
+			Usage: `Render every chart version of release.yaml found in charts/ offline with the Helm engine, without a cluster.
+				Each chart is rendered with its default values and the values files of renderCheck in configuration.yaml,
+				against the renderCheck Kubernetes versions within its catalog.cattle.io/kube-version range.
+				The rendered manifests are written to logs/render-check/<chart>/<version>/ for inspection.
+			`,
+			Action: renderCheck,
+			Flags:  []cli.Flag{chartFlag, configFlag},
+		},
+		{
+			Name: "schema-check",
+			Usage: `Validate the manifests of every chart version of release.yaml found in charts/ offline, rendered like render-check,
+				against the vendored schemas of the Kubernetes versions within the chart range and the CRDs of the chart and its -crd chart.
+				Every renderCheck Kubernetes version must have a vendored schema; without any, the vendored versions are checked.
+				APIs removed in a supported Kubernetes version fail the check, deprecated ones are only reported.
+			`,
+			Action: schemaCheck,
+			Flags:  []cli.Flag{chartFlag, configFlag},
+		},
 		{
 			Name:   "chart-bump",
 			Usage:  `Generate a new chart bump PR.`,
 			Action: chartBump,
 			Before: setupCache,
-			Flags:  []cli.Flag{packageFlag, branchFlag, overrideVersionFlag, multiRCFlag, newChartFlag, isPrimeChartFlag},
+			Flags: []cli.Flag{packageFlag, branchFlag, overrideVersionFlag, multiRCFlag, newChartFlag, isPrimeChartFlag,
+				createPRFlag, prDryRunFlag, optionalGHTokenFlag, optionalForkFlag, githubOwnerFlag, githubRepoFlag, githubAPIURLFlag, upstreamURLFlag},
+		},
+		{
+			Name: "chart-bump-batch",
+			Usage: `Bump every package with auto: true, or the comma-separated packages of --package, one after the other.
+				A package that fails to bump is reset and does not affect the others.
+				The old and new version and the status of every package are written to config/bump_batch.json.
+				With --branch-per-package each package is bumped on its own branch, and --create-pr opens a pull request for each of them.
+			`,
+			Action: chartBumpBatch,
+			Before: setupCache,
+			Flags: []cli.Flag{packageFlag, branchFlag, overrideVersionFlag, multiRCFlag, isPrimeChartFlag, branchPerPackageFlag,
+				createPRFlag, prDryRunFlag, optionalGHTokenFlag, optionalForkFlag, githubOwnerFlag, githubRepoFlag, githubAPIURLFlag, upstreamURLFlag},
 		},
 
 		{
@@ -766,11 +996,47 @@ func createOrUpdateIndex(c *cli.Context) {
 	ctx := context.Background()
 
 	getRepoRoot()
-	if err := helm.CreateOrUpdateHelmIndex(ctx, filesystem.GetFilesystem(RepoRoot)); err != nil {
+	indexFn := helm.CreateOrUpdateHelmIndex
+	if VerifyIndex {
+		indexFn = helm.RebuildHelmIndex
+	}
+	if err := indexFn(ctx, filesystem.GetFilesystem(RepoRoot)); err != nil {
 		logger.Fatal(ctx, err.Error())
 	}
 }
 
+func checkIndex(c *cli.Context) {
+	ctx := context.Background()
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+
+	violations, err := validate.CheckIndex(ctx, rootFs)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to check index.yaml: %w", err).Error())
+	}
+	if len(violations) > 0 && FixIndex {
+		for _, violation := range violations {
+			logger.Log(ctx, slog.LevelWarn, "fixing index.yaml violation", slog.String("chart", violation.Chart), slog.String("version", violation.Version),
+				slog.String("url", violation.URL), slog.String("message", violation.Message))
+		}
+		if err := helm.RebuildHelmIndex(ctx, rootFs); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to rebuild index.yaml: %w", err).Error())
+		}
+		if violations, err = validate.CheckIndex(ctx, rootFs); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to check index.yaml: %w", err).Error())
+		}
+	}
+
+	for _, violation := range violations {
+		logger.Log(ctx, slog.LevelError, "index.yaml violation", slog.String("chart", violation.Chart), slog.String("version", violation.Version),
+			slog.String("url", violation.URL), slog.String("message", violation.Message))
+	}
+	if len(violations) > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("found %d index.yaml violations", len(violations)))
+	}
+	logger.Log(ctx, slog.LevelInfo, "index.yaml matches assets")
+}
+
 func zipCharts(c *cli.Context) {
 	ctx := context.Background()
 
@@ -846,6 +1112,65 @@ func validateRepo(c *cli.Context) {
 		logger.Log(ctx, slog.LevelInfo, "successfully validated that current charts and assets are up-to-date")
 	}
 
+	// Forward-ported charts were already checked when they were released
+	if !Skip {
+		releaseOptions, err := options.LoadReleaseOptionsFromFile(ctx, rootFs, path.RepositoryReleaseYaml)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("unable to unmarshall release.yaml: %w", err).Error())
+		}
+
+		logger.Log(ctx, slog.LevelInfo, "checking CRD compatibility with the previously released versions")
+		crdIncompatibilities, err := validate.LoadCRDIncompatibilities(ctx, RepoRoot)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to load the acknowledged CRD incompatibilities: %w", err).Error())
+		}
+		crdResults, err := validate.CheckCRDCompatibility(ctx, rootFs, releaseOptions, crdIncompatibilities)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to check CRD compatibility: %w", err).Error())
+		}
+		if incompatible := logCRDCompatibility(ctx, crdResults); incompatible > 0 {
+			logger.Fatal(ctx, fmt.Sprintf("%d CRD changes can break existing custom resources, acknowledge them under crdIncompatibilities in package.yaml if intended", incompatible))
+		}
+
+		logger.Log(ctx, slog.LevelInfo, "checking values compatibility with the previously released versions")
+		removedValues, err := validate.LoadRemovedValues(ctx, RepoRoot)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to load the acknowledged removed values: %w", err).Error())
+		}
+		valuesResults, err := validate.CheckValuesCompatibility(ctx, rootFs, releaseOptions, removedValues)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to check values compatibility: %w", err).Error())
+		}
+		if removals := logValuesCompatibility(ctx, valuesResults); removals > 0 {
+			logger.Fatal(ctx, fmt.Sprintf("%d values keys removed, acknowledge them under removedValues in package.yaml if intended", removals))
+		}
+
+		logger.Log(ctx, slog.LevelInfo, "checking the catalog annotations of the charts to release")
+		if failed := checkAnnotations(ctx, rootFs, releaseTargets(releaseOptions)...); failed > 0 {
+			logger.Fatal(ctx, fmt.Sprintf("%d invalid catalog annotations", failed))
+		}
+
+		if chartsScriptOptions.RenderCheckOptions != nil {
+			logger.Log(ctx, slog.LevelInfo, "rendering the charts to release")
+			renderResults, err := validate.RenderCharts(ctx, rootFs, releaseOptions, chartsScriptOptions.RenderCheckOptions, "")
+			if err != nil {
+				logger.Fatal(ctx, fmt.Errorf("failed to render charts: %w", err).Error())
+			}
+			if failed := logRenderChecks(ctx, renderResults); failed > 0 {
+				logger.Fatal(ctx, fmt.Sprintf("%d chart renders failed", failed))
+			}
+
+			logger.Log(ctx, slog.LevelInfo, "validating the rendered manifests against the Kubernetes schemas")
+			violations, err := validate.CheckSchemas(ctx, rootFs, releaseOptions, chartsScriptOptions.RenderCheckOptions)
+			if err != nil {
+				logger.Fatal(ctx, fmt.Errorf("failed to check schemas: %w", err).Error())
+			}
+			if failed := logSchemaViolations(ctx, violations); failed > 0 {
+				logger.Fatal(ctx, fmt.Sprintf("%d schema violations found", failed))
+			}
+		}
+	}
+
 	if chartsScriptOptions.ValidateOptions != nil {
 		if LocalMode {
 			logger.Log(ctx, slog.LevelInfo, "local validation only")
@@ -965,6 +1290,62 @@ func parseScriptOptions(ctx context.Context) *options.ChartsScriptOptions {
 	return &chartsScriptOptions
 }
 
+// parseChartGroups loads the chart groups from package.yaml and the chartGroups of configuration.yaml, if present
+func parseChartGroups(ctx context.Context) auto.ChartGroups {
+	if ChartsScriptOptionsFile == "" {
+		ChartsScriptOptionsFile = path.ConfigurationYamlFile
+	}
+
+	var configured map[string][]string
+	if _, err := os.Stat(ChartsScriptOptionsFile); err == nil {
+		configured = parseScriptOptions(ctx).ChartGroups
+	}
+
+	groups, err := auto.LoadChartGroups(ctx, RepoRoot, configured)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to load chart groups: %w", err).Error())
+	}
+	return groups
+}
+
+// parseGithubOptions loads the github options from the configuration file if present,
+// overrides them with the given flags and fills the remaining fields with the rancher/charts defaults
+func parseGithubOptions(ctx context.Context) *options.GithubOptions {
+	if ChartsScriptOptionsFile == "" {
+		ChartsScriptOptionsFile = path.ConfigurationYamlFile
+	}
+
+	ghOpts := &options.GithubOptions{}
+	if _, err := os.Stat(ChartsScriptOptionsFile); err == nil {
+		if chartsScriptOptions := parseScriptOptions(ctx); chartsScriptOptions.GithubOptions != nil {
+			ghOpts = chartsScriptOptions.GithubOptions
+		}
+	}
+
+	if GithubOwner != "" {
+		ghOpts.Owner = GithubOwner
+	}
+	if GithubRepo != "" {
+		ghOpts.Repo = GithubRepo
+	}
+	if GithubAPIURL != "" {
+		ghOpts.APIURL = GithubAPIURL
+	}
+	if UpstreamURL != "" {
+		ghOpts.UpstreamURL = UpstreamURL
+	}
+
+	ghOpts = auto.GithubDefaults(ghOpts)
+	logger.Log(ctx, slog.LevelDebug, "github options", slog.Group("github",
+		slog.String("owner", ghOpts.Owner),
+		slog.String("repo", ghOpts.Repo),
+		slog.String("upstreamURL", ghOpts.UpstreamURL),
+		slog.String("apiURL", ghOpts.APIURL),
+	))
+
+	return ghOpts
+}
+
 func getRepoRoot() {
 	ctx := context.Background()
 
@@ -1054,6 +1435,12 @@ func checkRCTagsAndVersions(c *cli.Context) {
 func lifecycleStatus(c *cli.Context) {
 	ctx := context.Background()
 
+	if OutputFormat != "" {
+		if err := lifecycle.CheckOutputFormat(OutputFormat); err != nil {
+			logger.Fatal(ctx, err.Error())
+		}
+	}
+
 	// Initialize dependencies with branch-version and current chart
 	logger.Log(ctx, slog.LevelDebug, "initialize lifecycle-status")
 
@@ -1063,13 +1450,102 @@ func lifecycleStatus(c *cli.Context) {
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
 	}
+	lifeCycleDep.Git.UpstreamURL = parseGithubOptions(ctx).UpstreamURL
 
 	// Execute lifecycle status check and save the logs
 	logger.Log(ctx, slog.LevelDebug, "checking lifecycle status and saving logs")
-	_, err = lifeCycleDep.CheckLifecycleStatusAndSave(ctx, CurrentChart)
+	status, err := lifeCycleDep.CheckLifecycleStatusAndSave(ctx, CurrentChart)
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to check lifecycle status: %w", err).Error())
 	}
+
+	if OutputFormat != "" {
+		if err := status.Write(os.Stdout, OutputFormat); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to print lifecycle status: %w", err).Error())
+		}
+	}
+}
+
+func lifecycleDiff(c *cli.Context) {
+	ctx := context.Background()
+
+	if c.NArg() != 2 {
+		logger.Fatal(ctx, "lifecycle-diff expects 2 arguments: <before-state.json> <after-state.json>")
+	}
+
+	before, err := lifecycle.LoadStateFile(c.Args().Get(0))
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to load state: %w", err).Error())
+	}
+	after, err := lifecycle.LoadStateFile(c.Args().Get(1))
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to load state: %w", err).Error())
+	}
+
+	format := OutputFormat
+	if format == "" {
+		format = lifecycle.OutputTable
+	}
+	if err := lifecycle.WriteStateChanges(os.Stdout, format, lifecycle.DiffStates(before, after)); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to print lifecycle diff: %w", err).Error())
+	}
+}
+
+func lifecyclePrune(c *cli.Context) {
+	ctx := context.Background()
+
+	// Initialize dependencies with branch-version and current chart
+	logger.Log(ctx, slog.LevelDebug, "initialize lifecycle-prune")
+
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+	lifeCycleDep, err := lifecycle.InitDependencies(ctx, rootFs, RepoRoot, c.String("branch-version"), CurrentChart, false)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
+	}
+
+	prune := auto.InitPrune(ctx, lifeCycleDep, CurrentChart)
+
+	if DryRun {
+		plan, err := prune.Plan(ctx)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to plan lifecycle prune: %w", err).Error())
+		}
+		if err := plan.Write(os.Stdout, PorcelainMode); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to print lifecycle prune plan: %w", err).Error())
+		}
+		return
+	}
+
+	if err := prune.Execute(ctx); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to prune lifecycle: %w", err).Error())
+	}
+}
+
+func lifecycleMatrix(c *cli.Context) {
+	ctx := context.Background()
+
+	format := OutputFormat
+	if format == "" {
+		format = lifecycle.OutputMarkdown
+	}
+
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+	lifeCycleDep, err := lifecycle.InitDependencies(ctx, rootFs, RepoRoot, c.String("branch-version"), CurrentChart, false)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
+	}
+	lifeCycleDep.Git.UpstreamURL = parseGithubOptions(ctx).UpstreamURL
+
+	matrix, err := lifeCycleDep.LifecycleMatrix(ctx, CurrentChart)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to build lifecycle matrix: %w", err).Error())
+	}
+	if err := matrix.Write(os.Stdout, format); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to print lifecycle matrix: %w", err).Error())
+	}
+	logger.Log(ctx, slog.LevelInfo, "lifecycle matrix issues", slog.Any("issues", matrix.Issues()))
 }
 
 func autoForwardPort(c *cli.Context) {
@@ -1089,20 +1565,55 @@ func autoForwardPort(c *cli.Context) {
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
 	}
+	ghOpts := parseGithubOptions(ctx)
+	lifeCycleDep.Git.UpstreamURL = ghOpts.UpstreamURL
 
-	// Execute lifecycle status check and save the logs
-	logger.Log(ctx, slog.LevelInfo, "checking lifecycle status and saving logs")
-	status, err := lifeCycleDep.CheckLifecycleStatusAndSave(ctx, CurrentChart)
+	// Clean up the branches the failed run left behind before checking the lifecycle status again
+	if Resume && !DryRun {
+		if err := auto.PrepareForwardPortResume(ctx, lifeCycleDep.Git, lifeCycleDep.VR.BranchVersion); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to prepare forward port resume: %w", err).Error())
+		}
+	}
+
+	// Execute lifecycle status check and save the logs; on dry-run nothing is saved
+	var status *lifecycle.Status
+	if DryRun {
+		logger.Log(ctx, slog.LevelInfo, "checking lifecycle status")
+		status, err = lifeCycleDep.CheckLifecycleStatus(ctx, CurrentChart)
+	} else {
+		logger.Log(ctx, slog.LevelInfo, "checking lifecycle status and saving logs")
+		status, err = lifeCycleDep.CheckLifecycleStatusAndSave(ctx, CurrentChart)
+	}
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to check lifecycle status: %w", err).Error())
 	}
 
+	// Pull requests are only opened when a token is available or printed on dry-run
+	var gh *auto.Github
+	if GithubToken != "" || PRDryRun {
+		gh, err = auto.NewGithub(ctx, GithubToken, ghOpts)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to create github client: %w", err).Error())
+		}
+	}
+
 	// Execute forward port with loaded information from status
-	fp, err := auto.CreateForwardPortStructure(ctx, lifeCycleDep, status.AssetsToBeForwardPorted, ForkURL)
+	fp, err := auto.CreateForwardPortStructure(ctx, lifeCycleDep, status.AssetsToBeForwardPorted, parseChartGroups(ctx), ForkURL, ghOpts, gh, PRDryRun, Resume)
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to prepare forward port: %w", err).Error())
 	}
 
+	if DryRun {
+		plan, err := fp.Plan(ctx, CurrentChart)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to plan forward port: %w", err).Error())
+		}
+		if err := plan.Write(os.Stdout, PorcelainMode); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to print forward port plan: %w", err).Error())
+		}
+		return
+	}
+
 	err = fp.ExecuteForwardPort(ctx, CurrentChart)
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to execute forward port: %w", err).Error())
@@ -1126,32 +1637,51 @@ func release(c *cli.Context) {
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
 	}
+	dependencies.Git.UpstreamURL = parseGithubOptions(ctx).UpstreamURL
 
 	status, err := lifecycle.LoadState(rootFs)
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("could not load state; please run lifecycle-status before this command: %w", err).Error())
 	}
 
-	release, err := auto.InitRelease(ctx, dependencies, status, ChartVersion, CurrentChart, ForkURL)
+	// the charts of the group with the same version are released along with the chart
+	releases, err := auto.InitGroupRelease(ctx, dependencies, status, ChartVersion, CurrentChart, ForkURL, parseChartGroups(ctx))
 	if err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to initialize release: %w", err).Error())
 	}
 
-	if err := release.PullAsset(); err != nil {
+	if DryRun {
+		plan, err := auto.PlanGroupRelease(ctx, rootFs, releases)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to plan release: %w", err).Error())
+		}
+		if err := plan.Write(os.Stdout, PorcelainMode); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to print release plan: %w", err).Error())
+		}
+		return
+	}
+
+	if err := dependencies.CheckCleanTree(ctx); err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to execute release: %w", err).Error())
 	}
 
-	// Unzip Assets: ASSET=<chart>/<chart>-<version.tgz make unzip
-	CurrentAsset = release.Chart + "/" + release.AssetTgz
-	unzipAssets(c)
+	for _, release := range releases {
+		if err := release.PullAsset(); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to execute release: %w", err).Error())
+		}
 
-	if err := release.PullIcon(ctx, rootFs); err != nil {
-		logger.Fatal(ctx, fmt.Errorf("failed to pull icon: %w", err).Error())
-	}
+		// Unzip Assets: ASSET=<chart>/<chart>-<version.tgz make unzip
+		CurrentAsset = release.Chart + "/" + release.AssetTgz
+		unzipAssets(c)
 
-	// update release.yaml
-	if err := release.UpdateReleaseYaml(ctx, true); err != nil {
-		logger.Fatal(ctx, fmt.Errorf("failed to update release.yaml: %w", err).Error())
+		if err := release.PullIcon(ctx, rootFs); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to pull icon: %w", err).Error())
+		}
+
+		// update release.yaml
+		if err := release.UpdateReleaseYaml(ctx, true); err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to update release.yaml: %w", err).Error())
+		}
 	}
 
 	// make index
@@ -1186,7 +1716,12 @@ func validateRelease(c *cli.Context) {
 		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
 	}
 
-	if err := auto.ValidatePullRequest(ctx, GithubToken, PullRequest, dependencies); err != nil {
+	gh, err := auto.NewGithub(ctx, GithubToken, parseGithubOptions(ctx))
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to create github client: %w", err).Error())
+	}
+
+	if err := auto.ValidatePullRequest(ctx, gh, PullRequest, dependencies); err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to validate pull request: %w", err).Error())
 	}
 }
@@ -1208,6 +1743,251 @@ func compareIndexFiles(c *cli.Context) {
 	logger.Log(ctx, slog.LevelInfo, "index.yaml files are the same at git repository and charts.rancher.io")
 }
 
+func listChartGroups(c *cli.Context) {
+	ctx := context.Background()
+
+	getRepoRoot()
+	groups := parseChartGroups(ctx)
+	if err := groups.Write(os.Stdout); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to print chart groups: %w", err).Error())
+	}
+
+	indexFile, err := helm.OpenIndexYaml(ctx, filesystem.GetFilesystem(RepoRoot))
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to open index.yaml: %w", err).Error())
+	}
+	indexCharts := make([]string, 0, len(indexFile.Entries))
+	for chart := range indexFile.Entries {
+		indexCharts = append(indexCharts, chart)
+	}
+
+	if uncovered := groups.Uncovered(indexCharts); len(uncovered) > 0 {
+		fmt.Fprintf(os.Stdout, "\nnot covered by any group: %s\n", strings.Join(uncovered, ", "))
+		logger.Log(ctx, slog.LevelWarn, "charts not covered by any chart group", slog.Any("charts", uncovered))
+	}
+}
+
+func validateUpgradePaths(c *cli.Context) {
+	ctx := context.Background()
+
+	format := OutputFormat
+	if format == "" {
+		format = lifecycle.OutputTable
+	}
+	if err := lifecycle.CheckOutputFormat(format); err != nil {
+		logger.Fatal(ctx, err.Error())
+	}
+
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+	// all charts are loaded, so the CRD charts can be checked against their main chart
+	lifeCycleDep, err := lifecycle.InitDependencies(ctx, rootFs, RepoRoot, c.String("branch-version"), "", false)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("encountered error while initializing dependencies: %w", err).Error())
+	}
+
+	violations := lifeCycleDep.ValidateUpgradePaths(CurrentChart)
+	if err := lifecycle.WriteUpgradePathViolations(os.Stdout, format, violations); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to print upgrade path violations: %w", err).Error())
+	}
+	if len(violations) > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("found %d upgrade path violations", len(violations)))
+	}
+
+	logger.Log(ctx, slog.LevelInfo, "upgrade paths are valid")
+}
+
+func validateAnnotations(c *cli.Context) {
+	ctx := context.Background()
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+
+	if failed := checkAnnotations(ctx, rootFs, CurrentChart); failed > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("%d invalid catalog annotations", failed))
+	}
+	logger.Log(ctx, slog.LevelInfo, "catalog annotations are valid")
+}
+
+// checkAnnotations validates the catalog annotations of the targets, each a <chart>, a <chart>/<version> or "" for every chart,
+// against version_rules.json, if any, logs every violation and returns the number of violations
+func checkAnnotations(ctx context.Context, rootFs billy.Filesystem, targets ...string) int {
+	versionRules, err := lifecycle.LoadVersionRules(ctx, rootFs)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to load version rules: %w", err).Error())
+	}
+	if versionRules == nil {
+		logger.Log(ctx, slog.LevelWarn, "no version rules, the rancher-version ranges are not checked against the branch versions")
+	}
+
+	failed := 0
+	for _, target := range targets {
+		violations, err := validate.ValidateAnnotations(ctx, rootFs, versionRules, target)
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to validate annotations: %w", err).Error())
+		}
+		for _, violation := range violations {
+			logger.Log(ctx, slog.LevelError, "invalid catalog annotation", slog.String("chart", violation.Chart), slog.String("version", violation.Version),
+				slog.String("annotation", violation.Annotation), slog.String("message", violation.Message))
+		}
+		failed += len(violations)
+	}
+	return failed
+}
+
+// releaseTargets returns the <chart>/<version> of every chart version in release.yaml, sorted by chart
+func releaseTargets(releaseOptions options.ReleaseOptions) []string {
+	chartNames := make([]string, 0, len(releaseOptions))
+	for chart := range releaseOptions {
+		chartNames = append(chartNames, chart)
+	}
+	sort.Strings(chartNames)
+
+	var targets []string
+	for _, chart := range chartNames {
+		for _, version := range releaseOptions[chart] {
+			targets = append(targets, chart+"/"+version)
+		}
+	}
+	return targets
+}
+
+func renderCheck(c *cli.Context) {
+	ctx := context.Background()
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+
+	chartsScriptOptions := parseScriptOptions(ctx)
+	releaseOptions, err := options.LoadReleaseOptionsFromFile(ctx, rootFs, path.RepositoryReleaseYaml)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("unable to unmarshall release.yaml: %w", err).Error())
+	}
+	if CurrentChart != "" {
+		releaseOptions = filterReleaseOptions(releaseOptions, CurrentChart)
+	}
+	if len(releaseOptions) == 0 {
+		logger.Log(ctx, slog.LevelInfo, "no charts to render in release.yaml")
+		return
+	}
+
+	if err := filesystem.RemoveAll(rootFs, path.RenderCheckDir); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to clean up %s: %w", path.RenderCheckDir, err).Error())
+	}
+	results, err := validate.RenderCharts(ctx, rootFs, releaseOptions, chartsScriptOptions.RenderCheckOptions, path.RenderCheckDir)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to render charts: %w", err).Error())
+	}
+	if failed := logRenderChecks(ctx, results); failed > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("%d chart renders failed", failed))
+	}
+
+	logger.Log(ctx, slog.LevelInfo, "all charts rendered", slog.Int("renders", len(results)), slog.String("manifests", path.RenderCheckDir))
+}
+
+func schemaCheck(c *cli.Context) {
+	ctx := context.Background()
+	getRepoRoot()
+	rootFs := filesystem.GetFilesystem(RepoRoot)
+
+	chartsScriptOptions := parseScriptOptions(ctx)
+	releaseOptions, err := options.LoadReleaseOptionsFromFile(ctx, rootFs, path.RepositoryReleaseYaml)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("unable to unmarshall release.yaml: %w", err).Error())
+	}
+	if CurrentChart != "" {
+		releaseOptions = filterReleaseOptions(releaseOptions, CurrentChart)
+	}
+	if len(releaseOptions) == 0 {
+		logger.Log(ctx, slog.LevelInfo, "no charts to check in release.yaml")
+		return
+	}
+
+	violations, err := validate.CheckSchemas(ctx, rootFs, releaseOptions, chartsScriptOptions.RenderCheckOptions)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to check schemas: %w", err).Error())
+	}
+	if failed := logSchemaViolations(ctx, violations); failed > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("%d schema violations found", failed))
+	}
+
+	logger.Log(ctx, slog.LevelInfo, "all rendered manifests are valid", slog.Int("warnings", len(violations)))
+}
+
+// filterReleaseOptions keeps the chart, or the chart version if chart is <chart>/<version>, of the release options
+func filterReleaseOptions(releaseOptions options.ReleaseOptions, chart string) options.ReleaseOptions {
+	chartName, version, _ := strings.Cut(chart, "/")
+	filtered := options.ReleaseOptions{}
+	for _, v := range releaseOptions[chartName] {
+		if version == "" || v == version {
+			filtered[chartName] = append(filtered[chartName], v)
+		}
+	}
+	return filtered
+}
+
+// logCRDCompatibility logs every incompatible CRD change and returns the number of unacknowledged ones
+func logCRDCompatibility(ctx context.Context, results []validate.CRDCompatibility) int {
+	incompatible := 0
+	for _, result := range results {
+		for _, incompatibility := range result.Incompatibilities {
+			logger.Log(ctx, slog.LevelWarn, "incompatible CRD change", slog.String("chart", result.Chart), slog.String("version", result.Version),
+				slog.String("previousVersion", result.PreviousVersion), slog.String("change", incompatibility.String()))
+		}
+		for _, incompatibility := range result.Unacknowledged {
+			logger.Log(ctx, slog.LevelError, "unacknowledged incompatible CRD change", slog.String("chart", result.Chart), slog.String("version", result.Version),
+				slog.String("previousVersion", result.PreviousVersion), slog.String("key", incompatibility.Key()))
+			incompatible++
+		}
+	}
+	return incompatible
+}
+
+// logValuesCompatibility logs every values change and returns the number of unacknowledged removals
+func logValuesCompatibility(ctx context.Context, results []validate.ValuesCompatibility) int {
+	removals := 0
+	for _, result := range results {
+		for _, change := range result.Changes {
+			logger.Log(ctx, slog.LevelDebug, "values change", slog.String("chart", result.Chart), slog.String("version", result.Version),
+				slog.String("previousVersion", result.PreviousVersion), slog.String("change", change.String()))
+		}
+		for _, change := range result.Unacknowledged {
+			logger.Log(ctx, slog.LevelError, "values key removed", slog.String("chart", result.Chart), slog.String("version", result.Version),
+				slog.String("previousVersion", result.PreviousVersion), slog.String("change", change.String()))
+			removals++
+		}
+	}
+	return removals
+}
+
+// logRenderChecks logs every render and returns the number of failed ones
+func logRenderChecks(ctx context.Context, results []validate.RenderCheck) int {
+	failed := 0
+	for _, result := range results {
+		attrs := []slog.Attr{slog.String("chart", result.Chart), slog.String("version", result.Version),
+			slog.String("kubeVersion", result.KubeVersion), slog.String("values", result.Values)}
+		if result.Error != "" {
+			failed++
+			logger.Log(ctx, slog.LevelError, "failed to render chart", append(attrs, slog.String("error", result.Error))...)
+			continue
+		}
+		logger.Log(ctx, slog.LevelInfo, "rendered chart", append(attrs, slog.String("manifests", result.Manifests))...)
+	}
+	return failed
+}
+
+// logSchemaViolations logs every schema violation and returns the number of errors
+func logSchemaViolations(ctx context.Context, violations []validate.SchemaViolation) int {
+	failed := 0
+	for _, violation := range violations {
+		if violation.Level == slog.LevelError {
+			failed++
+		}
+		logger.Log(ctx, violation.Level, violation.Message, slog.String("chart", violation.Chart), slog.String("version", violation.Version),
+			slog.String("values", violation.Values), slog.String("template", violation.Template), slog.String("object", violation.Object),
+			slog.String("kubeVersions", strings.Join(violation.KubeVersions, ",")))
+	}
+	return failed
+}
+
 func chartBump(c *cli.Context) {
 	ctx := context.Background()
 
@@ -1239,6 +2019,75 @@ func chartBump(c *cli.Context) {
 	if err := bump.BumpChart(ctx, OverrideVersion, MultiRC, NewChart, IsPrimeChart); err != nil {
 		logger.Fatal(ctx, fmt.Errorf("failed to bump: %w", err).Error())
 	}
+
+	if !CreatePR && !PRDryRun {
+		return
+	}
+	if GithubToken == "" && !PRDryRun {
+		logger.Fatal(ctx, "GH_TOKEN environment variable must be set to open the chart bump pull request")
+	}
+
+	gh, err := auto.NewGithub(ctx, GithubToken, parseGithubOptions(ctx))
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to create github client: %w", err).Error())
+	}
+
+	if _, err := bump.OpenPullRequest(ctx, gh, ForkURL, PRDryRun); err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to open pull request: %w", err).Error())
+	}
+}
+
+func chartBumpBatch(c *cli.Context) {
+	ctx := context.Background()
+
+	if Branch == "" || OverrideVersion == "" {
+		logger.Fatal(ctx, fmt.Sprintf("must provide values for Branch[%s] and OverrideVersion[%s]", Branch, OverrideVersion))
+	}
+	if OverrideVersion != "patch" && OverrideVersion != "minor" && OverrideVersion != "auto" {
+		logger.Fatal(ctx, "OverrideVersion must be set to either patch, minor, or auto")
+	}
+	if (CreatePR || PRDryRun) && !BranchPerPackage {
+		logger.Fatal(ctx, "--create-pr and --pr-dry-run require --branch-per-package")
+	}
+
+	opts := auto.BatchBumpOptions{
+		VersionOverride:  OverrideVersion,
+		MultiRC:          MultiRC,
+		IsPrimeChart:     IsPrimeChart,
+		BranchPerPackage: BranchPerPackage,
+		ForkURL:          ForkURL,
+		PRDryRun:         PRDryRun,
+	}
+	if CurrentPackage != "" {
+		opts.Packages = strings.Split(CurrentPackage, ",")
+	}
+
+	if CreatePR || PRDryRun {
+		if GithubToken == "" && !PRDryRun {
+			logger.Fatal(ctx, "GH_TOKEN environment variable must be set to open the chart bump pull requests")
+		}
+		gh, err := auto.NewGithub(ctx, GithubToken, parseGithubOptions(ctx))
+		if err != nil {
+			logger.Fatal(ctx, fmt.Errorf("failed to create github client: %w", err).Error())
+		}
+		opts.GH = gh
+	}
+
+	ChartsScriptOptionsFile = path.ConfigurationYamlFile
+	chartsScriptOptions := parseScriptOptions(ctx)
+
+	output, err := auto.BatchBump(ctx, RepoRoot, Branch, chartsScriptOptions, opts)
+	if err != nil {
+		logger.Fatal(ctx, fmt.Errorf("failed to bump packages: %w", err).Error())
+	}
+
+	for _, result := range output.Results {
+		logger.Log(ctx, slog.LevelInfo, "chart bump", slog.String("package", result.Package), slog.String("status", result.Status),
+			slog.String("oldVersion", result.OldVersion), slog.String("newVersion", result.NewVersion), slog.String("error", result.Error))
+	}
+	if failed := output.Failed(); len(failed) > 0 {
+		logger.Fatal(ctx, fmt.Sprintf("failed to bump packages: %s; see %s", strings.Join(failed, ", "), path.BumpBatchFile))
+	}
 }
 
 func updateOCIRegistry(c *cli.Context) {
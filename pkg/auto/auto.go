@@ -2,19 +2,21 @@ package auto
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver"
+	"github.com/go-git/go-billy/v5"
 	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
 	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/zip"
 )
 
 /**
@@ -24,12 +26,16 @@ import (
 
 // ForwardPort holds the data and methods to forward-port charts
 type ForwardPort struct {
-	yqPath                  string
 	git                     *git.Git
+	rootFs                  billy.Filesystem
 	VR                      *lifecycle.VersionRules
 	assetsToBeForwardPorted map[string][]lifecycle.Asset
+	groups                  ChartGroups // pull requests are organized by chart group
 	pullRequests            map[string]PullRequest
 	forkRemoteURL           string
+	gh                      *Github // nil when pull requests should not be opened
+	prDryRun                bool    // print the pull requests payloads instead of opening them
+	journal                 *Journal
 }
 
 // PullRequest represents a pull request to be created for each chart separately
@@ -40,58 +46,26 @@ type PullRequest struct {
 
 // Command holds the necessary information to forward-port a chart
 type Command struct {
-	Chart   string   // The chart to forward-port
-	Version string   // The version to forward-port
-	Command []string // The command to run to forward-port
+	Chart   string // The chart to forward-port
+	Version string // The version to forward-port
 }
 
-const (
-	chartsRepoURL  = "https://github.com/rancher/charts"
-	chartsRepoName = "charts"
-)
-
 /**
 * These are the common methods and functions
 * to be used by the automations to forward-port and release charts.
 **/
 
-// whichYQCommand will return the PATH with the yq directory appended to it,
-// if yq command is found in the system, otherwise it will return an error.
-func whichYQCommand(ctx context.Context) (string, error) {
-	cmd := exec.Command("which", "yq")
-	output, err := cmd.Output() // Capture the output instead of printing it
-	if err != nil {
-		logger.Log(ctx, slog.LevelError, "error while getting yq path", logger.Err(err))
-		return "", err
-	}
-	yqPath := strings.TrimSpace(string(output)) // Convert output to string and trim whitespace
-	if yqPath == "" {
-		errYq := errors.New("yq command not found")
-		logger.Log(ctx, slog.LevelError, "yq command not found", logger.Err(errYq))
-		return "", errYq
-	}
-	// Extract the directory from the yqPath and append the yq directory to the PATH
-	yqDirPath := filepath.Dir(yqPath)
-	currentPath := os.Getenv("PATH")
-	newPath := yqDirPath + ":" + currentPath
-	return newPath, nil
-}
-
-// createForwardPortCommands will create the forward port script commands for each asset and version,
+// createForwardPortCommands will create the forward port commands for each asset and version,
 // and return a sorted slice of commands
-func (f *ForwardPort) createForwardPortCommands(ctx context.Context, chart string) ([]Command, error) {
+func (f *ForwardPort) createForwardPortCommands(ctx context.Context, chart string) []Command {
 
 	commands := make([]Command, 0)
 	for asset, versions := range f.assetsToBeForwardPorted {
-		if chart != "" && !strings.HasPrefix(asset, chart) {
+		if chart != "" && !strings.HasPrefix(asset, chart) && f.groups.Main(asset) != f.groups.Main(chart) {
 			continue
 		}
 		for _, version := range versions {
-			command, err := f.writeMakeCommand(asset, version.Version)
-			if err != nil {
-				return nil, err
-			}
-			commands = append(commands, command)
+			commands = append(commands, Command{Chart: asset, Version: version.Version})
 		}
 	}
 	// Sorting the commands slice by the Chart field in alphabetical order
@@ -113,98 +87,49 @@ func (f *ForwardPort) createForwardPortCommands(ctx context.Context, chart strin
 		return commands[i].Chart < commands[j].Chart
 	})
 
-	return commands, nil
+	return commands
 }
 
-// writeMakeCommand will write the forward-port command for the given asset and version
-func (f *ForwardPort) writeMakeCommand(asset, version string) (Command, error) {
-	/**
-	* make forward-port
-	* CHART=rancher-provisioning-capi
-	* VERSION=100.0.0+up0.0.1
-	* BRANCH=dev-v2.9
-	* UPSTREAM=upstream
-	 */
-
-	upstreamRemote, ok := f.git.Remotes["https://github.com/rancher/charts"]
-	if !ok {
-		errNoUpstreamRemote := errors.New("upstream remote not found; you need to have the upstream remote configured in your git repository (https://github.com/rancher/charts)")
-		return Command{}, errNoUpstreamRemote
-	}
-	commands := []string{
-		"make",
-		"forward-port",
-		"CHART=" + asset,
-		"VERSION=" + version,
-		"BRANCH=" + f.VR.DevBranch,
-		"UPSTREAM=" + upstreamRemote,
+// forwardPortAsset will forward-port the given asset version from the development branch into the current branch:
+//   - checkout assets/<chart>/<chart>-<version>.tgz (and its icon) from the development branch
+//   - unpack the asset into charts/<chart>/<version>
+//   - append the version to release.yaml
+//   - update the index.yaml
+func (f *ForwardPort) forwardPortAsset(ctx context.Context, chart, version string) error {
+	logger.Log(ctx, slog.LevelInfo, "forward-porting", slog.String("chart", chart), slog.String("version", version))
+
+	r := &Release{
+		git:          f.git,
+		VR:           f.VR,
+		Chart:        chart,
+		ChartVersion: version,
 	}
+	r.AssetPath, r.AssetTgz = mountAssetVersionPath(chart, version)
 
-	return Command{Chart: asset, Command: commands, Version: version}, nil
-}
-
-// checkIfChartChanged will check if the chart has changed from the last chart.
-// It will return true if the chart has changed, otherwise it will return false.
-// If the chart has changed, it will check for special cases like (fleet and neuvector), and CRD dependencies.
-func checkIfChartChanged(lastChart, currentChart string) bool {
-	// Check if the current chart is different from the last chart
-	if lastChart == currentChart {
-		return false
+	if err := r.PullAsset(); err != nil {
+		return fmt.Errorf("failed to pull asset %s: %w", r.AssetPath, err)
 	}
 
-	// Check for special edge cases
-	return checkEdgeCasesIfChartChanged(lastChart, currentChart)
-}
-
-// checkEdgeCasesIfChartChanged will check for special cases like:
-//
-//	-CRD dependencies
-//	-fleet
-//	-neuvector
-//	-rancher-alerting-driver + rancher-aks-operator
-//	-rancher-gke-operator + rancher-gatekeeper
-//
-// It will return true if the chart changed, false otherwise
-func checkEdgeCasesIfChartChanged(lastChart, currentChart string) bool {
-
-	lastParts := strings.Split(lastChart, "-")
-	currentParts := strings.Split(currentChart, "-")
-
-	minLength := 0 // compare which chart is shorter in name (last or current)
-	if len(currentParts) < len(lastParts) {
-		minLength = len(currentParts)
-	} else {
-		minLength = len(lastParts)
+	if err := zip.DumpAssets(ctx, f.git.Dir, chart+"/"+r.AssetTgz); err != nil {
+		return err
 	}
 
-	equalCounter := 0
-	for i := 0; i < minLength; i++ {
-		if lastParts[i] == currentParts[i] {
-			equalCounter++
-		}
+	if err := r.PullIcon(ctx, f.rootFs); err != nil {
+		return err
 	}
 
-	if equalCounter == 0 {
-		return true
-	} else if equalCounter == 1 && strings.HasPrefix("rancher-", lastParts[0]) && minLength < 3 {
-		return true
-	} else if equalCounter == 1 && minLength >= 3 {
-		return true
+	releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, f.rootFs, path.RepositoryReleaseYaml)
+	if err != nil {
+		return err
 	}
-
-	// treat operators edge cases
-	// rancher-aks-operator && rancher-gke-operator || rancher-aks-operator-crd && rancher-gke-operator-crd
-	if (equalCounter == 2 && minLength == 3) || (equalCounter == 3 && minLength == 4) {
-		if lastParts[0] == "rancher" && currentParts[0] == "rancher" {
-			if lastParts[2] == "operator" && currentParts[2] == "operator" {
-				if lastParts[1] != currentParts[1] {
-					return true
-				}
-			}
-		}
+	if releaseOpts == nil {
+		releaseOpts = options.ReleaseOptions{}
+	}
+	if err := releaseOpts.Append(chart, version).WriteToFile(ctx, f.rootFs, path.RepositoryReleaseYaml); err != nil {
+		return err
 	}
 
-	return false
+	return helm.CreateOrUpdateHelmIndex(ctx, f.rootFs)
 }
 
 // createNewBranchToForwardPort will create a new branch to forward-port the assets
@@ -241,22 +166,3 @@ func prepareReleaseYaml(ctx context.Context) error {
 	logger.Log(ctx, slog.LevelInfo, "content of release.yaml erased successfully.")
 	return nil
 }
-
-// executeCommand will execute the given command using the yqPath if needed
-func executeCommand(ctx context.Context, command []string, yqPath string) error {
-	// Prepare the command
-	cmd := exec.Command(command[0], command[1:]...)
-	cmd.Env = append(os.Environ(), "PATH="+yqPath)
-
-	// Set the command's stdout and stderr to the current process's stdout and stderr
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	// Execute it
-	if err := cmd.Run(); err != nil {
-		errCommand := fmt.Errorf("error while executing command: %s; err: %w", command, err)
-		logger.Log(ctx, slog.LevelError, "error while executing command", logger.Err(errCommand))
-		return errCommand
-	}
-	return nil
-}
@@ -0,0 +1,453 @@
+package auto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/chartvalues"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"helm.sh/helm/v3/pkg/chart"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// Changelog holds what changed between the previous and the new version of the charts of a bump
+type Changelog struct {
+	Chart      string
+	NewVersion string
+	// Upstream is nil when the upstream is not a git repository
+	Upstream *UpstreamLog
+	Charts   []ChartChangelog
+	// Notes about the parts of the changelog that could not be generated
+	Notes []string
+
+	// compareErrs are the errors of the charts that could not be compared with their previous released version
+	compareErrs []error
+}
+
+// UpstreamLog is the git log of the upstream chart between the previous and the new upstream version
+type UpstreamLog struct {
+	URL        string
+	Branch     string
+	OldVersion string
+	NewVersion string
+	// Commits on the chart directory, "<short hash> <subject>", newest first
+	Commits []string
+}
+
+// ChartChangelog holds the changes of a single chart between two versions
+type ChartChangelog struct {
+	Chart        string
+	OldVersion   string
+	NewVersion   string
+	AppVersion   *Change
+	Dependencies []Change
+	// Values are the changes of the values.yaml keys and of the values.schema.json properties
+	Values []chartvalues.Change
+	Images []Change
+	CRDs   []Change
+	// CRDIncompatibilities are the CRD changes that can break existing custom resources
+	CRDIncompatibilities []crds.Incompatibility
+}
+
+// Change is a named value that changed from Old to New; Old is empty when added and New is empty when removed
+type Change struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// generateChangelog compares the assets of the latest released version of each target chart with the bumped ones
+// and lists the upstream commits of the bump. It does not fail: what could not be compared is added to the notes,
+// and the charts that could not be compared then fail checkRemovedValues and checkCRDIncompatibilities.
+func (b *Bump) generateChangelog(ctx context.Context) *Changelog {
+	logger.Log(ctx, slog.LevelInfo, "generate bump changelog")
+
+	newVersion := b.Pkg.AutoGeneratedBumpVersion.String()
+	changelog := &Changelog{Chart: b.target.main, NewVersion: newVersion}
+
+	for _, chartName := range b.target.additional {
+		versions := b.assetsVersionsMap[chartName]
+		if len(versions) == 0 {
+			changelog.Notes = append(changelog.Notes, fmt.Sprintf("%s is a new chart, there is no previous version to compare", chartName))
+			continue
+		}
+		oldVersion := versions[0].Version
+
+		chartChangelog, err := compareChartAssets(b.rootFs, chartName, oldVersion, newVersion)
+		if err != nil {
+			logger.Log(ctx, slog.LevelWarn, "failed to compare chart versions", slog.String("chart", chartName), logger.Err(err))
+			changelog.Notes = append(changelog.Notes, fmt.Sprintf("failed to compare %s %s with %s: %v", chartName, oldVersion, newVersion, err))
+			changelog.compareErrs = append(changelog.compareErrs, fmt.Errorf("failed to compare %s %s with %s: %w", chartName, oldVersion, newVersion, err))
+			continue
+		}
+		changelog.Charts = append(changelog.Charts, chartChangelog)
+	}
+
+	upstream := b.Pkg.Upstream.GetOptions()
+	if !strings.HasSuffix(upstream.URL, ".git") || upstream.ChartRepoBranch == nil || b.Pkg.UpstreamChartVersion == nil {
+		return changelog
+	}
+	versions := b.assetsVersionsMap[b.target.main]
+	if len(versions) == 0 {
+		return changelog
+	}
+	_, oldUpstreamVersion, found := strings.Cut(versions[0].Version, "+up")
+	if !found {
+		return changelog
+	}
+
+	subdirectory := ""
+	if upstream.Subdirectory != nil {
+		subdirectory = *upstream.Subdirectory
+	}
+	commits, err := upstreamGitLog(ctx, upstream.URL, *upstream.ChartRepoBranch, subdirectory, oldUpstreamVersion, *b.Pkg.UpstreamChartVersion)
+	if err != nil {
+		logger.Log(ctx, slog.LevelWarn, "failed to list upstream commits", slog.String("url", upstream.URL), logger.Err(err))
+		changelog.Notes = append(changelog.Notes, fmt.Sprintf("failed to list the upstream commits of %s: %v", upstream.URL, err))
+		return changelog
+	}
+	changelog.Upstream = &UpstreamLog{
+		URL:        upstream.URL,
+		Branch:     *upstream.ChartRepoBranch,
+		OldVersion: oldUpstreamVersion,
+		NewVersion: *b.Pkg.UpstreamChartVersion,
+		Commits:    commits,
+	}
+	return changelog
+}
+
+// checkRemovedValues fails the bump if the values of a chart lost keys, or values.schema.json properties, since the
+// previous released version that are not acknowledged under removedValues in package.yaml, or if a chart could not be compared
+func (b *Bump) checkRemovedValues(ctx context.Context) error {
+	if err := errors.Join(b.changelog.compareErrs...); err != nil {
+		return err
+	}
+
+	var removals []string
+	for _, chartChangelog := range b.changelog.Charts {
+		for _, change := range chartvalues.Unacknowledged(chartChangelog.Values, b.Pkg.RemovedValues[chartChangelog.Chart]) {
+			logger.Log(ctx, slog.LevelError, "values key removed", slog.String("chart", chartChangelog.Chart), slog.String("change", change.String()))
+			removals = append(removals, fmt.Sprintf("%s: %s", chartChangelog.Chart, change))
+		}
+	}
+	if len(removals) > 0 {
+		return fmt.Errorf("values keys removed since the previous released version, acknowledge them under removedValues in package.yaml if intended: %s",
+			strings.Join(removals, "; "))
+	}
+	return nil
+}
+
+// checkCRDIncompatibilities fails the bump if the CRDs of a chart changed since the previous released version in a way
+// that can break existing custom resources and the change is not acknowledged under crdIncompatibilities in package.yaml,
+// or if a chart could not be compared
+func (b *Bump) checkCRDIncompatibilities(ctx context.Context) error {
+	if err := errors.Join(b.changelog.compareErrs...); err != nil {
+		return err
+	}
+
+	var incompatibilities []string
+	for _, chartChangelog := range b.changelog.Charts {
+		for _, incompatibility := range crds.Unacknowledged(chartChangelog.CRDIncompatibilities, b.Pkg.CRDIncompatibilities[chartChangelog.Chart]) {
+			logger.Log(ctx, slog.LevelError, "incompatible CRD change", slog.String("chart", chartChangelog.Chart), slog.String("change", incompatibility.String()))
+			incompatibilities = append(incompatibilities, fmt.Sprintf("%s: %s", chartChangelog.Chart, incompatibility))
+		}
+	}
+	if len(incompatibilities) > 0 {
+		return fmt.Errorf("incompatible CRD changes since the previous released version, acknowledge them under crdIncompatibilities in package.yaml if intended: %s",
+			strings.Join(incompatibilities, "; "))
+	}
+	return nil
+}
+
+// writeChangelog writes the changelog as markdown next to the bump version file
+func (b *Bump) writeChangelog(ctx context.Context) error {
+	if b.changelog == nil {
+		return nil
+	}
+	logger.Log(ctx, slog.LevelDebug, "write bump changelog", slog.String("file", path.BumpChangelogFile))
+	return os.WriteFile(path.BumpChangelogFile, []byte(b.changelog.Markdown()), 0644)
+}
+
+// compareChartAssets loads assets/<chart>/<chart>-<version>.tgz of both versions and compares them
+func compareChartAssets(rootFs billy.Filesystem, chartName, oldVersion, newVersion string) (ChartChangelog, error) {
+	oldAsset, _ := mountAssetVersionPath(chartName, oldVersion)
+	oldChart, err := helmLoader.Load(filesystem.GetAbsPath(rootFs, oldAsset))
+	if err != nil {
+		return ChartChangelog{}, err
+	}
+	newAsset, _ := mountAssetVersionPath(chartName, newVersion)
+	newChart, err := helmLoader.Load(filesystem.GetAbsPath(rootFs, newAsset))
+	if err != nil {
+		return ChartChangelog{}, err
+	}
+	return diffCharts(chartName, oldChart, newChart)
+}
+
+// diffCharts compares the appVersion, dependencies, values, images and CRDs of two versions of a chart
+// and checks that the new CRDs are compatible with the old ones
+func diffCharts(chartName string, oldChart, newChart *chart.Chart) (ChartChangelog, error) {
+	c := ChartChangelog{
+		Chart:      chartName,
+		OldVersion: oldChart.Metadata.Version,
+		NewVersion: newChart.Metadata.Version,
+	}
+
+	if oldChart.Metadata.AppVersion != newChart.Metadata.AppVersion {
+		c.AppVersion = &Change{Name: "appVersion", Old: oldChart.Metadata.AppVersion, New: newChart.Metadata.AppVersion}
+	}
+
+	c.Dependencies = diffMaps(chartDependencies(oldChart), chartDependencies(newChart))
+
+	values, err := chartvalues.Diff(oldChart, newChart)
+	if err != nil {
+		return c, err
+	}
+	c.Values = values
+
+	oldImages, newImages := make(map[string]string), make(map[string]string)
+	valuesImages("", oldChart.Values, oldImages)
+	valuesImages("", newChart.Values, newImages)
+	c.Images = diffMaps(oldImages, newImages)
+
+	oldCRDs, err := crds.FromChart(oldChart)
+	if err != nil {
+		return c, err
+	}
+	newCRDs, err := crds.FromChart(newChart)
+	if err != nil {
+		return c, err
+	}
+	c.CRDs = diffCRDs(oldCRDs, newCRDs)
+	c.CRDIncompatibilities = crds.Compare(oldCRDs, newCRDs)
+
+	return c, nil
+}
+
+// chartDependencies maps the name of each Chart.yaml dependency to its version
+func chartDependencies(c *chart.Chart) map[string]string {
+	dependencies := make(map[string]string)
+	for _, dependency := range c.Metadata.Dependencies {
+		dependencies[dependency.Name] = dependency.Version
+	}
+	return dependencies
+}
+
+// valuesImages collects every image of the values, a map with repository and tag keys, as <repository>:<tag> by its dotted path
+func valuesImages(prefix string, values map[string]interface{}, images map[string]string) {
+	repository, hasRepository := values["repository"].(string)
+	tag, hasTag := values["tag"]
+	if hasRepository && hasTag && prefix != "" {
+		images[strings.TrimSuffix(prefix, ".")] = fmt.Sprintf("%s:%v", repository, tag)
+		return
+	}
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			valuesImages(prefix+key+".", nested, images)
+		}
+	}
+}
+
+// diffCRDs returns the added, removed and changed CRDs with their versions sorted by name;
+// a CRD whose definition changed with the same versions is reported with equal Old and New
+func diffCRDs(oldCRDs, newCRDs []crds.CRD) []Change {
+	newByName := make(map[string]crds.CRD, len(newCRDs))
+	for _, newCRD := range newCRDs {
+		newByName[newCRD.Name] = newCRD
+	}
+	oldByName := make(map[string]crds.CRD, len(oldCRDs))
+	for _, oldCRD := range oldCRDs {
+		oldByName[oldCRD.Name] = oldCRD
+	}
+
+	changes := []Change{}
+	for _, oldCRD := range oldCRDs {
+		newCRD, ok := newByName[oldCRD.Name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Name: oldCRD.Name, Old: crdVersions(oldCRD)})
+		case newCRD.Document != oldCRD.Document:
+			changes = append(changes, Change{Name: oldCRD.Name, Old: crdVersions(oldCRD), New: crdVersions(newCRD)})
+		}
+	}
+	for _, newCRD := range newCRDs {
+		if _, ok := oldByName[newCRD.Name]; !ok {
+			changes = append(changes, Change{Name: newCRD.Name, New: crdVersions(newCRD)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// crdVersions returns the names of the versions of the CRD separated by commas
+func crdVersions(c crds.CRD) string {
+	names := make([]string, 0, len(c.Versions))
+	for _, version := range c.Versions {
+		names = append(names, version.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// diffMaps returns the added, removed and changed entries between old and new sorted by name
+func diffMaps(oldMap, newMap map[string]string) []Change {
+	changes := []Change{}
+	for name, oldValue := range oldMap {
+		if newValue := newMap[name]; newValue != oldValue {
+			changes = append(changes, Change{Name: name, Old: oldValue, New: newValue})
+		}
+	}
+	for name, newValue := range newMap {
+		if _, ok := oldMap[name]; !ok {
+			changes = append(changes, Change{Name: name, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// upstreamGitLog clones the upstream branch without file contents and lists the commits on the chart directory
+// from the commit that set the Chart.yaml version to the previous upstream version
+// up to the commit that set it to the new upstream version, so later commits of the branch are not included
+func upstreamGitLog(ctx context.Context, url, branch, subdirectory, oldUpstreamVersion, newUpstreamVersion string) ([]string, error) {
+	dir, err := os.MkdirTemp("", "upstream-changelog-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	logger.Log(ctx, slog.LevelDebug, "cloning upstream for the changelog", slog.String("url", url), slog.String("branch", branch))
+	clone := exec.Command("git", "clone", "--quiet", "--bare", "--filter=blob:none", "--single-branch", "--branch", branch, url, dir)
+	if output, err := clone.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w: %s", url, err, output)
+	}
+
+	chartDir := subdirectory
+	if chartDir == "" {
+		chartDir = "."
+	}
+	chartYaml := filepath.Join(chartDir, "Chart.yaml")
+
+	start, err := versionCommit(dir, chartYaml, oldUpstreamVersion)
+	if err != nil {
+		return nil, err
+	}
+	end, err := versionCommit(dir, chartYaml, newUpstreamVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command("git", "-C", dir, "log", "--format=%h %s", start+".."+end, "--", chartDir).Output()
+	if err != nil {
+		return nil, err
+	}
+	commits := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line != "" {
+			commits = append(commits, line)
+		}
+	}
+	return commits, nil
+}
+
+// versionCommit returns the first commit of the cloned repository at dir that set the version of chartYaml to version
+func versionCommit(dir, chartYaml, version string) (string, error) {
+	versionPattern := `^version:\s*["']?` + regexp.QuoteMeta(version) + `["']?\s*$`
+
+	output, err := exec.Command("git", "-C", dir, "log", "--reverse", "--format=%H", "-G", versionPattern, "HEAD", "--", chartYaml).Output()
+	if err != nil {
+		return "", err
+	}
+	commit, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if commit == "" {
+		return "", fmt.Errorf("upstream version %s not found in the history of %s", version, chartYaml)
+	}
+	return commit, nil
+}
+
+// Markdown renders the changelog, skipping the sections without changes
+func (c *Changelog) Markdown() string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# %s %s\n", c.Chart, c.NewVersion)
+
+	if c.Upstream != nil {
+		fmt.Fprintf(&md, "\n## Upstream\n\n%s (%s) `%s` → `%s`\n\n", c.Upstream.URL, c.Upstream.Branch, c.Upstream.OldVersion, c.Upstream.NewVersion)
+		if len(c.Upstream.Commits) == 0 {
+			md.WriteString("No commits on the chart directory.\n")
+		}
+		for _, commit := range c.Upstream.Commits {
+			fmt.Fprintf(&md, "- %s\n", commit)
+		}
+	}
+
+	for _, chartChangelog := range c.Charts {
+		fmt.Fprintf(&md, "\n## %s `%s` → `%s`\n", chartChangelog.Chart, chartChangelog.OldVersion, chartChangelog.NewVersion)
+		if chartChangelog.isEmpty() {
+			md.WriteString("\nNo changes.\n")
+			continue
+		}
+		if chartChangelog.AppVersion != nil {
+			fmt.Fprintf(&md, "\nappVersion: `%s` → `%s`\n", chartChangelog.AppVersion.Old, chartChangelog.AppVersion.New)
+		}
+		writeChangesTable(&md, "Dependencies", "Dependency", chartChangelog.Dependencies)
+		if len(chartChangelog.Values) > 0 {
+			md.WriteString("\n### Values\n\n")
+			for _, change := range chartChangelog.Values {
+				fmt.Fprintf(&md, "- %s\n", change)
+			}
+		}
+		writeChangesTable(&md, "Images", "Image", chartChangelog.Images)
+		writeChangesTable(&md, "CRDs", "CRD", chartChangelog.CRDs)
+		if len(chartChangelog.CRDIncompatibilities) > 0 {
+			md.WriteString("\n### :warning: CRD incompatibilities\n\n")
+			for _, incompatibility := range chartChangelog.CRDIncompatibilities {
+				fmt.Fprintf(&md, "- %s\n", incompatibility)
+			}
+		}
+	}
+
+	if len(c.Notes) > 0 {
+		md.WriteString("\n## Notes\n\n")
+		for _, note := range c.Notes {
+			fmt.Fprintf(&md, "- %s\n", note)
+		}
+	}
+
+	return md.String()
+}
+
+// writeChangesTable writes the changes as a markdown table under a section title
+func writeChangesTable(md *strings.Builder, title, column string, changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(md, "\n### %s\n\n| %s | Old | New |\n|---|---|---|\n", title, column)
+	for _, change := range changes {
+		fmt.Fprintf(md, "| %s | %s | %s |\n", change.Name, changeValue(change.Old), changeValue(change.New))
+	}
+}
+
+// changeValue formats a side of a change, - when it was added or removed
+func changeValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return "`" + value + "`"
+}
+
+// isEmpty reports whether nothing changed in the chart
+func (c ChartChangelog) isEmpty() bool {
+	return c.AppVersion == nil && len(c.Dependencies) == 0 && len(c.Values) == 0 &&
+		len(c.Images) == 0 && len(c.CRDs) == 0 && len(c.CRDIncompatibilities) == 0
+}
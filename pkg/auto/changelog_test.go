@@ -0,0 +1,269 @@
+package auto
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/charts"
+	"github.com/rancher/charts-build-scripts/pkg/chartvalues"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func Test_diffCharts(t *testing.T) {
+	crd := func(name, versions string) *chart.File {
+		return &chart.File{Name: "templates/crds.yaml", Data: []byte("{{- if .Values.enabled }}\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: " + name + "\nspec:\n  versions:\n" + versions + "{{- end }}\n")}
+	}
+
+	oldChart := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:         "fleet",
+			Version:      "105.0.0+up0.11.0",
+			AppVersion:   "0.11.0",
+			Dependencies: []*chart.Dependency{{Name: "gitjob", Version: "0.1.0"}, {Name: "removed", Version: "1.0.0"}},
+		},
+		Values: map[string]interface{}{
+			"image":     map[string]interface{}{"repository": "rancher/fleet", "tag": "v0.11.0"},
+			"agent":     map[string]interface{}{"image": map[string]interface{}{"repository": "rancher/fleet-agent", "tag": "v0.11.0"}},
+			"debug":     false,
+			"oldOption": "",
+		},
+		Templates: []*chart.File{crd("gitrepos.fleet.cattle.io", "  - name: v1alpha1\n")},
+		Files:     []*chart.File{{Name: "crds/bundles.yaml", Data: []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: bundles.fleet.cattle.io\nspec:\n  versions:\n  - name: v1alpha1\n")}},
+	}
+	newChart := &chart.Chart{
+		Metadata: &chart.Metadata{
+			Name:         "fleet",
+			Version:      "105.0.1+up0.11.1",
+			AppVersion:   "0.11.1",
+			Dependencies: []*chart.Dependency{{Name: "gitjob", Version: "0.1.1"}, {Name: "added", Version: "2.0.0"}},
+		},
+		Values: map[string]interface{}{
+			"image":     map[string]interface{}{"repository": "rancher/fleet", "tag": "v0.11.1"},
+			"agent":     map[string]interface{}{"image": map[string]interface{}{"repository": "rancher/fleet-agent", "tag": "v0.11.0"}},
+			"debug":     false,
+			"newOption": map[string]interface{}{"enabled": true},
+		},
+		Templates: []*chart.File{
+			crd("gitrepos.fleet.cattle.io", "  - name: v1alpha1\n  - name: v1\n"),
+			crd("clusters.fleet.cattle.io", "  - name: v1alpha1\n"),
+		},
+	}
+
+	changelog, err := diffCharts("fleet", oldChart, newChart)
+	assert.NoError(t, err)
+	assert.Equal(t, ChartChangelog{
+		Chart:      "fleet",
+		OldVersion: "105.0.0+up0.11.0",
+		NewVersion: "105.0.1+up0.11.1",
+		AppVersion: &Change{Name: "appVersion", Old: "0.11.0", New: "0.11.1"},
+		Dependencies: []Change{
+			{Name: "added", New: "2.0.0"},
+			{Name: "gitjob", Old: "0.1.0", New: "0.1.1"},
+			{Name: "removed", Old: "1.0.0"},
+		},
+		Values: []chartvalues.Change{
+			{Key: "image.tag", Type: chartvalues.DefaultChanged, Old: `"v0.11.0"`, New: `"v0.11.1"`},
+			{Key: "newOption.enabled", Type: chartvalues.Added, New: "true"},
+			{Key: "oldOption", Type: chartvalues.Removed, Old: `""`},
+		},
+		Images: []Change{{Name: "image", Old: "rancher/fleet:v0.11.0", New: "rancher/fleet:v0.11.1"}},
+		CRDs: []Change{
+			{Name: "bundles.fleet.cattle.io", Old: "v1alpha1"},
+			{Name: "clusters.fleet.cattle.io", New: "v1alpha1"},
+			{Name: "gitrepos.fleet.cattle.io", Old: "v1alpha1", New: "v1alpha1,v1"},
+		},
+		CRDIncompatibilities: []crds.Incompatibility{
+			{CRD: "bundles.fleet.cattle.io", Rule: crds.RemovedCRD, Message: "CRD bundles.fleet.cattle.io is removed"},
+		},
+	}, changelog)
+}
+
+func Test_ChangelogMarkdown(t *testing.T) {
+	changelog := &Changelog{
+		Chart:      "fleet",
+		NewVersion: "105.0.1+up0.11.1",
+		Upstream: &UpstreamLog{
+			URL:        "https://github.com/rancher/fleet.git",
+			Branch:     "main",
+			OldVersion: "0.11.0",
+			NewVersion: "0.11.1",
+			Commits:    []string{"abc1234 bump to 0.11.1", "def5678 fix agent"},
+		},
+		Charts: []ChartChangelog{
+			{
+				Chart:      "fleet",
+				OldVersion: "105.0.0+up0.11.0",
+				NewVersion: "105.0.1+up0.11.1",
+				AppVersion: &Change{Name: "appVersion", Old: "0.11.0", New: "0.11.1"},
+				Values:     []chartvalues.Change{{Key: "newOption.enabled", Type: chartvalues.Added, New: "true"}},
+				Images:     []Change{{Name: "image", Old: "rancher/fleet:v0.11.0", New: "rancher/fleet:v0.11.1"}},
+			},
+			{Chart: "fleet-crd", OldVersion: "105.0.0+up0.11.0", NewVersion: "105.0.1+up0.11.1"},
+		},
+		Notes: []string{"fleet-agent is a new chart, there is no previous version to compare"},
+	}
+
+	expected := "# fleet 105.0.1+up0.11.1\n" +
+		"\n## Upstream\n\nhttps://github.com/rancher/fleet.git (main) `0.11.0` → `0.11.1`\n\n" +
+		"- abc1234 bump to 0.11.1\n- def5678 fix agent\n" +
+		"\n## fleet `105.0.0+up0.11.0` → `105.0.1+up0.11.1`\n" +
+		"\nappVersion: `0.11.0` → `0.11.1`\n" +
+		"\n### Values\n\n- added newOption.enabled with default true\n" +
+		"\n### Images\n\n| Image | Old | New |\n|---|---|---|\n| image | `rancher/fleet:v0.11.0` | `rancher/fleet:v0.11.1` |\n" +
+		"\n## fleet-crd `105.0.0+up0.11.0` → `105.0.1+up0.11.1`\n\nNo changes.\n" +
+		"\n## Notes\n\n- fleet-agent is a new chart, there is no previous version to compare\n"
+	assert.Equal(t, expected, changelog.Markdown())
+}
+
+func Test_checkRemovedValues(t *testing.T) {
+	changelog := &Changelog{
+		Chart: "fleet",
+		Charts: []ChartChangelog{
+			{
+				Chart: "fleet",
+				Values: []chartvalues.Change{
+					{Key: "legacy.enabled", Type: chartvalues.Removed, Old: "false"},
+					{Key: "newOption", Type: chartvalues.Added, New: "true"},
+				},
+			},
+			{
+				Chart:  "fleet-agent",
+				Values: []chartvalues.Change{{Key: "oldOption", Type: chartvalues.Removed, Old: `""`}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		removedValues map[string][]string
+		expectedErr   string
+	}{
+		{
+			name:          "#1 unacknowledged removals",
+			removedValues: nil,
+			expectedErr:   `fleet: removed legacy.enabled with default false; fleet-agent: removed oldOption with default ""`,
+		},
+		{
+			name:          "#2 removal acknowledged by a parent key",
+			removedValues: map[string][]string{"fleet": {"legacy"}},
+			expectedErr:   `fleet-agent: removed oldOption with default ""`,
+		},
+		{
+			name:          "#3 every removal acknowledged",
+			removedValues: map[string][]string{"fleet": {"legacy.enabled"}, "fleet-agent": {"oldOption"}},
+		},
+		{
+			name:          "#4 acknowledged for another chart",
+			removedValues: map[string][]string{"fleet": {"legacy", "oldOption"}},
+			expectedErr:   `fleet-agent: removed oldOption with default ""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bump{changelog: changelog, Pkg: &charts.Package{RemovedValues: tt.removedValues}}
+			err := b.checkRemovedValues(context.Background())
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+
+	t.Run("#5 a chart that could not be compared", func(t *testing.T) {
+		b := &Bump{
+			changelog: &Changelog{compareErrs: []error{errors.New("failed to compare fleet 105.0.0 with 105.1.0: no such file")}},
+			Pkg:       &charts.Package{},
+		}
+		assert.ErrorContains(t, b.checkRemovedValues(context.Background()), "failed to compare fleet 105.0.0 with 105.1.0")
+	})
+}
+
+func Test_checkCRDIncompatibilities(t *testing.T) {
+	changelog := &Changelog{
+		Chart: "fleet",
+		Charts: []ChartChangelog{
+			{Chart: "fleet"},
+			{
+				Chart: "fleet-crd",
+				CRDIncompatibilities: []crds.Incompatibility{
+					{CRD: "gitrepos.fleet.cattle.io", Version: "v1alpha1", Rule: crds.RemovedProperty, Path: ".spec.paused", Message: "property is removed"},
+					{CRD: "bundles.fleet.cattle.io", Rule: crds.RemovedCRD, Message: "CRD bundles.fleet.cattle.io is removed"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		crdIncompatibilities map[string][]string
+		expectedErr          string
+	}{
+		{
+			name:        "#1 unacknowledged incompatibilities",
+			expectedErr: "fleet-crd: gitrepos.fleet.cattle.io v1alpha1 .spec.paused: property is removed (removed-property); fleet-crd: bundles.fleet.cattle.io",
+		},
+		{
+			name:                 "#2 incompatibility acknowledged by its CRD and version",
+			crdIncompatibilities: map[string][]string{"fleet-crd": {"gitrepos.fleet.cattle.io v1alpha1"}},
+			expectedErr:          "fleet-crd: bundles.fleet.cattle.io: CRD bundles.fleet.cattle.io is removed (removed-crd)",
+		},
+		{
+			name:                 "#3 every incompatibility acknowledged",
+			crdIncompatibilities: map[string][]string{"fleet-crd": {"gitrepos.fleet.cattle.io", "bundles.fleet.cattle.io"}},
+		},
+		{
+			name:                 "#4 acknowledged for another chart",
+			crdIncompatibilities: map[string][]string{"fleet": {"gitrepos.fleet.cattle.io", "bundles.fleet.cattle.io"}},
+			expectedErr:          "acknowledge them under crdIncompatibilities in package.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bump{changelog: changelog, Pkg: &charts.Package{CRDIncompatibilities: tt.crdIncompatibilities}}
+			err := b.checkCRDIncompatibilities(context.Background())
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func Test_upstreamGitLog(t *testing.T) {
+	upstreamDir := t.TempDir()
+	gittest.NewRepo(t, upstreamDir, "main", nil)
+	commit := func(file, content, message string) {
+		t.Helper()
+		gittest.Commit(t, upstreamDir, message, map[string]string{file: content})
+	}
+	commit("charts/fleet/Chart.yaml", "name: fleet\nversion: 0.10.0\n", "release 0.10.0")
+	commit("charts/fleet/Chart.yaml", "name: fleet\nversion: 0.11.0\n", "release 0.11.0")
+	commit("charts/fleet/values.yaml", "debug: true\n", "add debug option")
+	commit("README.md", "fleet\n", "update readme")
+	commit("charts/fleet/Chart.yaml", "name: fleet\nversion: 0.11.1\n", "release 0.11.1")
+	commit("charts/fleet/values.yaml", "debug: false\n", "disable debug")
+
+	t.Run("#1 commits on the chart directory between the previous and the new version", func(t *testing.T) {
+		commits, err := upstreamGitLog(context.Background(), upstreamDir, "main", "charts/fleet", "0.11.0", "0.11.1")
+		assert.NoError(t, err)
+		assert.Len(t, commits, 2)
+		assert.Contains(t, commits[0], "release 0.11.1")
+		assert.Contains(t, commits[1], "add debug option")
+	})
+
+	t.Run("#2 previous version not in the history", func(t *testing.T) {
+		_, err := upstreamGitLog(context.Background(), upstreamDir, "main", "charts/fleet", "0.9.0", "0.11.1")
+		assert.ErrorContains(t, err, "upstream version 0.9.0 not found")
+	})
+
+	t.Run("#3 new version not in the history", func(t *testing.T) {
+		_, err := upstreamGitLog(context.Background(), upstreamDir, "main", "charts/fleet", "0.11.0", "0.12.0")
+		assert.ErrorContains(t, err, "upstream version 0.12.0 not found")
+	})
+}
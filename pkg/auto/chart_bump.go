@@ -19,43 +19,6 @@ import (
 	"github.com/rancher/charts-build-scripts/pkg/path"
 )
 
-// ChartTargetsMap represents all current active charts
-var ChartTargetsMap = map[string][]string{
-	"elemental":                  {"elemental", "elemental-crd"},
-	"fleet":                      {"fleet", "fleet-crd", "fleet-agent"},
-	"harvester-cloud-provider":   {"harvester-cloud-provider"},
-	"harvester-csi-driver":       {"harvester-csi-driver"},
-	"longhorn":                   {"longhorn", "longhorn-crd"},
-	"neuvector":                  {"neuvector", "neuvector-crd", "neuvector-monitor"},
-	"prometheus-federator":       {"prometheus-federator"},
-	"rancher-aks-operator":       {"rancher-aks-operator", "rancher-aks-operator-crd"},
-	"rancher-alerting-drivers":   {"rancher-alerting-drivers"},
-	"rancher-backup":             {"rancher-backup", "rancher-backup-crd"},
-	"rancher-cis-benchmark":      {"rancher-cis-benchmark", "rancher-cis-benchmark-crd"},
-	"rancher-compliance":         {"rancher-compliance", "rancher-compliance-crd"},
-	"rancher-csp-adapter":        {"rancher-csp-adapter"},
-	"rancher-eks-operator":       {"rancher-eks-operator", "rancher-eks-operator-crd"},
-	"rancher-gatekeeper":         {"rancher-gatekeeper", "rancher-gatekeeper-crd"},
-	"rancher-gke-operator":       {"rancher-gke-operator", "rancher-gke-operator-crd"},
-	"rancher-istio":              {"rancher-istio"},
-	"rancher-logging":            {"rancher-logging", "rancher-logging-crd"},
-	"rancher-monitoring":         {"rancher-monitoring", "rancher-monitoring-crd"},
-	"rancher-project-monitoring": {"rancher-project-monitoring"},
-	"rancher-provisioning-capi":  {"rancher-provisioning-capi"},
-	"rancher-pushprox":           {"rancher-pushprox"},
-	"rancher-vsphere-csi":        {"rancher-vsphere-csi"},
-	"rancher-vsphere-cpi":        {"rancher-vsphere-cpi"},
-	"rancher-webhook":            {"rancher-webhook"},
-	"rancher-windows-gmsa":       {"rancher-windows-gmsa", "rancher-windows-gmsa-crd"},
-	"rancher-wins-upgrader":      {"rancher-wins-upgrader"},
-	"sriov":                      {"sriov", "sriov-crd"},
-	"system-upgrade-controller":  {"system-upgrade-controller"},
-	"ui-plugin-operator":         {"ui-plugin-operator", "ui-plugin-operator-crd"},
-	"rancher-turtles":            {"rancher-turtles"},
-	"rancher-turtles-providers":  {"rancher-turtles-providers"},
-	"rancher-ali-operator":       {"rancher-ali-operator", "rancher-ali-operator-crd"},
-}
-
 // Bump represents the chart bump process for a single chart
 // (with its CRD and dependencies).
 type Bump struct {
@@ -63,12 +26,16 @@ type Bump struct {
 	configOptions *options.ChartsScriptOptions
 	// target chart, CRD and any additional chart
 	target target
+	// chart groups from package.yaml and configuration.yaml
+	groups ChartGroups
 	// represents package/<target_chart> directory loaded options
 	Pkg *charts.Package
 	// versions to be calculated
 	versions *versions
 	// release.yaml file information
 	releaseYaml *Release
+	// changes between the latest released and the bumped versions
+	changelog *Changelog
 	// version rules at the current branch which will be applied to versions field
 	versionRules *lifecycle.VersionRules
 	// all assets versions present
@@ -94,7 +61,6 @@ type BumpOutput struct {
 var (
 	errNotDevBranch                 = errors.New("a development branch must be provided; (e.g., dev-v2.*)")
 	errBadPackage                   = errors.New("unexpected format for PACKAGE env variable")
-	errChartNotListed               = errors.New("chart not listed")
 	errNoPackage                    = errors.New("no package provided")
 	errMultiplePackages             = errors.New("multiple packages provided; this is not supported")
 	errFalseAuto                    = errors.New("package.yaml must be configured for auto-chart-bump")
@@ -141,6 +107,16 @@ func SetupBump(ctx context.Context, repoRoot, targetPackage, targetBranch string
 	}
 	logger.Log(ctx, slog.LevelDebug, "", slog.String("branch-line", branch))
 
+	// Load the chart groups to find the charts bumped together with the target chart
+	var configuredGroups map[string][]string
+	if chScriptOpts != nil {
+		configuredGroups = chScriptOpts.ChartGroups
+	}
+	bump.groups, err = LoadChartGroups(ctx, repoRoot, configuredGroups)
+	if err != nil {
+		return bump, err
+	}
+
 	// Load and check the chart name from the target given package
 	if err := bump.parseChartFromPackage(targetPackage); err != nil {
 		return bump, err
@@ -152,6 +128,10 @@ func SetupBump(ctx context.Context, repoRoot, targetPackage, targetBranch string
 		err = fmt.Errorf("failure at SetupBump: %w ", err)
 		return bump, err
 	}
+	// Git tree must be clean before proceeding with removing charts
+	if err := dependencies.CheckCleanTree(ctx); err != nil {
+		return bump, fmt.Errorf("failure at SetupBump: %w ", err)
+	}
 
 	bump.versionRules = dependencies.VR
 	bump.assetsVersionsMap = dependencies.AssetsVersionsMap
@@ -246,11 +226,8 @@ func (b *Bump) parseChartFromPackage(targetPackage string) error {
 		return errBadPackage
 	}
 
-	if _, exist := ChartTargetsMap[b.target.main]; !exist {
-		return errChartNotListed
-	}
-
-	b.target.additional = ChartTargetsMap[b.target.main]
+	// a chart not covered by any group is bumped alone
+	b.target.additional = b.groups.Members(b.target.main)
 	return nil
 }
 
@@ -296,7 +273,7 @@ func (b *Bump) parsePackageYaml(packages []*charts.Package) error {
 		}
 		if additionalChart.CRDChartOptions != nil {
 			switch {
-			case additionalChart.CRDChartOptions.TemplateDirectory == "":
+			case additionalChart.CRDChartOptions.TemplateDirectory == "" && !additionalChart.CRDChartOptions.GenerateFromMainChart:
 				return errAdditionalChartWorkDir
 			case additionalChart.CRDChartOptions.CRDDirectory == "":
 				return errCRDWorkDir
@@ -355,6 +332,15 @@ func (b *Bump) BumpChart(ctx context.Context, versionOverride string, multiRCs,
 		return err
 	}
 
+	// compare with the latest released versions before any RC is removed
+	b.changelog = b.generateChangelog(ctx)
+	if err := b.checkRemovedValues(ctx); err != nil {
+		return err
+	}
+	if err := b.checkCRDIncompatibilities(ctx); err != nil {
+		return err
+	}
+
 	// check if should remove previous RCs versions
 	if !multiRCs && !newChart {
 		logger.Log(ctx, slog.LevelWarn, "removing existing RC's")
@@ -371,7 +357,11 @@ func (b *Bump) BumpChart(ctx context.Context, versionOverride string, multiRCs,
 	logger.Log(ctx, slog.LevelInfo, "bump version",
 		slog.String("bumpVersion", b.Pkg.AutoGeneratedBumpVersion.String()))
 
-	return b.writeBumpJSON(ctx, b.target.additional, b.Pkg.AutoGeneratedBumpVersion.String())
+	if err := b.writeBumpJSON(ctx, b.target.additional, b.Pkg.AutoGeneratedBumpVersion.String()); err != nil {
+		return err
+	}
+
+	return b.writeChangelog(ctx)
 }
 
 // prepare = && git status && git add . && git commit -m "make prepare"
@@ -595,3 +585,55 @@ func (b *Bump) writeBumpJSON(ctx context.Context, targetCharts []string, bumpVer
 
 	return nil
 }
+
+// OpenPullRequest will push the current branch with the bump commits and open (or update)
+// the pull request into the development branch. It returns the pull request URL.
+// If forkURL is empty the branch is pushed to the upstream remote, otherwise to the fork remote.
+// On dry-run nothing is pushed and the pull request payload is only printed.
+func (b *Bump) OpenPullRequest(ctx context.Context, gh *Github, forkURL string, dryRun bool) (string, error) {
+	logger.Log(ctx, slog.LevelInfo, "open chart bump pull request")
+
+	headOwner := gh.Owner
+	remote, err := b.repo.UpstreamRemote()
+	if forkURL != "" {
+		var ok bool
+		remote, ok = b.repo.Remotes[forkURL]
+		if !ok {
+			return "", fmt.Errorf("remote %s not found in git remotes, you need to configure your fork on your git remote references", forkURL)
+		}
+		headOwner = ownerFromRemoteURL(forkURL)
+	} else if err != nil {
+		return "", err
+	}
+
+	bumpVersion := b.Pkg.AutoGeneratedBumpVersion.String()
+	title := fmt.Sprintf("[%s] %s %s", b.versionRules.DevBranch, b.target.main, bumpVersion)
+	body := bumpPullRequestBody(b.target.additional, bumpVersion)
+	if b.changelog != nil {
+		body += "\n" + b.changelog.Markdown()
+	}
+	payload := gh.newPullRequestPayload(title, body, headOwner, b.repo.Branch, b.versionRules.DevBranch)
+
+	if !dryRun {
+		if err := b.repo.PushBranch(remote, b.repo.Branch); err != nil {
+			logger.Log(ctx, slog.LevelError, "error while pushing bump branch", slog.String("branch", b.repo.Branch), logger.Err(err))
+			return "", err
+		}
+	}
+
+	return gh.CreateOrUpdatePullRequest(ctx, payload, dryRun)
+}
+
+// bumpPullRequestBody lists the bumped charts and the new version in a markdown table
+func bumpPullRequestBody(targetCharts []string, bumpVersion string) string {
+	var body strings.Builder
+
+	body.WriteString("Automated chart bump to version `" + bumpVersion + "`:\n\n")
+	body.WriteString("| Chart | Version |\n")
+	body.WriteString("|---|---|\n")
+	for _, chart := range targetCharts {
+		body.WriteString("| " + chart + " | " + bumpVersion + " |\n")
+	}
+
+	return body.String()
+}
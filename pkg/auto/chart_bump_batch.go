@@ -0,0 +1,277 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rancher/charts-build-scripts/pkg/charts"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+)
+
+// Status of each package bumped by a batch chart bump
+const (
+	BumpStatusBumped = "bumped"
+	BumpStatusFailed = "failed"
+)
+
+// BatchBumpOptions are the options applied to every package of a batch chart bump
+type BatchBumpOptions struct {
+	// Packages to bump; every package with auto: true when empty
+	Packages        []string
+	VersionOverride string
+	MultiRC         bool
+	IsPrimeChart    bool
+	// BranchPerPackage bumps each package on its own branch created from the current branch
+	BranchPerPackage bool
+	// GH opens a pull request for each bumped branch when BranchPerPackage is set
+	GH       *Github
+	ForkURL  string
+	PRDryRun bool
+}
+
+// BatchBumpResult is the outcome of the bump of a single package
+type BatchBumpResult struct {
+	Package     string   `json:"package"`
+	Charts      []string `json:"charts,omitempty"`
+	OldVersion  string   `json:"old_version,omitempty"`
+	NewVersion  string   `json:"new_version,omitempty"`
+	Branch      string   `json:"branch,omitempty"`
+	PullRequest string   `json:"pull_request,omitempty"`
+	Status      string   `json:"status"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// BatchBumpOutput defines the structure that will be written to config/bump_batch.json
+type BatchBumpOutput struct {
+	Results []BatchBumpResult `json:"results"`
+}
+
+var errNoAutoPackages = errors.New("no package with auto: true found")
+
+// ListAutoPackages returns the packages configured for auto-chart-bump (auto: true) that are released
+func ListAutoPackages(ctx context.Context, repoRoot string) ([]string, error) {
+	packages, err := charts.ListPackages(ctx, repoRoot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rootFs := filesystem.GetFilesystem(repoRoot)
+	autoPackages := []string{}
+	for _, pkg := range packages {
+		pkgFs, err := rootFs.Chroot(filepath.Join(path.RepositoryPackagesDir, pkg))
+		if err != nil {
+			return nil, err
+		}
+		pkgOpts, err := options.LoadPackageOptionsFromFile(ctx, pkgFs, path.PackageOptionsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load package.yaml of %s: %w", pkg, err)
+		}
+		if pkgOpts.Auto && !pkgOpts.DoNotRelease {
+			autoPackages = append(autoPackages, pkg)
+		}
+	}
+	return autoPackages, nil
+}
+
+// BatchBump bumps every package of the batch one after the other from the current branch.
+// A package that fails to bump is reset to the commit it started from, so it never affects the others.
+// Unless BranchPerPackage is set, the successful bumps are committed on top of each other on the current branch.
+// The result of every package is written to config/bump_batch.json.
+func BatchBump(ctx context.Context, repoRoot, targetBranch string, chScriptOpts *options.ChartsScriptOptions, opts BatchBumpOptions) (*BatchBumpOutput, error) {
+	return batchBump(ctx, repoRoot, targetBranch, chScriptOpts, opts, batchBumpPackage)
+}
+
+// bumpPackageFunc sets up and bumps a single package of a batch chart bump
+type bumpPackageFunc func(ctx context.Context, repoRoot, pkg, targetBranch string, chScriptOpts *options.ChartsScriptOptions, opts BatchBumpOptions) (*Bump, error)
+
+// batchBump is BatchBump with the bump of each package done by bumpPackage
+func batchBump(ctx context.Context, repoRoot, targetBranch string, chScriptOpts *options.ChartsScriptOptions, opts BatchBumpOptions, bumpPackage bumpPackageFunc) (*BatchBumpOutput, error) {
+	logger.Log(ctx, slog.LevelInfo, "batch auto-chart-bump")
+
+	branchVersion, err := parseBranchVersion(targetBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.OpenGitRepo(ctx, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.IsClean(ctx); err != nil {
+		return nil, err
+	}
+	baseBranch := repo.Branch
+
+	packages := opts.Packages
+	if len(packages) == 0 {
+		if packages, err = ListAutoPackages(ctx, repoRoot); err != nil {
+			return nil, err
+		}
+	}
+	if len(packages) == 0 {
+		return nil, errNoAutoPackages
+	}
+
+	// every bump writes config/bump_version.json and config/bump_changelog.md without committing them;
+	// they are restored after each package
+	restoreBumpFiles := snapshotFiles(filepath.Join(repoRoot, path.BumpVersionFile), filepath.Join(repoRoot, path.BumpChangelogFile))
+
+	output := &BatchBumpOutput{Results: []BatchBumpResult{}}
+	for _, pkg := range packages {
+		result := BatchBumpResult{Package: pkg}
+
+		head, err := repo.HeadCommit()
+		if err != nil {
+			return output, err
+		}
+
+		if opts.BranchPerPackage {
+			result.Branch = batchBumpBranch(pkg, branchVersion)
+			if err := repo.CreateAndCheckoutBranch(result.Branch); err != nil {
+				result.Status = BumpStatusFailed
+				result.Error = fmt.Sprintf("failed to create branch %s: %v", result.Branch, err)
+				result.Branch = ""
+				output.Results = append(output.Results, result)
+				continue
+			}
+		}
+
+		bump, err := bumpPackage(ctx, repoRoot, pkg, targetBranch, chScriptOpts, opts)
+		if bump != nil {
+			result.Charts = bump.target.additional
+			result.OldVersion = getLatestVersionRecursively(bump.assetsVersionsMap[bump.target.main])
+		}
+
+		if err := restoreBumpFiles(); err != nil {
+			return output, fmt.Errorf("failed to restore the bump files: %w", err)
+		}
+
+		if err != nil {
+			logger.Log(ctx, slog.LevelError, "failed to bump package", slog.String("package", pkg), logger.Err(err))
+			result.Status = BumpStatusFailed
+			result.Error = err.Error()
+
+			// drop the partial bump: commits, staged and untracked files
+			if err := repo.ResetHardTo(head); err != nil {
+				return output, fmt.Errorf("failed to reset %s to %s: %w", pkg, head, err)
+			}
+			if err := repo.CleanUntracked(); err != nil {
+				return output, err
+			}
+			if opts.BranchPerPackage {
+				if err := repo.CheckoutBranch(baseBranch); err != nil {
+					return output, err
+				}
+				if err := repo.DeleteBranch(result.Branch); err != nil {
+					return output, err
+				}
+				result.Branch = ""
+			}
+			output.Results = append(output.Results, result)
+			continue
+		}
+
+		result.Status = BumpStatusBumped
+		result.NewVersion = bump.Pkg.AutoGeneratedBumpVersion.String()
+
+		if opts.BranchPerPackage {
+			if opts.GH != nil {
+				url, err := bump.OpenPullRequest(ctx, opts.GH, opts.ForkURL, opts.PRDryRun)
+				if err != nil {
+					// the bump is kept on result.Branch, which may have been pushed, so it can be retried from there
+					result.Status = BumpStatusFailed
+					result.NewVersion = ""
+					result.Error = fmt.Sprintf("failed to open pull request for branch %s: %v", result.Branch, err)
+				}
+				result.PullRequest = url
+			}
+			if err := repo.CheckoutBranch(baseBranch); err != nil {
+				return output, err
+			}
+		}
+
+		output.Results = append(output.Results, result)
+	}
+
+	if err := output.write(filepath.Join(repoRoot, path.BumpBatchFile)); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+// batchBumpPackage sets up and bumps a single package of the batch
+func batchBumpPackage(ctx context.Context, repoRoot, pkg, targetBranch string, chScriptOpts *options.ChartsScriptOptions, opts BatchBumpOptions) (*Bump, error) {
+	logger.Log(ctx, slog.LevelInfo, "bump package", slog.String("package", pkg))
+
+	bump, err := SetupBump(ctx, repoRoot, pkg, targetBranch, chScriptOpts, false)
+	if err != nil {
+		return bump, fmt.Errorf("failed to setup: %w", err)
+	}
+	if err := bump.BumpChart(ctx, opts.VersionOverride, opts.MultiRC, false, opts.IsPrimeChart); err != nil {
+		return bump, fmt.Errorf("failed to bump: %w", err)
+	}
+	return bump, nil
+}
+
+// snapshotFiles saves the content of the files and returns a function restoring it;
+// the files that did not exist are removed
+func snapshotFiles(files ...string) func() error {
+	contents := make(map[string][]byte)
+	for _, file := range files {
+		if data, err := os.ReadFile(file); err == nil {
+			contents[file] = data
+		}
+	}
+
+	return func() error {
+		for _, file := range files {
+			data, existed := contents[file]
+			if !existed {
+				if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := os.WriteFile(file, data, 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// batchBumpBranch returns the branch of a package bumped with BranchPerPackage
+// e.g., chart-bump-rancher-istio-1.22-rancher-istio-2.10
+func batchBumpBranch(pkg, branchVersion string) string {
+	return "chart-bump-" + strings.ReplaceAll(pkg, "/", "-") + "-" + branchVersion
+}
+
+// Failed returns the packages that failed to bump
+func (o *BatchBumpOutput) Failed() []string {
+	failed := []string{}
+	for _, result := range o.Results {
+		if result.Status == BumpStatusFailed {
+			failed = append(failed, result.Package)
+		}
+	}
+	return failed
+}
+
+// write writes the batch results as indented json to file
+func (o *BatchBumpOutput) write(file string) error {
+	jsonData, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, jsonData, 0644)
+}
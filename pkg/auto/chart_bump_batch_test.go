@@ -0,0 +1,199 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/rancher/charts-build-scripts/pkg/charts"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBatchBumpTestRepo creates a git repository with the given package.yaml files committed at main
+func newBatchBumpTestRepo(t *testing.T, packages map[string]string) string {
+	t.Helper()
+	repoRoot := t.TempDir()
+
+	files := map[string]string{
+		"config/bump_version.json":  "{}\n",
+		"config/version_rules.json": `{"rules": {"2.9": {"min": "104.0.0", "max": "105.0.0"}}}`,
+	}
+	for pkg, packageYaml := range packages {
+		files[filepath.Join("packages", pkg, "package.yaml")] = packageYaml
+	}
+	gittest.NewRepo(t, repoRoot, "main", files)
+
+	return repoRoot
+}
+
+func Test_ListAutoPackages(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := newBatchBumpTestRepo(t, map[string]string{
+		"fleet":                            "url: https://github.com/rancher/fleet.git\nauto: true\n",
+		"longhorn":                         "url: https://github.com/longhorn/charts.git\n",
+		"rancher-istio/1.22/rancher-istio": "url: https://github.com/rancher/istio.git\nauto: true\n",
+		"rancher-webhook":                  "url: https://github.com/rancher/webhook.git\nauto: true\ndoNotRelease: true\n",
+	})
+
+	packages, err := ListAutoPackages(context.Background(), repoRoot)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"fleet", "rancher-istio/1.22/rancher-istio"}, packages)
+}
+
+func Test_BatchBump(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+
+	// there are no version rules for 2.10 in the repository, so every package fails at SetupBump
+	packages := map[string]string{
+		"fleet":    "url: https://github.com/rancher/fleet.git\nauto: true\n",
+		"longhorn": "url: https://github.com/longhorn/charts.git\nauto: true\n",
+	}
+
+	t.Run("#1 failures are isolated and reported", func(t *testing.T) {
+		repoRoot := newBatchBumpTestRepo(t, packages)
+
+		output, err := BatchBump(ctx, repoRoot, "dev-v2.10", nil, BatchBumpOptions{VersionOverride: "auto"})
+		assert.NoError(t, err)
+		assert.Len(t, output.Results, 2)
+		for _, result := range output.Results {
+			assert.Equal(t, BumpStatusFailed, result.Status)
+			assert.NotEmpty(t, result.Error)
+		}
+		assert.ElementsMatch(t, []string{"fleet", "longhorn"}, output.Failed())
+
+		var written BatchBumpOutput
+		data, err := os.ReadFile(filepath.Join(repoRoot, "config", "bump_batch.json"))
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(data, &written))
+		assert.Equal(t, *output, written)
+
+		bumpVersion, err := os.ReadFile(filepath.Join(repoRoot, "config", "bump_version.json"))
+		assert.NoError(t, err)
+		assert.Equal(t, "{}\n", string(bumpVersion))
+	})
+
+	t.Run("#2 selected packages on their own branch", func(t *testing.T) {
+		repoRoot := newBatchBumpTestRepo(t, packages)
+
+		output, err := BatchBump(ctx, repoRoot, "dev-v2.10", nil, BatchBumpOptions{
+			Packages:         []string{"longhorn", "not-a-package"},
+			VersionOverride:  "auto",
+			BranchPerPackage: true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"longhorn", "not-a-package"}, output.Failed())
+		assert.ErrorContains(t, exec.Command("git", "-C", repoRoot, "rev-parse", "--verify", "chart-bump-longhorn-2.10").Run(), "exit status")
+
+		branch, err := exec.Command("git", "-C", repoRoot, "rev-parse", "--abbrev-ref", "HEAD").Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "main\n", string(branch))
+	})
+
+	// fakeBump commits an asset and writes the bump files like a successful bump, failing for the given package after committing
+	fakeBump := func(failing string) bumpPackageFunc {
+		return func(ctx context.Context, repoRoot, pkg, targetBranch string, _ *options.ChartsScriptOptions, _ BatchBumpOptions) (*Bump, error) {
+			gittest.Commit(t, repoRoot, "bump "+pkg, map[string]string{filepath.Join("assets", pkg, pkg+"-105.1.0+up1.1.0.tgz"): "archive\n"})
+			assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, "config", "bump_version.json"), []byte(`{"new_version": "105.1.0+up1.1.0"}`), 0644))
+
+			newVersion := semver.MustParse("105.1.0+up1.1.0")
+			bump := &Bump{
+				target:            target{main: pkg, additional: []string{pkg, pkg + "-crd"}},
+				assetsVersionsMap: map[string][]lifecycle.Asset{pkg: {{Version: "105.1.0+up1.1.0-rc.1"}, {Version: "105.0.0+up1.0.0"}}},
+				Pkg:               &charts.Package{AutoGeneratedBumpVersion: &newVersion},
+				repo:              &git.Git{Dir: repoRoot},
+			}
+			if pkg == failing {
+				return bump, errors.New("failed to pull upstream")
+			}
+			return bump, nil
+		}
+	}
+	log := func(t *testing.T, repoRoot, ref string) string {
+		return gittest.Run(t, repoRoot, "log", "--format=%s", ref)
+	}
+
+	t.Run("#3 successful bumps are stacked on the current branch", func(t *testing.T) {
+		repoRoot := newBatchBumpTestRepo(t, packages)
+
+		output, err := batchBump(ctx, repoRoot, "dev-v2.10", nil, BatchBumpOptions{Packages: []string{"fleet", "longhorn", "neuvector"}}, fakeBump("longhorn"))
+		assert.NoError(t, err)
+		assert.Equal(t, []BatchBumpResult{
+			{Package: "fleet", Charts: []string{"fleet", "fleet-crd"}, OldVersion: "105.0.0+up1.0.0", NewVersion: "105.1.0+up1.1.0", Status: BumpStatusBumped},
+			{Package: "longhorn", Charts: []string{"longhorn", "longhorn-crd"}, OldVersion: "105.0.0+up1.0.0", Status: BumpStatusFailed, Error: "failed to pull upstream"},
+			{Package: "neuvector", Charts: []string{"neuvector", "neuvector-crd"}, OldVersion: "105.0.0+up1.0.0", NewVersion: "105.1.0+up1.1.0", Status: BumpStatusBumped},
+		}, output.Results)
+
+		// the failed bump is dropped and the others are committed on top of each other
+		assert.Equal(t, "bump neuvector\nbump fleet\ninitial commit\n", log(t, repoRoot, "main"))
+		assert.NoFileExists(t, filepath.Join(repoRoot, "assets", "longhorn", "longhorn-105.1.0+up1.1.0.tgz"))
+		assert.Equal(t, "main\n", gittest.Run(t, repoRoot, "rev-parse", "--abbrev-ref", "HEAD"))
+
+		bumpVersion, err := os.ReadFile(filepath.Join(repoRoot, "config", "bump_version.json"))
+		assert.NoError(t, err)
+		assert.Equal(t, "{}\n", string(bumpVersion))
+
+		data, err := os.ReadFile(filepath.Join(repoRoot, "config", "bump_batch.json"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"old_version": "105.0.0+up1.0.0"`)
+		assert.Contains(t, string(data), `"new_version": "105.1.0+up1.1.0"`)
+	})
+
+	t.Run("#4 successful bumps on their own branch", func(t *testing.T) {
+		repoRoot := newBatchBumpTestRepo(t, packages)
+
+		output, err := batchBump(ctx, repoRoot, "dev-v2.10", nil, BatchBumpOptions{Packages: []string{"fleet", "longhorn"}, BranchPerPackage: true}, fakeBump(""))
+		assert.NoError(t, err)
+		assert.Empty(t, output.Failed())
+		assert.Equal(t, "chart-bump-fleet-2.10", output.Results[0].Branch)
+		assert.Equal(t, "chart-bump-longhorn-2.10", output.Results[1].Branch)
+
+		// every branch starts from the base branch, which is checked out again and left as-is
+		assert.Equal(t, "main\n", gittest.Run(t, repoRoot, "rev-parse", "--abbrev-ref", "HEAD"))
+		assert.Equal(t, "initial commit\n", log(t, repoRoot, "main"))
+		assert.Equal(t, "bump fleet\ninitial commit\n", log(t, repoRoot, "chart-bump-fleet-2.10"))
+		assert.Equal(t, "bump longhorn\ninitial commit\n", log(t, repoRoot, "chart-bump-longhorn-2.10"))
+	})
+
+	t.Run("#5 a pull request that cannot be opened keeps the branch without a new version", func(t *testing.T) {
+		repoRoot := newBatchBumpTestRepo(t, packages)
+		gh, err := NewGithub(ctx, "token", &options.GithubOptions{})
+		assert.NoError(t, err)
+
+		// the fake bump repository has no upstream remote to push to
+		output, err := batchBump(ctx, repoRoot, "dev-v2.10", nil, BatchBumpOptions{Packages: []string{"fleet"}, BranchPerPackage: true, GH: gh}, fakeBump(""))
+		assert.NoError(t, err)
+		assert.Equal(t, []BatchBumpResult{
+			{
+				Package:    "fleet",
+				Charts:     []string{"fleet", "fleet-crd"},
+				OldVersion: "105.0.0+up1.0.0",
+				Branch:     "chart-bump-fleet-2.10",
+				Status:     BumpStatusFailed,
+				Error:      "failed to open pull request for branch chart-bump-fleet-2.10: upstream remote not configured",
+			},
+		}, output.Results)
+		assert.Equal(t, "bump fleet\ninitial commit\n", log(t, repoRoot, "chart-bump-fleet-2.10"))
+		assert.Equal(t, "main\n", gittest.Run(t, repoRoot, "rev-parse", "--abbrev-ref", "HEAD"))
+	})
+
+	t.Run("#6 not a development branch", func(t *testing.T) {
+		_, err := BatchBump(ctx, newBatchBumpTestRepo(t, packages), "main", nil, BatchBumpOptions{})
+		assert.ErrorIs(t, err, errNotDevBranch)
+	})
+}
+
+func Test_batchBumpBranch(t *testing.T) {
+	assert.Equal(t, "chart-bump-fleet-2.10", batchBumpBranch("fleet", "2.10"))
+	assert.Equal(t, "chart-bump-rancher-istio-1.22-rancher-istio-2.10", batchBumpBranch("rancher-istio/1.22/rancher-istio", "2.10"))
+}
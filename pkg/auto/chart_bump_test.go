@@ -99,12 +99,18 @@ func Test_parseChartFromPackage(t *testing.T) {
 		expected expected
 	}
 
+	groups := ChartGroups{
+		"fleet":                {"fleet", "fleet-crd", "fleet-agent"},
+		"prometheus-federator": {"prometheus-federator"},
+		"rancher-monitoring":   {"rancher-monitoring", "rancher-monitoring-crd"},
+	}
+
 	tests := []test{
 		{
 			name: "#1",
 			input: input{
 				targetPackage: "fleet",
-				bump:          &Bump{},
+				bump:          &Bump{groups: groups},
 			},
 			expected: expected{
 				err: nil,
@@ -117,7 +123,7 @@ func Test_parseChartFromPackage(t *testing.T) {
 			name: "#2",
 			input: input{
 				targetPackage: "packages/v100/fleet",
-				bump:          &Bump{},
+				bump:          &Bump{groups: groups},
 			},
 			expected: expected{
 				err: nil,
@@ -130,7 +136,7 @@ func Test_parseChartFromPackage(t *testing.T) {
 			name: "#3",
 			input: input{
 				targetPackage: "rancher-monitoring/v100/rancher-monitoring/prometheus-federator",
-				bump:          &Bump{},
+				bump:          &Bump{groups: groups},
 			},
 			expected: expected{
 				err: nil,
@@ -143,7 +149,7 @@ func Test_parseChartFromPackage(t *testing.T) {
 			name: "#3.1",
 			input: input{
 				targetPackage: "rancher-monitoring/prometheus-federator",
-				bump:          &Bump{},
+				bump:          &Bump{groups: groups},
 			},
 			expected: expected{
 				err: nil,
@@ -156,7 +162,7 @@ func Test_parseChartFromPackage(t *testing.T) {
 			name: "#3.2",
 			input: input{
 				targetPackage: "rancher-monitoring/rancher-monitoring",
-				bump:          &Bump{},
+				bump:          &Bump{groups: groups},
 			},
 			expected: expected{
 				err: nil,
@@ -165,6 +171,17 @@ func Test_parseChartFromPackage(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "#4 chart not in any group",
+			input: input{
+				targetPackage: "rancher-istio",
+				bump:          &Bump{groups: groups},
+			},
+			expected: expected{
+				err:  nil,
+				bump: &Bump{target: target{main: "rancher-istio", additional: []string{"rancher-istio"}}},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -172,6 +189,7 @@ func Test_parseChartFromPackage(t *testing.T) {
 			err := tc.input.bump.parseChartFromPackage(tc.input.targetPackage)
 			assertError(t, err, tc.expected.err)
 			assert.Equal(t, tc.expected.bump.target.main, tc.input.bump.target.main)
+			assert.Equal(t, tc.expected.bump.target.additional, tc.input.bump.target.additional)
 		})
 	}
 }
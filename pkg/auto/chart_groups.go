@@ -0,0 +1,185 @@
+package auto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/charts"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// ChartGroups maps the main chart of each group to the charts bumped, forward-ported and released together with it,
+// starting with the main chart. e.g., fleet: [fleet, fleet-crd, fleet-agent]
+type ChartGroups map[string][]string
+
+// upstreamArchiveVersion matches the version at the end of an upstream chart archive name, e.g. -0.9.0 in fleet-crd-0.9.0
+var upstreamArchiveVersion = regexp.MustCompile(`-v?[0-9]+\.[0-9]+\.[0-9]+.*$`)
+
+// LoadChartGroups derives a chart group from each package.yaml, the main chart and its additionalCharts,
+// and overrides them with the groups declared in the chartGroups of configuration.yaml.
+func LoadChartGroups(ctx context.Context, repoRoot string, configured map[string][]string) (ChartGroups, error) {
+	groups := make(ChartGroups)
+
+	packages, err := charts.ListPackages(ctx, repoRoot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rootFs := filesystem.GetFilesystem(repoRoot)
+	for _, pkg := range packages {
+		main, members, err := packageChartGroup(ctx, rootFs, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chart group of package %s: %w", pkg, err)
+		}
+		// packages of the same chart at different versions, e.g. rancher-istio/1.22/rancher-istio
+		for _, member := range members {
+			if !slices.Contains(groups[main], member) {
+				groups[main] = append(groups[main], member)
+			}
+		}
+	}
+
+	for main, members := range configured {
+		if !slices.Contains(members, main) {
+			members = append([]string{main}, members...)
+		}
+		groups[main] = members
+	}
+
+	logger.Log(ctx, slog.LevelDebug, "chart groups loaded", slog.Any("groups", groups))
+	return groups, nil
+}
+
+// packageChartGroup returns the main chart of the package, named after the last element of the package path,
+// and the charts of its group
+func packageChartGroup(ctx context.Context, rootFs billy.Filesystem, pkg string) (string, []string, error) {
+	main := filepath.Base(pkg)
+
+	pkgFs, err := rootFs.Chroot(filepath.Join(path.RepositoryPackagesDir, pkg))
+	if err != nil {
+		return "", nil, err
+	}
+	pkgOpts, err := options.LoadPackageOptionsFromFile(ctx, pkgFs, path.PackageOptionsFile)
+	if err != nil {
+		return "", nil, err
+	}
+
+	members := []string{main}
+	for _, additional := range pkgOpts.AdditionalChartOptions {
+		name := additionalChartName(pkgFs, main, additional)
+		if name == "" {
+			logger.Log(ctx, slog.LevelWarn, "could not derive the additional chart name; declare the group in configuration.yaml",
+				slog.String("package", pkg), slog.String("workingDir", additional.WorkingDir))
+			continue
+		}
+		members = append(members, name)
+	}
+	return main, members, nil
+}
+
+// additionalChartName derives the name of an additional chart from, in order:
+//
+//	the Chart.yaml of its working directory (after make prepare) or of its CRD template directory
+//	the upstream subdirectory or archive name, e.g. fleet-agent for .../fleet-agent-0.9.0.tgz
+//	the working directory suffix, e.g. fleet-crd for the charts-crd working directory of fleet
+func additionalChartName(pkgFs billy.Filesystem, main string, additional options.AdditionalChartOptions) string {
+	if name := chartYamlName(pkgFs, additional.WorkingDir); name != "" {
+		return name
+	}
+	if additional.CRDChartOptions != nil {
+		if name := chartYamlName(pkgFs, additional.CRDChartOptions.TemplateDirectory); name != "" {
+			return name
+		}
+	}
+	if upstream := additional.UpstreamOptions; upstream != nil {
+		if upstream.Subdirectory != nil && *upstream.Subdirectory != "" {
+			return filepath.Base(*upstream.Subdirectory)
+		}
+		if strings.HasSuffix(upstream.URL, ".tgz") {
+			return upstreamArchiveVersion.ReplaceAllString(strings.TrimSuffix(filepath.Base(upstream.URL), ".tgz"), "")
+		}
+	}
+	if suffix, found := strings.CutPrefix(additional.WorkingDir, "charts-"); found && suffix != "" {
+		return main + "-" + suffix
+	}
+	return ""
+}
+
+// chartYamlName returns the chart name of the Chart.yaml in the directory of the package, or "" if there is none
+func chartYamlName(pkgFs billy.Filesystem, dir string) string {
+	if dir == "" {
+		return ""
+	}
+	metadata, err := chartutil.LoadChartfile(filesystem.GetAbsPath(pkgFs, filepath.Join(dir, "Chart.yaml")))
+	if err != nil {
+		return ""
+	}
+	return metadata.Name
+}
+
+// Members returns the charts of the group of the main chart, or only the chart if it is not the main chart of a group
+func (g ChartGroups) Members(chart string) []string {
+	if members, ok := g[chart]; ok {
+		return members
+	}
+	return []string{chart}
+}
+
+// Main returns the main chart of the group the chart belongs to.
+// Charts not covered by any group are their own main chart, except CRD charts that belong to the chart they are named after.
+func (g ChartGroups) Main(chart string) string {
+	if _, ok := g[chart]; ok {
+		return chart
+	}
+	for _, main := range g.mains() {
+		if slices.Contains(g[main], chart) {
+			return main
+		}
+	}
+	return strings.TrimSuffix(chart, "-crd")
+}
+
+// Uncovered returns the charts that do not belong to any group, sorted by name
+func (g ChartGroups) Uncovered(names []string) []string {
+	uncovered := []string{}
+	for _, chart := range names {
+		main := g.Main(chart)
+		if _, ok := g[main]; !ok || !slices.Contains(g[main], chart) {
+			uncovered = append(uncovered, chart)
+		}
+	}
+	sort.Strings(uncovered)
+	return uncovered
+}
+
+// Write writes each group as "<main>: <member>, <member>..." sorted by the main chart
+func (g ChartGroups) Write(w io.Writer) error {
+	for _, main := range g.mains() {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", main, strings.Join(g[main], ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mains returns the main charts sorted by name
+func (g ChartGroups) mains() []string {
+	mains := make([]string, 0, len(g))
+	for main := range g {
+		mains = append(mains, main)
+	}
+	sort.Strings(mains)
+	return mains
+}
@@ -0,0 +1,122 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadChartGroups(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+	writeFile := func(file, content string) {
+		t.Helper()
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoRoot, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, file), []byte(content), 0644))
+	}
+
+	writeFile("packages/fleet/package.yaml", `url: https://github.com/rancher/fleet/releases/download/v0.9.0/fleet-0.9.0.tgz
+additionalCharts:
+- workingDir: charts-crd
+  upstreamOptions:
+    url: https://github.com/rancher/fleet/releases/download/v0.9.0/fleet-crd-0.9.0.tgz
+- workingDir: charts-agent
+  upstreamOptions:
+    url: https://github.com/rancher/fleet.git
+    subdirectory: charts/fleet-agent
+`)
+	writeFile("packages/longhorn/package.yaml", `url: https://github.com/longhorn/charts.git
+additionalCharts:
+- workingDir: charts-crd
+  crdOptions:
+    templateDirectory: crd-template
+`)
+	writeFile("packages/longhorn/crd-template/Chart.yaml", "apiVersion: v2\nname: longhorn-crds\nversion: 1.0.0\n")
+	writeFile("packages/rancher-istio/1.22/rancher-istio/package.yaml", "url: https://github.com/rancher/istio.git\n")
+	writeFile("packages/rancher-istio/1.23/rancher-istio/package.yaml", "url: https://github.com/rancher/istio.git\n")
+	writeFile("packages/neuvector/package.yaml", "url: https://neuvector.github.io/neuvector-helm/core-2.8.0.tgz\n")
+
+	groups, err := LoadChartGroups(context.Background(), repoRoot, map[string][]string{
+		"neuvector": {"neuvector-crd", "neuvector-monitor"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ChartGroups{
+		"fleet":         {"fleet", "fleet-crd", "fleet-agent"},
+		"longhorn":      {"longhorn", "longhorn-crds"},
+		"rancher-istio": {"rancher-istio"},
+		"neuvector":     {"neuvector", "neuvector-crd", "neuvector-monitor"},
+	}, groups)
+}
+
+func Test_ChartGroups(t *testing.T) {
+	groups := ChartGroups{
+		"fleet":     {"fleet", "fleet-crd", "fleet-agent"},
+		"neuvector": {"neuvector", "neuvector-crd", "neuvector-monitor"},
+	}
+
+	t.Run("#1 main chart", func(t *testing.T) {
+		assert.Equal(t, "fleet", groups.Main("fleet"))
+		assert.Equal(t, "fleet", groups.Main("fleet-agent"))
+		assert.Equal(t, "neuvector", groups.Main("neuvector-monitor"))
+		assert.Equal(t, "longhorn", groups.Main("longhorn-crd"))
+		assert.Equal(t, "rancher-webhook", groups.Main("rancher-webhook"))
+	})
+
+	t.Run("#2 members", func(t *testing.T) {
+		assert.Equal(t, []string{"fleet", "fleet-crd", "fleet-agent"}, groups.Members("fleet"))
+		assert.Equal(t, []string{"fleet-agent"}, groups.Members("fleet-agent"))
+		assert.Equal(t, []string{"rancher-webhook"}, groups.Members("rancher-webhook"))
+	})
+
+	t.Run("#3 uncovered charts", func(t *testing.T) {
+		assert.Equal(t, []string{"longhorn", "longhorn-crd"}, groups.Uncovered([]string{"longhorn-crd", "fleet-crd", "neuvector", "longhorn"}))
+	})
+
+	t.Run("#4 write", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, groups.Write(&out))
+		assert.Equal(t, "fleet: fleet, fleet-crd, fleet-agent\nneuvector: neuvector, neuvector-crd, neuvector-monitor\n", out.String())
+	})
+}
+
+func Test_organizePullRequestsByChart(t *testing.T) {
+	f := &ForwardPort{
+		VR:           &lifecycle.VersionRules{BranchVersion: "2.10"},
+		groups:       ChartGroups{"fleet": {"fleet", "fleet-crd", "fleet-agent"}},
+		pullRequests: make(map[string]PullRequest),
+	}
+
+	f.organizePullRequestsByChart([]Command{
+		{Chart: "fleet", Version: "105.0.0+up0.11.0"},
+		{Chart: "fleet-agent", Version: "105.0.0+up0.11.0"},
+		{Chart: "fleet-crd", Version: "105.0.0+up0.11.0"},
+		{Chart: "longhorn", Version: "105.0.0+up1.7.0"},
+		{Chart: "longhorn-crd", Version: "105.0.0+up1.7.0"},
+		{Chart: "rancher-aks-operator", Version: "105.0.0+up1.9.0"},
+		{Chart: "rancher-gke-operator", Version: "105.0.0+up1.9.0"},
+	})
+
+	assert.Equal(t, map[string]PullRequest{
+		"fleet": {branch: "auto-forward-port-fleet-2.10", commands: []Command{
+			{Chart: "fleet", Version: "105.0.0+up0.11.0"},
+			{Chart: "fleet-agent", Version: "105.0.0+up0.11.0"},
+			{Chart: "fleet-crd", Version: "105.0.0+up0.11.0"},
+		}},
+		"longhorn": {branch: "auto-forward-port-longhorn-2.10", commands: []Command{
+			{Chart: "longhorn", Version: "105.0.0+up1.7.0"},
+			{Chart: "longhorn-crd", Version: "105.0.0+up1.7.0"},
+		}},
+		"rancher-aks-operator": {branch: "auto-forward-port-rancher-aks-operator-2.10", commands: []Command{
+			{Chart: "rancher-aks-operator", Version: "105.0.0+up1.9.0"},
+		}},
+		"rancher-gke-operator": {branch: "auto-forward-port-rancher-gke-operator-2.10", commands: []Command{
+			{Chart: "rancher-gke-operator", Version: "105.0.0+up1.9.0"},
+		}},
+	}, f.pullRequests)
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/rancher/charts-build-scripts/pkg/git"
 	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
 )
 
 /**
@@ -16,14 +18,18 @@ import (
 **/
 
 // CreateForwardPortStructure will create the ForwardPort struct with access to the necessary dependencies.
-// It will also check if yq command is installed on the system.
-func CreateForwardPortStructure(ctx context.Context, ld *lifecycle.Dependencies, assetsToPort map[string][]lifecycle.Asset, forkURL string) (*ForwardPort, error) {
+// It will also check if the upstream remote is configured and if the fork is a valid fork of the configured upstream repository.
+// If a GitHub client is given, a pull request will be opened for each pushed branch (or only printed on prDryRun).
+// On resume, the journal of the previous run is loaded and the completed branches are skipped.
+// The assets are forward-ported in a pull request per chart group.
+func CreateForwardPortStructure(ctx context.Context, ld *lifecycle.Dependencies, assetsToPort map[string][]lifecycle.Asset, groups ChartGroups, forkURL string, ghOpts *options.GithubOptions, gh *Github, prDryRun, resume bool) (*ForwardPort, error) {
 	logger.Log(ctx, slog.LevelInfo, "preparing forward port data")
 
-	// is yq installed?
-	yqPath, err := whichYQCommand(ctx)
-	if err != nil {
-		return nil, err
+	ghOpts = GithubDefaults(ghOpts)
+
+	ld.Git.UpstreamURL = ghOpts.UpstreamURL
+	if _, err := ld.Git.UpstreamRemote(); err != nil {
+		return nil, fmt.Errorf("upstream remote not found; you need to have the upstream remote configured in your git repository (%s)", ghOpts.UpstreamURL)
 	}
 
 	_, ok := ld.Git.Remotes[forkURL]
@@ -32,19 +38,34 @@ func CreateForwardPortStructure(ctx context.Context, ld *lifecycle.Dependencies,
 		return nil, errGitRemote
 	}
 
-	isForkRemoteConfigured := git.CheckForValidForkRemote(chartsRepoURL, forkURL, chartsRepoName)
+	isForkRemoteConfigured := git.CheckForValidForkRemote(ghOpts.UpstreamURL, forkURL, ghOpts.Repo)
 	if !isForkRemoteConfigured {
 		errRemoteConfig := fmt.Errorf("Remote %s not configured correctly, you need to configure your fork on your git remote references", forkURL)
 		return nil, errRemoteConfig
 	}
 
+	journal := newJournal(journalPath(ld.VR.BranchVersion), ld.Git.Branch, ld.VR.DevBranch)
+	if resume {
+		previous, err := loadJournal(journal.path)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			journal = previous
+		}
+	}
+
 	return &ForwardPort{
-		yqPath:                  yqPath,
 		git:                     ld.Git,
+		rootFs:                  ld.RootFs,
 		VR:                      ld.VR,
 		assetsToBeForwardPorted: assetsToPort,
+		groups:                  groups,
 		pullRequests:            make(map[string]PullRequest),
 		forkRemoteURL:           forkURL,
+		gh:                      gh,
+		prDryRun:                prDryRun,
+		journal:                 journal,
 	}, nil
 }
 
@@ -52,49 +73,36 @@ func CreateForwardPortStructure(ctx context.Context, ld *lifecycle.Dependencies,
 func (f *ForwardPort) ExecuteForwardPort(ctx context.Context, chart string) error {
 	logger.Log(ctx, slog.LevelInfo, "starting forward port")
 
-	// Get the forward port script commands
-	commands, err := f.createForwardPortCommands(ctx, chart)
-	if err != nil {
-		return err
-	}
+	// Get the forward port commands
+	commands := f.createForwardPortCommands(ctx, chart)
 	// Organize the commands into pull requests grouping by chart with it's dependencies
 	f.organizePullRequestsByChart(commands)
 	// Execute the forward port commands
 	return f.executeForwardPorts(ctx)
 }
 
-// organizePullRequests will organize the commands into pull requests
+// organizePullRequestsByChart will organize the commands into a pull request per chart group,
+// e.g. fleet, fleet-crd and fleet-agent are forward-ported in the same pull request
 func (f *ForwardPort) organizePullRequestsByChart(commands []Command) {
-	lastChart := ""
-
 	for _, command := range commands {
+		main := f.groups.Main(command.Chart)
 
-		changed := checkIfChartChanged(lastChart, command.Chart)
-
-		// If the chart is the same as the last chart, append the command to the pull request
-		if !changed {
-			pr := f.pullRequests[lastChart]            // Extract the struct from the map
-			pr.commands = append(pr.commands, command) // Modify the struct's field
-			f.pullRequests[lastChart] = pr             // Put the modified struct back into the map
-		}
-
-		// If the chart is different from the last chart, organize a new pull request
-		if changed {
-			pr := PullRequest{
-				branch:   fmt.Sprintf("auto-forward-port-%s-%s", command.Chart, f.VR.BranchVersion),
-				commands: []Command{command},
-			}
-			f.pullRequests[command.Chart] = pr
-			lastChart = command.Chart
+		pr, ok := f.pullRequests[main]
+		if !ok {
+			pr.branch = fmt.Sprintf("auto-forward-port-%s-%s", main, f.VR.BranchVersion)
 		}
+		pr.commands = append(pr.commands, command)
+		f.pullRequests[main] = pr
 	}
 }
 
 // executeForwardPorts will execute the forward-port commands.
 // It will create a new branch to forward-port the assets, clean the release.yaml file and commit.
 // After each forward-port execution it will add and commit the changes to the git repository.
-// It will push the branch to the remote repository and delete the local branch before moving,
-// to the next Pull Request.
+// It will push the branch to the remote repository, open the pull request,
+// and delete the local branch before moving to the next Pull Request.
+// Every step is recorded in the journal; branches already done are skipped
+// and branches already pushed only get their pull request opened.
 func (f *ForwardPort) executeForwardPorts(ctx context.Context) error {
 	// save the original branch to change back after forward-port
 	originalBranch := f.git.Branch
@@ -110,50 +118,139 @@ func (f *ForwardPort) executeForwardPorts(ctx context.Context) error {
 	fpLogs.WriteHEAD(ctx, f.VR, "Forward-Ported Assets")
 
 	for asset, pr := range f.pullRequests {
-		fpLogs.Write(ctx, asset, "INFO")
-
-		// open and check if it is clean the git repo
-		if err := f.createNewBranchToForwardPort(ctx, pr.branch); err != nil {
-			return err
-		}
-		// clean release.yaml in the new branch
-		if err := prepareReleaseYaml(ctx); err != nil {
-			return err
-		}
-		// git add && commit cleaned release.yaml
-		if err := f.git.AddAndCommit("cleaning release.yaml"); err != nil {
-			return err
+		entry := f.journal.entry(pr.branch, asset)
+		if entry.State == journalDone {
+			logger.Log(ctx, slog.LevelInfo, "skipping already forward-ported branch", slog.String("branch", pr.branch))
+			continue
 		}
 
-		fpLogs.Write(ctx, "Branch: "+pr.branch, "INFO")
+		fpLogs.Write(ctx, asset, "INFO")
 
-		for _, command := range pr.commands {
-			// execute make forward-port
-			if err := executeCommand(ctx, command.Command, f.yqPath); err != nil {
-				return err
-			}
-			// git add && commit the changes
-			msg := "forward-port " + command.Chart + " " + command.Version
-			if err := f.git.AddAndCommit(msg); err != nil {
+		if entry.State != journalPushed {
+			if err := f.forwardPortBranch(ctx, pr, fpLogs); err != nil {
 				return err
 			}
-			// Log this so later we can merge the PRs
-			fpLogs.Write(ctx, msg, "")
 		}
-		// push branch
-		if err := f.git.PushBranch(f.git.Remotes[f.forkRemoteURL], pr.branch); err != nil {
+
+		// open or update the pull request and save its URL for merging later
+		prURL, err := f.openPullRequest(ctx, asset, pr, originalBranch)
+		if err != nil {
+			// the branch is pushed, change back to the original branch so --resume only opens the pull request
+			if checkoutErr := f.git.CheckoutBranch(originalBranch); checkoutErr != nil {
+				logger.Log(ctx, slog.LevelError, "failed to checkout the original branch", slog.String("branch", originalBranch), logger.Err(checkoutErr))
+			}
 			return err
 		}
-		// save to log file branch
-		fpLogs.Write(ctx, "PUSHED", "INFO")
+		if prURL != "" {
+			fpLogs.Write(ctx, "PR: "+prURL, "INFO")
+			entry.PullRequest = prURL
+		}
 		// Change back to the original branch to avoid conflicts
 		if err := f.git.CheckoutBranch(originalBranch); err != nil {
 			return err
 		}
 		// delete local created and pushed branch
-		if err := f.git.DeleteBranch(pr.branch); err != nil {
+		if f.git.BranchExists(pr.branch) {
+			if err := f.git.DeleteBranch(pr.branch); err != nil {
+				return err
+			}
+		}
+		if err := f.journal.setState(pr.branch, journalDone); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// forwardPortBranch creates the pull request branch, commits each forward-ported asset version and pushes it to the fork.
+func (f *ForwardPort) forwardPortBranch(ctx context.Context, pr PullRequest, fpLogs *lifecycle.Logs) error {
+	// open and check if it is clean the git repo
+	if err := f.createNewBranchToForwardPort(ctx, pr.branch); err != nil {
+		return err
+	}
+	if err := f.journal.setState(pr.branch, journalCreated); err != nil {
+		return err
+	}
+	// clean release.yaml in the new branch
+	if err := prepareReleaseYaml(ctx); err != nil {
+		return err
+	}
+	// git add && commit cleaned release.yaml
+	if err := f.commit(pr.branch, "cleaning release.yaml"); err != nil {
+		return err
+	}
+
+	fpLogs.Write(ctx, "Branch: "+pr.branch, "INFO")
+
+	for _, command := range pr.commands {
+		// forward-port the asset version from the development branch
+		if err := f.forwardPortAsset(ctx, command.Chart, command.Version); err != nil {
+			return err
+		}
+		// git add && commit the changes
+		msg := "forward-port " + command.Chart + " " + command.Version
+		if err := f.commit(pr.branch, msg); err != nil {
+			return err
+		}
+		// Log this so later we can merge the PRs
+		fpLogs.Write(ctx, msg, "")
+	}
+	if err := f.journal.setState(pr.branch, journalCommitted); err != nil {
+		return err
+	}
+
+	// push branch
+	if err := f.git.PushBranch(f.git.Remotes[f.forkRemoteURL], pr.branch); err != nil {
+		return err
+	}
+	// save to log file branch
+	fpLogs.Write(ctx, "PUSHED", "INFO")
+	return f.journal.setState(pr.branch, journalPushed)
+}
+
+// commit adds and commits all changes, recording the commit in the journal
+func (f *ForwardPort) commit(branch, message string) error {
+	if err := f.git.AddAndCommit(message); err != nil {
+		return err
+	}
+
+	sha, err := f.git.HeadCommit()
+	if err != nil {
+		return err
+	}
+
+	return f.journal.addCommit(branch, message, sha)
+}
+
+// openPullRequest will open or update the pull request from the pushed fork branch into the base branch.
+// It returns the pull request URL, which is empty if no GitHub client is configured or on dry-run.
+func (f *ForwardPort) openPullRequest(ctx context.Context, chart string, pr PullRequest, base string) (string, error) {
+	if f.gh == nil {
+		logger.Log(ctx, slog.LevelWarn, "no GitHub token provided; skipping pull request creation", slog.String("branch", pr.branch))
+		return "", nil
+	}
+
+	title := fmt.Sprintf("[%s] forward-port %s", base, chart)
+	payload := f.gh.newPullRequestPayload(title, forwardPortPullRequestBody(f.VR, base, pr.commands), ownerFromRemoteURL(f.forkRemoteURL), pr.branch, base)
+
+	return f.gh.CreateOrUpdatePullRequest(ctx, payload, f.prDryRun)
+}
+
+// forwardPortPullRequestBody lists the forward-ported charts and versions in a markdown table,
+// with the release branch each version was released from
+func forwardPortPullRequestBody(vr *lifecycle.VersionRules, base string, commands []Command) string {
+	var body strings.Builder
+
+	body.WriteString("Automated forward-port of the following chart versions into `" + base + "`:\n\n")
+	body.WriteString("| Chart | Version | Released from |\n")
+	body.WriteString("|---|---|---|\n")
+	for _, command := range commands {
+		source := "unknown"
+		if branchVersion := vr.BranchOf(command.Version); branchVersion != "" {
+			source = "`" + vr.ProdBranchPrefix + branchVersion + "`"
+		}
+		body.WriteString("| " + command.Chart + " | " + command.Version + " | " + source + " |\n")
+	}
+
+	return body.String()
+}
@@ -0,0 +1,117 @@
+package auto
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+// newForwardPortTestRepos creates an upstream repository with a main branch and a dev branch
+// holding the given chart assets, and a local clone of it checked out at main.
+func newForwardPortTestRepos(t *testing.T, devBranch string, assets map[string][]string) (string, string) {
+	t.Helper()
+	upstreamDir := filepath.Join(t.TempDir(), "upstream")
+	localDir := filepath.Join(t.TempDir(), "local")
+
+	gittest.NewRepo(t, upstreamDir, "main", map[string]string{"README.md": "charts\n"})
+	gittest.Run(t, upstreamDir, "checkout", "-b", devBranch)
+	for name, versions := range assets {
+		assetDir := filepath.Join(upstreamDir, "assets", name)
+		assert.NoError(t, os.MkdirAll(assetDir, 0755))
+		for _, version := range versions {
+			c := &chart.Chart{
+				Metadata: &chart.Metadata{
+					APIVersion: chart.APIVersionV2,
+					Name:       name,
+					Version:    version,
+					Icon:       "https://charts.rancher.io/assets/logos/" + name + ".svg",
+				},
+			}
+			_, err := chartutil.Save(c, assetDir)
+			assert.NoError(t, err)
+		}
+	}
+	gittest.Commit(t, upstreamDir, "add assets", nil)
+	gittest.Run(t, upstreamDir, "checkout", "main")
+	gittest.Clone(t, upstreamDir, localDir, "main")
+
+	return upstreamDir, localDir
+}
+
+func Test_forwardPortAsset(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+	upstreamDir, localDir := newForwardPortTestRepos(t, "dev-v2.9", map[string][]string{
+		"chart-1": {"104.0.0+up1.0.0", "104.1.0+up1.1.0"},
+		"chart-2": {"104.0.0+up2.0.0"},
+	})
+
+	f := &ForwardPort{
+		git: &git.Git{
+			Dir:         localDir,
+			Branch:      "main",
+			Remotes:     map[string]string{upstreamDir: "upstream"},
+			UpstreamURL: upstreamDir,
+		},
+		rootFs: filesystem.GetFilesystem(localDir),
+		VR:     &lifecycle.VersionRules{DevBranch: "dev-v2.9"},
+	}
+
+	t.Run("#1 forward-port a single version", func(t *testing.T) {
+		assert.NoError(t, f.forwardPortAsset(ctx, "chart-1", "104.1.0+up1.1.0"))
+
+		assert.FileExists(t, filepath.Join(localDir, "assets", "chart-1", "chart-1-104.1.0+up1.1.0.tgz"))
+		assert.FileExists(t, filepath.Join(localDir, "charts", "chart-1", "104.1.0+up1.1.0", "Chart.yaml"))
+		assert.NoFileExists(t, filepath.Join(localDir, "assets", "chart-1", "chart-1-104.0.0+up1.0.0.tgz"))
+
+		releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, f.rootFs, "release.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, options.ReleaseOptions{"chart-1": {"104.1.0+up1.1.0"}}, releaseOpts)
+
+		index, err := helmRepo.LoadIndexFile(filepath.Join(localDir, "index.yaml"))
+		assert.NoError(t, err)
+		assert.True(t, index.Has("chart-1", "104.1.0+up1.1.0"))
+		assert.False(t, index.Has("chart-1", "104.0.0+up1.0.0"))
+	})
+
+	t.Run("#2 forward-port versions of another chart are appended", func(t *testing.T) {
+		assert.NoError(t, f.forwardPortAsset(ctx, "chart-2", "104.0.0+up2.0.0"))
+		assert.NoError(t, f.forwardPortAsset(ctx, "chart-1", "104.0.0+up1.0.0"))
+
+		releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, f.rootFs, "release.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, options.ReleaseOptions{
+			"chart-1": {"104.0.0+up1.0.0", "104.1.0+up1.1.0"},
+			"chart-2": {"104.0.0+up2.0.0"},
+		}, releaseOpts)
+
+		index, err := helmRepo.LoadIndexFile(filepath.Join(localDir, "index.yaml"))
+		assert.NoError(t, err)
+		assert.True(t, index.Has("chart-1", "104.0.0+up1.0.0"))
+		assert.True(t, index.Has("chart-1", "104.1.0+up1.1.0"))
+		assert.True(t, index.Has("chart-2", "104.0.0+up2.0.0"))
+	})
+
+	t.Run("#3 version not found in the development branch", func(t *testing.T) {
+		assert.Error(t, f.forwardPortAsset(ctx, "chart-1", "105.0.0+up1.0.0"))
+	})
+
+	t.Run("#4 changes can be committed", func(t *testing.T) {
+		assert.NoError(t, f.git.AddAndCommit("forward-port chart versions"))
+		clean, err := f.git.StatusProcelain(ctx)
+		assert.NoError(t, err)
+		assert.True(t, clean)
+	})
+}
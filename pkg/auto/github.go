@@ -0,0 +1,218 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"golang.org/x/oauth2"
+)
+
+// Referred to: https://github.com/rancher/charts
+const (
+	defaultGithubOwner = "rancher"
+	defaultGithubRepo  = "charts"
+)
+
+// Github holds the GitHub API client and the repository it should operate on
+type Github struct {
+	client *github.Client
+	Owner  string
+	Repo   string
+	// labels and reviewers applied to every opened pull request
+	prOptions options.PullRequestOptions
+}
+
+// PullRequestPayload holds everything needed to open or update a pull request
+type PullRequestPayload struct {
+	Title         string   `json:"title"`
+	Body          string   `json:"body"`
+	Head          string   `json:"head"` // <owner>:<branch>
+	Base          string   `json:"base"`
+	Labels        []string `json:"labels,omitempty"`
+	Reviewers     []string `json:"reviewers,omitempty"`
+	TeamReviewers []string `json:"team_reviewers,omitempty"`
+}
+
+// GithubDefaults returns a copy of the given options with the empty fields set to the rancher/charts defaults.
+// The upstream URL defaults to https://github.com/<owner>/<repo> when owner or repo are customized.
+func GithubDefaults(opts *options.GithubOptions) *options.GithubOptions {
+	gh := options.GithubOptions{}
+	if opts != nil {
+		gh = *opts
+	}
+
+	if gh.Owner == "" {
+		gh.Owner = defaultGithubOwner
+	}
+	if gh.Repo == "" {
+		gh.Repo = defaultGithubRepo
+	}
+	if gh.UpstreamURL == "" {
+		gh.UpstreamURL = git.DefaultUpstreamURL
+		if gh.Owner != defaultGithubOwner || gh.Repo != defaultGithubRepo {
+			gh.UpstreamURL = "https://github.com/" + gh.Owner + "/" + gh.Repo
+		}
+	}
+
+	return &gh
+}
+
+// NewGithub creates the GitHub client for the configured repository.
+// If a token is given the client is authenticated with it.
+// If an API URL is given (GitHub Enterprise or a fake server) the client is pointed at it instead of api.github.com.
+func NewGithub(ctx context.Context, token string, opts *options.GithubOptions) (*Github, error) {
+	opts = GithubDefaults(opts)
+
+	httpClient := http.DefaultClient
+	if token != "" {
+		tokenSource := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		httpClient = oauth2.NewClient(ctx, tokenSource)
+	}
+
+	client := github.NewClient(httpClient)
+	if opts.APIURL != "" {
+		var err error
+		client, err = github.NewEnterpriseClient(opts.APIURL, opts.APIURL, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub API URL %s: %w", opts.APIURL, err)
+		}
+	}
+
+	return &Github{
+		client:    client,
+		Owner:     opts.Owner,
+		Repo:      opts.Repo,
+		prOptions: opts.PullRequestOptions,
+	}, nil
+}
+
+// getPullRequest returns the pull request with the given number
+func (g *Github) getPullRequest(ctx context.Context, number int) (*github.PullRequest, error) {
+	pr, resp, err := g.client.PullRequests.Get(ctx, g.Owner, g.Repo, number)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get pull request, status code: %s", resp.Status)
+	}
+
+	return pr, nil
+}
+
+// listPullRequestFiles returns the files changed by the pull request with the given number
+func (g *Github) listPullRequestFiles(ctx context.Context, number int) ([]*github.CommitFile, error) {
+	files, _, err := g.client.PullRequests.ListFiles(ctx, g.Owner, g.Repo, number, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// newPullRequestPayload creates the payload for a pull request from headOwner:branch into base,
+// with the labels and reviewers from the configuration.
+func (g *Github) newPullRequestPayload(title, body, headOwner, branch, base string) *PullRequestPayload {
+	if headOwner == "" {
+		headOwner = g.Owner
+	}
+
+	return &PullRequestPayload{
+		Title:         title,
+		Body:          body,
+		Head:          headOwner + ":" + branch,
+		Base:          base,
+		Labels:        g.prOptions.Labels,
+		Reviewers:     g.prOptions.Reviewers,
+		TeamReviewers: g.prOptions.TeamReviewers,
+	}
+}
+
+// CreateOrUpdatePullRequest opens a pull request with the given payload,
+// or updates the title and body of the open pull request for the same head and base.
+// Labels and reviewers are applied in both cases. It returns the pull request URL.
+// On dry-run the payload is only printed and no request is made.
+func (g *Github) CreateOrUpdatePullRequest(ctx context.Context, payload *PullRequestPayload, dryRun bool) (string, error) {
+	if dryRun {
+		data, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		logger.Log(ctx, slog.LevelInfo, "dry-run: pull request payload", slog.String("owner", g.Owner), slog.String("repo", g.Repo))
+		fmt.Println(string(data))
+		return "", nil
+	}
+
+	openPRs, _, err := g.client.PullRequests.List(ctx, g.Owner, g.Repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  payload.Head,
+		Base:  payload.Base,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pull requests for %s: %w", payload.Head, err)
+	}
+
+	var pr *github.PullRequest
+	if len(openPRs) > 0 {
+		logger.Log(ctx, slog.LevelInfo, "updating pull request", slog.Int("number", openPRs[0].GetNumber()))
+		pr, _, err = g.client.PullRequests.Edit(ctx, g.Owner, g.Repo, openPRs[0].GetNumber(), &github.PullRequest{
+			Title: github.String(payload.Title),
+			Body:  github.String(payload.Body),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to update pull request: %w", err)
+		}
+	} else {
+		logger.Log(ctx, slog.LevelInfo, "creating pull request", slog.String("head", payload.Head), slog.String("base", payload.Base))
+		pr, _, err = g.client.PullRequests.Create(ctx, g.Owner, g.Repo, &github.NewPullRequest{
+			Title:               github.String(payload.Title),
+			Body:                github.String(payload.Body),
+			Head:                github.String(payload.Head),
+			Base:                github.String(payload.Base),
+			MaintainerCanModify: github.Bool(true),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create pull request: %w", err)
+		}
+	}
+
+	if len(payload.Labels) > 0 {
+		if _, _, err := g.client.Issues.AddLabelsToIssue(ctx, g.Owner, g.Repo, pr.GetNumber(), payload.Labels); err != nil {
+			return pr.GetHTMLURL(), fmt.Errorf("failed to add labels to pull request: %w", err)
+		}
+	}
+
+	if len(payload.Reviewers) > 0 || len(payload.TeamReviewers) > 0 {
+		reviewers := github.ReviewersRequest{
+			Reviewers:     payload.Reviewers,
+			TeamReviewers: payload.TeamReviewers,
+		}
+		if _, _, err := g.client.PullRequests.RequestReviewers(ctx, g.Owner, g.Repo, pr.GetNumber(), reviewers); err != nil {
+			return pr.GetHTMLURL(), fmt.Errorf("failed to request reviewers for pull request: %w", err)
+		}
+	}
+
+	logger.Log(ctx, slog.LevelInfo, "pull request ready", slog.String("url", pr.GetHTMLURL()))
+	return pr.GetHTMLURL(), nil
+}
+
+// ownerFromRemoteURL returns the owner of a repository from its remote URL.
+// e.g. https://github.com/user/charts -> user ; git@github.com:user/charts.git -> user
+func ownerFromRemoteURL(remoteURL string) string {
+	parts := strings.FieldsFunc(strings.TrimSuffix(remoteURL, ".git"), func(r rune) bool {
+		return r == '/' || r == ':'
+	})
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[len(parts)-2]
+}
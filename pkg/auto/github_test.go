@@ -0,0 +1,252 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GithubDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *options.GithubOptions
+		expected *options.GithubOptions
+	}{
+		{
+			name:  "#1 nil options",
+			input: nil,
+			expected: &options.GithubOptions{
+				Owner:       "rancher",
+				Repo:        "charts",
+				UpstreamURL: "https://github.com/rancher/charts",
+			},
+		},
+		{
+			name: "#2 custom owner and repo",
+			input: &options.GithubOptions{
+				Owner: "user",
+				Repo:  "charts-prime",
+			},
+			expected: &options.GithubOptions{
+				Owner:       "user",
+				Repo:        "charts-prime",
+				UpstreamURL: "https://github.com/user/charts-prime",
+			},
+		},
+		{
+			name: "#3 GitHub Enterprise",
+			input: &options.GithubOptions{
+				Owner:       "rancher",
+				Repo:        "charts",
+				UpstreamURL: "https://git.mirror.io/rancher/charts",
+				APIURL:      "https://git.mirror.io/api/v3/",
+			},
+			expected: &options.GithubOptions{
+				Owner:       "rancher",
+				Repo:        "charts",
+				UpstreamURL: "https://git.mirror.io/rancher/charts",
+				APIURL:      "https://git.mirror.io/api/v3/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GithubDefaults(tt.input))
+		})
+	}
+}
+
+func Test_loadPullRequestValidation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/user/charts-prime/pulls/10", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 10, "title": "release chart-1"}`)
+	})
+	mux.HandleFunc("/api/v3/repos/user/charts-prime/pulls/10/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"filename": "assets/chart-1/chart-1-104.0.0.tgz", "status": "added"}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	gh, err := NewGithub(context.Background(), "", &options.GithubOptions{
+		Owner:  "user",
+		Repo:   "charts-prime",
+		APIURL: server.URL + "/api/v3/",
+	})
+	assert.NoError(t, err)
+
+	dep := &lifecycle.Dependencies{}
+
+	t.Run("#1 configured repository", func(t *testing.T) {
+		v, err := loadPullRequestValidation(context.Background(), gh, "10", dep)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, v.pr.GetNumber())
+		assert.Len(t, v.files, 1)
+		assert.Equal(t, "assets/chart-1/chart-1-104.0.0.tgz", v.files[0].GetFilename())
+		assert.Equal(t, dep, v.dep)
+	})
+
+	t.Run("#2 pull request not found", func(t *testing.T) {
+		_, err := loadPullRequestValidation(context.Background(), gh, "11", dep)
+		assert.Error(t, err)
+	})
+
+	t.Run("#3 invalid pull request number", func(t *testing.T) {
+		_, err := loadPullRequestValidation(context.Background(), gh, "abc", dep)
+		assert.Error(t, err)
+	})
+}
+
+func Test_CreateOrUpdatePullRequest(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		body   map[string]interface{}
+	}
+
+	newFakeServer := func(openPRs string, requests *[]request) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := request{method: r.Method, path: r.URL.Path}
+			if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+				json.Unmarshal(data, &req.body)
+			}
+			*requests = append(*requests, req)
+
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/api/v3/repos/rancher/charts/pulls":
+				assert.Equal(t, "user:auto-forward-port-fleet-2.10", r.URL.Query().Get("head"))
+				assert.Equal(t, "dev-v2.10", r.URL.Query().Get("base"))
+				fmt.Fprint(w, openPRs)
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/rancher/charts/pulls":
+				fmt.Fprint(w, `{"number": 7, "html_url": "https://github.com/rancher/charts/pull/7"}`)
+			case r.Method == http.MethodPatch && r.URL.Path == "/api/v3/repos/rancher/charts/pulls/5":
+				fmt.Fprint(w, `{"number": 5, "html_url": "https://github.com/rancher/charts/pull/5"}`)
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/rancher/charts/issues/7/labels",
+				r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/rancher/charts/issues/5/labels":
+				fmt.Fprint(w, `[]`)
+			case r.Method == http.MethodPost && r.URL.Path == "/api/v3/repos/rancher/charts/pulls/7/requested_reviewers":
+				fmt.Fprint(w, `{"number": 7}`)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	}
+
+	ghOpts := &options.GithubOptions{
+		PullRequestOptions: options.PullRequestOptions{
+			Labels:    []string{"forward-port"},
+			Reviewers: []string{"reviewer"},
+		},
+	}
+
+	t.Run("#1 create pull request with labels and reviewers", func(t *testing.T) {
+		requests := []request{}
+		server := newFakeServer(`[]`, &requests)
+		defer server.Close()
+
+		ghOpts.APIURL = server.URL + "/api/v3/"
+		ghOpts.PullRequestOptions.Reviewers = []string{"reviewer"}
+		gh, err := NewGithub(context.Background(), "token", ghOpts)
+		assert.NoError(t, err)
+
+		payload := gh.newPullRequestPayload("[dev-v2.10] forward-port fleet", "body", "user", "auto-forward-port-fleet-2.10", "dev-v2.10")
+		url, err := gh.CreateOrUpdatePullRequest(context.Background(), payload, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://github.com/rancher/charts/pull/7", url)
+
+		assert.Len(t, requests, 4)
+		assert.Equal(t, http.MethodPost, requests[1].method)
+		assert.Equal(t, "[dev-v2.10] forward-port fleet", requests[1].body["title"])
+		assert.Equal(t, "user:auto-forward-port-fleet-2.10", requests[1].body["head"])
+		assert.Equal(t, "dev-v2.10", requests[1].body["base"])
+		assert.Equal(t, "/api/v3/repos/rancher/charts/issues/7/labels", requests[2].path)
+		assert.Equal(t, "/api/v3/repos/rancher/charts/pulls/7/requested_reviewers", requests[3].path)
+	})
+
+	t.Run("#2 update existing pull request", func(t *testing.T) {
+		requests := []request{}
+		server := newFakeServer(`[{"number": 5}]`, &requests)
+		defer server.Close()
+
+		ghOpts.APIURL = server.URL + "/api/v3/"
+		ghOpts.PullRequestOptions.Reviewers = nil
+		gh, err := NewGithub(context.Background(), "token", ghOpts)
+		assert.NoError(t, err)
+
+		payload := gh.newPullRequestPayload("[dev-v2.10] forward-port fleet", "new body", "user", "auto-forward-port-fleet-2.10", "dev-v2.10")
+		url, err := gh.CreateOrUpdatePullRequest(context.Background(), payload, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://github.com/rancher/charts/pull/5", url)
+
+		assert.Len(t, requests, 3)
+		assert.Equal(t, http.MethodPatch, requests[1].method)
+		assert.Equal(t, "new body", requests[1].body["body"])
+	})
+
+	t.Run("#3 dry-run does not call the API", func(t *testing.T) {
+		requests := []request{}
+		server := newFakeServer(`[]`, &requests)
+		defer server.Close()
+
+		ghOpts.APIURL = server.URL + "/api/v3/"
+		gh, err := NewGithub(context.Background(), "", ghOpts)
+		assert.NoError(t, err)
+
+		payload := gh.newPullRequestPayload("[dev-v2.10] forward-port fleet", "body", "user", "auto-forward-port-fleet-2.10", "dev-v2.10")
+		url, err := gh.CreateOrUpdatePullRequest(context.Background(), payload, true)
+		assert.NoError(t, err)
+		assert.Empty(t, url)
+		assert.Empty(t, requests)
+	})
+}
+
+func Test_ownerFromRemoteURL(t *testing.T) {
+	tests := []struct {
+		remoteURL string
+		expected  string
+	}{
+		{"https://github.com/user/charts", "user"},
+		{"https://github.com/user/charts.git", "user"},
+		{"git@github.com:user/charts.git", "user"},
+		{"charts", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteURL, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ownerFromRemoteURL(tt.remoteURL))
+		})
+	}
+}
+
+func Test_forwardPortPullRequestBody(t *testing.T) {
+	vr := &lifecycle.VersionRules{
+		Rules: map[string]lifecycle.Version{
+			"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			"2.10": {Min: "105.0.0", Max: "106.0.0"},
+		},
+		DevBranch:        "dev-v2.10",
+		ProdBranchPrefix: "release-v",
+	}
+	commands := []Command{
+		{Chart: "fleet", Version: "104.0.0+up0.10.0"},
+		{Chart: "fleet-crd", Version: "104.0.0+up0.10.0"},
+		{Chart: "neuvector", Version: "99.0.0+up1.0.0"},
+	}
+
+	expected := "Automated forward-port of the following chart versions into `dev-v2.10`:\n\n" +
+		"| Chart | Version | Released from |\n" +
+		"|---|---|---|\n" +
+		"| fleet | 104.0.0+up0.10.0 | `release-v2.9` |\n" +
+		"| fleet-crd | 104.0.0+up0.10.0 | `release-v2.9` |\n" +
+		"| neuvector | 99.0.0+up1.0.0 | unknown |\n"
+
+	assert.Equal(t, expected, forwardPortPullRequestBody(vr, "dev-v2.10", commands))
+}
@@ -0,0 +1,178 @@
+package auto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+)
+
+/**
+* journal.go persists the progress of auto-forward-port
+* so a failed run can be resumed with --resume.
+**/
+
+// States of a forward-port branch in the journal, in the order they are reached
+const (
+	journalCreated   = "created"   // the local branch was created
+	journalCommitted = "committed" // every forward-port commit was created
+	journalPushed    = "pushed"    // the branch was pushed to the fork
+	journalDone      = "done"      // the pull request was opened and the local branch deleted
+)
+
+// Journal records the progress of each forward-port branch
+type Journal struct {
+	path       string
+	BaseBranch string                   `json:"base_branch"`
+	DevBranch  string                   `json:"dev_branch"`
+	Branches   map[string]*JournalEntry `json:"branches"`
+}
+
+// JournalEntry is the progress of a single forward-port branch
+type JournalEntry struct {
+	Chart       string          `json:"chart"`
+	State       string          `json:"state"`
+	Commits     []JournalCommit `json:"commits"`
+	PullRequest string          `json:"pull_request,omitempty"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+// JournalCommit is a commit created on a forward-port branch
+type JournalCommit struct {
+	Message string `json:"message"`
+	SHA     string `json:"sha"`
+}
+
+// journalPath returns the journal file for the given branch version, e.g. logs/forward-port-journal_2.10.json
+func journalPath(branchVersion string) string {
+	return fmt.Sprintf("logs/forward-port-journal_%s.json", branchVersion)
+}
+
+// newJournal creates an empty journal; it is only written to disk on the first update
+func newJournal(path, baseBranch, devBranch string) *Journal {
+	return &Journal{
+		path:       path,
+		BaseBranch: baseBranch,
+		DevBranch:  devBranch,
+		Branches:   make(map[string]*JournalEntry),
+	}
+}
+
+// loadJournal loads the journal from the given path, returning nil if it does not exist
+func loadJournal(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	j := &Journal{}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+	}
+	j.path = path
+	if j.Branches == nil {
+		j.Branches = make(map[string]*JournalEntry)
+	}
+
+	return j, nil
+}
+
+// save writes the journal to disk
+func (j *Journal) save() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.path, data, 0644)
+}
+
+// entry returns the entry for the branch, creating it if needed
+func (j *Journal) entry(branch, chart string) *JournalEntry {
+	e, ok := j.Branches[branch]
+	if !ok {
+		e = &JournalEntry{Chart: chart, Commits: []JournalCommit{}}
+		j.Branches[branch] = e
+	}
+	return e
+}
+
+// setState updates the state of the branch and saves the journal
+func (j *Journal) setState(branch, state string) error {
+	e := j.Branches[branch]
+	e.State = state
+	e.UpdatedAt = time.Now().Format(time.RFC3339)
+	return j.save()
+}
+
+// addCommit records a commit created on the branch and saves the journal
+func (j *Journal) addCommit(branch, message, sha string) error {
+	e := j.Branches[branch]
+	e.Commits = append(e.Commits, JournalCommit{Message: message, SHA: sha})
+	e.UpdatedAt = time.Now().Format(time.RFC3339)
+	return j.save()
+}
+
+// PrepareForwardPortResume cleans up the local branches of a failed forward-port run before resuming it.
+// Only branches recorded in the journal that were not pushed yet are touched:
+// if one of them is checked out its changes are discarded and the base branch is checked out again,
+// then the local branch is deleted so it can be created from scratch.
+// Pushed branches are kept, the resumed run will only open their pull requests.
+func PrepareForwardPortResume(ctx context.Context, g *git.Git, branchVersion string) error {
+	j, err := loadJournal(journalPath(branchVersion))
+	if err != nil {
+		return err
+	}
+	if j == nil {
+		logger.Log(ctx, slog.LevelWarn, "no forward-port journal found; nothing to resume", slog.String("journal", journalPath(branchVersion)))
+		return nil
+	}
+
+	for branch, e := range j.Branches {
+		if e.State != journalCreated && e.State != journalCommitted {
+			continue
+		}
+
+		logger.Log(ctx, slog.LevelInfo, "cleaning partially forward-ported branch", slog.String("branch", branch), slog.String("state", e.State))
+		if g.Branch == branch {
+			if err := g.HardHEADReset(); err != nil {
+				return fmt.Errorf("failed to reset %s: %w", branch, err)
+			}
+			if err := g.CleanUntracked(); err != nil {
+				return fmt.Errorf("failed to clean %s: %w", branch, err)
+			}
+			if err := g.CheckoutBranch(j.BaseBranch); err != nil {
+				return err
+			}
+			g.Branch = j.BaseBranch
+		}
+		if g.BranchExists(branch) {
+			if err := g.DeleteBranch(branch); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", branch, err)
+			}
+		}
+
+		e.State = ""
+		e.Commits = []JournalCommit{}
+	}
+
+	if g.Branch != j.BaseBranch {
+		return fmt.Errorf("the forward-port journal was started from %s but the current branch is %s", j.BaseBranch, g.Branch)
+	}
+
+	return j.save()
+}
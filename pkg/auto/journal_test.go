@@ -0,0 +1,153 @@
+package auto
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loadJournal(t *testing.T) {
+	journalFile := filepath.Join(t.TempDir(), "logs", "forward-port-journal_2.9.json")
+
+	t.Run("#1 missing journal", func(t *testing.T) {
+		j, err := loadJournal(journalFile)
+		assert.NoError(t, err)
+		assert.Nil(t, j)
+	})
+
+	t.Run("#2 saved journal is loaded", func(t *testing.T) {
+		j := newJournal(journalFile, "release-v2.9", "dev-v2.9")
+		j.entry("auto-forward-port-fleet-2.9", "fleet")
+		assert.NoError(t, j.setState("auto-forward-port-fleet-2.9", journalCreated))
+		assert.NoError(t, j.addCommit("auto-forward-port-fleet-2.9", "cleaning release.yaml", "abc"))
+
+		loaded, err := loadJournal(journalFile)
+		assert.NoError(t, err)
+		assert.Equal(t, j, loaded)
+		assert.Equal(t, journalCreated, loaded.Branches["auto-forward-port-fleet-2.9"].State)
+		assert.Equal(t, []JournalCommit{{Message: "cleaning release.yaml", SHA: "abc"}}, loaded.Branches["auto-forward-port-fleet-2.9"].Commits)
+	})
+
+	t.Run("#3 invalid journal", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(journalFile, []byte("{"), 0644))
+		_, err := loadJournal(journalFile)
+		assert.Error(t, err)
+	})
+}
+
+func Test_resumeForwardPort(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+	upstreamDir, localDir := newForwardPortTestRepos(t, "dev-v2.9", map[string][]string{
+		"fleet":    {"104.0.0+up0.10.0"},
+		"longhorn": {"104.0.0+up1.6.0"},
+	})
+	forkDir := filepath.Join(t.TempDir(), "fork")
+	assert.NoError(t, exec.Command("git", "init", "--bare", forkDir).Run())
+
+	// the charts repository ignores the logs and has a release.yaml
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, ".gitignore"), []byte("logs/\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, "release.yaml"), []byte("fleet:\n- 103.0.0+up0.9.0\n"), 0644))
+	gittest.Run(t, localDir, "add", "-A")
+	gittest.Run(t, localDir, "commit", "-m", "charts repository")
+	t.Chdir(localDir)
+
+	g := &git.Git{
+		Dir:         localDir,
+		Branch:      "main",
+		Remotes:     map[string]string{upstreamDir: "upstream", forkDir: "fork"},
+		UpstreamURL: upstreamDir,
+	}
+	vr := &lifecycle.VersionRules{DevBranch: "dev-v2.9", BranchVersion: "2.9"}
+	assets := map[string][]lifecycle.Asset{
+		"fleet":    {{Version: "104.0.0+up0.10.0"}},
+		"longhorn": {{Version: "104.0.0+up1.6.0"}},
+	}
+	newForwardPort := func(journal *Journal) *ForwardPort {
+		return &ForwardPort{
+			git:                     g,
+			rootFs:                  filesystem.GetFilesystem(localDir),
+			VR:                      vr,
+			assetsToBeForwardPorted: assets,
+			pullRequests:            make(map[string]PullRequest),
+			forkRemoteURL:           forkDir,
+			journal:                 journal,
+		}
+	}
+
+	// #1 the push fails because the fork remote is not configured yet
+	f := newForwardPort(newJournal(journalPath("2.9"), "main", "dev-v2.9"))
+	assert.Error(t, f.ExecuteForwardPort(ctx, ""))
+
+	journal, err := loadJournal(journalPath("2.9"))
+	assert.NoError(t, err)
+	assert.Len(t, journal.Branches, 1)
+	var failedBranch string
+	for branch, entry := range journal.Branches {
+		failedBranch = branch
+		assert.Equal(t, journalCommitted, entry.State)
+		assert.Len(t, entry.Commits, 2)
+	}
+
+	// #2 the partially forward-ported branch is cleaned up
+	gittest.Run(t, localDir, "remote", "add", "fork", forkDir)
+	g.Branch = failedBranch
+	assert.NoError(t, PrepareForwardPortResume(ctx, g, "2.9"))
+	assert.Equal(t, "main", g.Branch)
+	assert.False(t, g.BranchExists(failedBranch))
+
+	journal, err = loadJournal(journalPath("2.9"))
+	assert.NoError(t, err)
+	assert.Empty(t, journal.Branches[failedBranch].State)
+	assert.Empty(t, journal.Branches[failedBranch].Commits)
+
+	// #3 the resumed run forward-ports every branch
+	f = newForwardPort(journal)
+	assert.NoError(t, f.ExecuteForwardPort(ctx, ""))
+
+	journal, err = loadJournal(journalPath("2.9"))
+	assert.NoError(t, err)
+	assert.Len(t, journal.Branches, 2)
+	for branch, entry := range journal.Branches {
+		assert.Equal(t, journalDone, entry.State)
+		assert.False(t, g.BranchExists(branch))
+		assert.NoError(t, exec.Command("git", "-C", forkDir, "rev-parse", "--verify", "refs/heads/"+branch).Run())
+	}
+	// the development branch is fetched locally to pull the assets
+	assertUntouched(t, localDir, "dev-v2.9\nmain\n")
+
+	// #4 a new resume skips the branches already done
+	f = newForwardPort(journal)
+	assert.NoError(t, exec.Command("git", "-C", forkDir, "branch", "-D", "auto-forward-port-fleet-2.9").Run())
+	assert.NoError(t, f.ExecuteForwardPort(ctx, ""))
+	assert.Error(t, exec.Command("git", "-C", forkDir, "rev-parse", "--verify", "refs/heads/auto-forward-port-fleet-2.9").Run())
+
+	// #5 the original branch is checked out again when the pull request cannot be opened after the push
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	gh, err := NewGithub(ctx, "token", &options.GithubOptions{APIURL: server.URL + "/api/v3/"})
+	assert.NoError(t, err)
+	f = newForwardPort(newJournal(filepath.Join("logs", "open-pull-request-journal.json"), "main", "dev-v2.9"))
+	f.gh = gh
+	assert.Error(t, f.ExecuteForwardPort(ctx, "fleet"))
+	assert.Equal(t, journalPushed, f.journal.Branches["auto-forward-port-fleet-2.9"].State)
+	assert.NoError(t, exec.Command("git", "-C", forkDir, "rev-parse", "--verify", "refs/heads/auto-forward-port-fleet-2.9").Run())
+	assertUntouched(t, localDir, "auto-forward-port-fleet-2.9\ndev-v2.9\nmain\n")
+	head, err := exec.Command("git", "-C", localDir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "main\n", string(head))
+}
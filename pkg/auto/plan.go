@@ -0,0 +1,300 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+)
+
+/**
+* plan.go computes what auto-forward-port, release and lifecycle-prune would do
+* without touching the working tree or the remotes (--dry-run).
+**/
+
+// Plan describes the branches, commits and files a run would create or change
+type Plan struct {
+	Command   string       `json:"command"`
+	Branch    string       `json:"branch"`               // the branch the run starts from
+	DevBranch string       `json:"dev_branch,omitempty"` // the branch the assets are pulled from, if any
+	Branches  []BranchPlan `json:"branches"`
+}
+
+// BranchPlan describes the changes made on a single branch
+type BranchPlan struct {
+	Name        string       `json:"name"`
+	Create      bool         `json:"create"`                 // the branch is created from Plan.Branch and deleted locally after the push
+	PushRemote  string       `json:"push_remote,omitempty"`  // the remote the branch is pushed to
+	PullRequest string       `json:"pull_request,omitempty"` // the title of the pull request opened into Plan.Branch
+	Commits     []CommitPlan `json:"commits"`
+}
+
+// CommitPlan describes a single set of changes; Message is empty when the run does not commit them
+type CommitPlan struct {
+	Message      string                 `json:"message,omitempty"`
+	Files        []string               `json:"files"`
+	ReleaseYaml  options.ReleaseOptions `json:"release_yaml"`            // release.yaml content after the change
+	IndexEntries map[string][]string    `json:"index_entries,omitempty"` // chart versions added to index.yaml
+	IndexRemoved map[string][]string    `json:"index_removed,omitempty"` // chart versions removed from index.yaml
+}
+
+// Plan computes the forward-port of the assets without creating branches, committing or pushing.
+// The pull requests are organized exactly as ExecuteForwardPort would do.
+func (f *ForwardPort) Plan(ctx context.Context, chart string) (*Plan, error) {
+	logger.Log(ctx, slog.LevelInfo, "planning forward port")
+
+	if err := f.git.FetchRemoteBranch(f.VR.DevBranch); err != nil {
+		return nil, err
+	}
+
+	commands := f.createForwardPortCommands(ctx, chart)
+	f.organizePullRequestsByChart(commands)
+
+	plan := &Plan{
+		Command:   "auto-forward-port",
+		Branch:    f.git.Branch,
+		DevBranch: f.VR.DevBranch,
+		Branches:  []BranchPlan{},
+	}
+
+	assets := make([]string, 0, len(f.pullRequests))
+	for asset := range f.pullRequests {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		pr := f.pullRequests[asset]
+		branch := BranchPlan{
+			Name:        pr.branch,
+			Create:      true,
+			PushRemote:  f.git.Remotes[f.forkRemoteURL],
+			PullRequest: fmt.Sprintf("[%s] forward-port %s", f.git.Branch, asset),
+			Commits: []CommitPlan{{
+				Message:     "cleaning release.yaml",
+				Files:       []string{path.RepositoryReleaseYaml},
+				ReleaseYaml: options.ReleaseOptions{},
+			}},
+		}
+
+		releaseOpts := options.ReleaseOptions{}
+		for _, command := range pr.commands {
+			files, err := planAssetFiles(ctx, f.git, f.rootFs, f.VR.DevBranch, command.Chart, command.Version)
+			if err != nil {
+				return nil, err
+			}
+			releaseOpts = releaseOpts.Append(command.Chart, command.Version)
+
+			branch.Commits = append(branch.Commits, CommitPlan{
+				Message:      "forward-port " + command.Chart + " " + command.Version,
+				Files:        files,
+				ReleaseYaml:  copyReleaseOptions(releaseOpts),
+				IndexEntries: map[string][]string{command.Chart: {command.Version}},
+			})
+		}
+
+		plan.Branches = append(plan.Branches, branch)
+	}
+
+	return plan, nil
+}
+
+// Plan computes the release of the chart version on the current branch without changing any file
+func (r *Release) Plan(ctx context.Context, rootFs billy.Filesystem) (*Plan, error) {
+	logger.Log(ctx, slog.LevelInfo, "planning release", slog.String("chart", r.Chart), slog.String("version", r.ChartVersion))
+
+	if err := r.git.FetchRemoteBranch(r.VR.DevBranch); err != nil {
+		return nil, err
+	}
+
+	files, err := planAssetFiles(ctx, r.git, rootFs, r.VR.DevBranch, r.Chart, r.ChartVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseVersions, err := readReleaseYaml(ctx, r.ReleaseYamlPath)
+	if err != nil {
+		return nil, err
+	}
+	// the release command overwrites the chart versions in release.yaml
+	releaseVersions[r.Chart] = []string{r.ChartVersion}
+
+	return &Plan{
+		Command:   "release",
+		Branch:    r.git.Branch,
+		DevBranch: r.VR.DevBranch,
+		Branches: []BranchPlan{{
+			Name: r.git.Branch,
+			Commits: []CommitPlan{{
+				Files:        files,
+				ReleaseYaml:  options.ReleaseOptions(releaseVersions),
+				IndexEntries: map[string][]string{r.Chart: {r.ChartVersion}},
+			}},
+		}},
+	}, nil
+}
+
+// PlanGroupRelease computes the release of a chart and the charts of its group as a single set of changes
+func PlanGroupRelease(ctx context.Context, rootFs billy.Filesystem, releases []*Release) (*Plan, error) {
+	plan, err := releases[0].Plan(ctx, rootFs)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := &plan.Branches[0].Commits[0]
+	var files []string
+	for _, r := range releases {
+		memberPlan := plan
+		if r != releases[0] {
+			if memberPlan, err = r.Plan(ctx, rootFs); err != nil {
+				return nil, err
+			}
+			commit.ReleaseYaml[r.Chart] = []string{r.ChartVersion}
+			commit.IndexEntries[r.Chart] = []string{r.ChartVersion}
+		}
+		for _, file := range memberPlan.Branches[0].Commits[0].Files {
+			if file != path.RepositoryReleaseYaml && file != path.RepositoryHelmIndexFile && !slices.Contains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+	commit.Files = append(files, path.RepositoryReleaseYaml, path.RepositoryHelmIndexFile)
+
+	return plan, nil
+}
+
+// planAssetFiles returns the files touched by pulling the asset version from the development branch:
+// the asset, the unpacked chart, the icon if it is not present yet, release.yaml and index.yaml.
+// The asset is read with git show, so nothing is checked out.
+func planAssetFiles(ctx context.Context, g *git.Git, rootFs billy.Filesystem, devBranch, chart, version string) ([]string, error) {
+	assetPath, _ := mountAssetVersionPath(chart, version)
+
+	if err := g.CheckFileExists(assetPath, devBranch); err != nil {
+		return nil, fmt.Errorf("asset version not found in dev branch: %s", assetPath)
+	}
+
+	data, err := g.ShowFile(devBranch, assetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from dev branch: %w", assetPath, err)
+	}
+	helmChart, err := helmLoader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not load Helm chart %s: %w", assetPath, err)
+	}
+
+	files := []string{assetPath, path.RepositoryChartsDir + "/" + chart + "/" + version + "/"}
+
+	if relativeIconPath, ok := strings.CutPrefix(helmChart.Metadata.Icon, "file://"); ok {
+		exists, err := filesystem.PathExists(ctx, rootFs, relativeIconPath)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			if err := g.CheckFileExists(relativeIconPath, devBranch); err != nil {
+				return nil, fmt.Errorf("icon file not found in dev branch but should: %s", relativeIconPath)
+			}
+			files = append(files, relativeIconPath)
+		}
+	}
+
+	return append(files, path.RepositoryReleaseYaml, path.RepositoryHelmIndexFile), nil
+}
+
+// copyReleaseOptions returns a deep copy so each commit keeps the release.yaml content at that point
+func copyReleaseOptions(r options.ReleaseOptions) options.ReleaseOptions {
+	c := make(options.ReleaseOptions, len(r))
+	for chart, versions := range r {
+		c[chart] = append([]string{}, versions...)
+	}
+	return c
+}
+
+// Write writes the plan to w as indented JSON or as human readable text
+func (p *Plan) Write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(p)
+	}
+
+	var b strings.Builder
+	if p.DevBranch != "" {
+		fmt.Fprintf(&b, "%s plan for %s (assets from %s)\n", p.Command, p.Branch, p.DevBranch)
+	} else {
+		fmt.Fprintf(&b, "%s plan for %s\n", p.Command, p.Branch)
+	}
+	if len(p.Branches) == 0 {
+		b.WriteString("\nnothing to do\n")
+	}
+
+	for _, branch := range p.Branches {
+		if branch.Create {
+			fmt.Fprintf(&b, "\nbranch %s (new, from %s)\n", branch.Name, p.Branch)
+		} else {
+			fmt.Fprintf(&b, "\nbranch %s\n", branch.Name)
+		}
+
+		for _, commit := range branch.Commits {
+			if commit.Message != "" {
+				fmt.Fprintf(&b, "  commit %q\n", commit.Message)
+			} else {
+				b.WriteString("  changes (not committed)\n")
+			}
+			for _, file := range commit.Files {
+				fmt.Fprintf(&b, "    %s\n", file)
+			}
+			fmt.Fprintf(&b, "    release.yaml: %s\n", formatVersionsMap(commit.ReleaseYaml))
+			if len(commit.IndexEntries) > 0 {
+				fmt.Fprintf(&b, "    index.yaml: + %s\n", formatVersionsMap(commit.IndexEntries))
+			}
+			if len(commit.IndexRemoved) > 0 {
+				fmt.Fprintf(&b, "    index.yaml: - %s\n", formatVersionsMap(commit.IndexRemoved))
+			}
+		}
+
+		if branch.PushRemote != "" {
+			fmt.Fprintf(&b, "  push to %s\n", branch.PushRemote)
+		}
+		if branch.PullRequest != "" {
+			fmt.Fprintf(&b, "  pull request %q into %s\n", branch.PullRequest, p.Branch)
+		}
+		if branch.Create {
+			fmt.Fprintf(&b, "  delete local branch %s\n", branch.Name)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatVersionsMap formats chart versions sorted by chart, e.g. "chart-1 [1.0.0 1.1.0], chart-2 [2.0.0]"
+func formatVersionsMap(m map[string][]string) string {
+	if len(m) == 0 {
+		return "{}"
+	}
+
+	charts := make([]string, 0, len(m))
+	for chart := range m {
+		charts = append(charts, chart)
+	}
+	sort.Strings(charts)
+
+	formatted := make([]string, 0, len(charts))
+	for _, chart := range charts {
+		formatted = append(formatted, chart+" ["+strings.Join(m[chart], " ")+"]")
+	}
+	return strings.Join(formatted, ", ")
+}
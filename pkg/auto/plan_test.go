@@ -0,0 +1,226 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertUntouched checks that the local repository has no changes and only the given local branches
+func assertUntouched(t *testing.T, dir string, branches string) {
+	t.Helper()
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	assert.NoError(t, err)
+	assert.Empty(t, string(status))
+
+	out, err := exec.Command("git", "-C", dir, "branch", "--format=%(refname:short)").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, branches, string(out))
+}
+
+func Test_ForwardPortPlan(t *testing.T) {
+	ctx := context.Background()
+	upstreamDir, localDir := newForwardPortTestRepos(t, "dev-v2.9", map[string][]string{
+		"chart-1":     {"104.0.0+up1.0.0", "104.1.0+up1.1.0"},
+		"chart-1-crd": {"104.0.0+up1.0.0"},
+		"longhorn":    {"104.0.0+up1.6.0"},
+	})
+
+	newForwardPort := func(assets map[string][]lifecycle.Asset) *ForwardPort {
+		return &ForwardPort{
+			git: &git.Git{
+				Dir:         localDir,
+				Branch:      "main",
+				Remotes:     map[string]string{upstreamDir: "upstream", "https://github.com/user/charts": "fork"},
+				UpstreamURL: upstreamDir,
+			},
+			rootFs:                  filesystem.GetFilesystem(localDir),
+			VR:                      &lifecycle.VersionRules{DevBranch: "dev-v2.9", BranchVersion: "2.9"},
+			assetsToBeForwardPorted: assets,
+			pullRequests:            make(map[string]PullRequest),
+			forkRemoteURL:           "https://github.com/user/charts",
+		}
+	}
+
+	t.Run("#1 plan pull requests by chart", func(t *testing.T) {
+		f := newForwardPort(map[string][]lifecycle.Asset{
+			"chart-1":     {{Version: "104.1.0+up1.1.0"}, {Version: "104.0.0+up1.0.0"}},
+			"chart-1-crd": {{Version: "104.0.0+up1.0.0"}},
+			"longhorn":    {{Version: "104.0.0+up1.6.0"}},
+		})
+
+		plan, err := f.Plan(ctx, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "auto-forward-port", plan.Command)
+		assert.Equal(t, "main", plan.Branch)
+		assert.Equal(t, "dev-v2.9", plan.DevBranch)
+		assert.Len(t, plan.Branches, 2)
+
+		chart1 := plan.Branches[0]
+		assert.Equal(t, "auto-forward-port-chart-1-2.9", chart1.Name)
+		assert.True(t, chart1.Create)
+		assert.Equal(t, "fork", chart1.PushRemote)
+		assert.Equal(t, "[main] forward-port chart-1", chart1.PullRequest)
+		assert.Len(t, chart1.Commits, 4)
+		assert.Equal(t, "cleaning release.yaml", chart1.Commits[0].Message)
+		assert.Equal(t, options.ReleaseOptions{}, chart1.Commits[0].ReleaseYaml)
+		assert.Equal(t, "forward-port chart-1 104.0.0+up1.0.0", chart1.Commits[1].Message)
+		assert.Equal(t, []string{
+			"assets/chart-1/chart-1-104.0.0+up1.0.0.tgz",
+			"charts/chart-1/104.0.0+up1.0.0/",
+			"release.yaml",
+			"index.yaml",
+		}, chart1.Commits[1].Files)
+		assert.Equal(t, map[string][]string{"chart-1": {"104.0.0+up1.0.0"}}, chart1.Commits[1].IndexEntries)
+		assert.Equal(t, options.ReleaseOptions{
+			"chart-1":     {"104.0.0+up1.0.0", "104.1.0+up1.1.0"},
+			"chart-1-crd": {"104.0.0+up1.0.0"},
+		}, chart1.Commits[3].ReleaseYaml)
+
+		assert.Equal(t, "auto-forward-port-longhorn-2.9", plan.Branches[1].Name)
+		assert.Len(t, plan.Branches[1].Commits, 2)
+
+		assertUntouched(t, localDir, "main\n")
+	})
+
+	t.Run("#2 plan a single chart", func(t *testing.T) {
+		f := newForwardPort(map[string][]lifecycle.Asset{
+			"chart-1":  {{Version: "104.1.0+up1.1.0"}},
+			"longhorn": {{Version: "104.0.0+up1.6.0"}},
+		})
+
+		plan, err := f.Plan(ctx, "longhorn")
+		assert.NoError(t, err)
+		assert.Len(t, plan.Branches, 1)
+		assert.Equal(t, "auto-forward-port-longhorn-2.9", plan.Branches[0].Name)
+	})
+
+	t.Run("#3 asset version not found in the development branch", func(t *testing.T) {
+		f := newForwardPort(map[string][]lifecycle.Asset{
+			"chart-1": {{Version: "105.0.0+up1.0.0"}},
+		})
+
+		_, err := f.Plan(ctx, "")
+		assert.Error(t, err)
+	})
+}
+
+func Test_ReleasePlan(t *testing.T) {
+	ctx := context.Background()
+	upstreamDir, localDir := newForwardPortTestRepos(t, "dev-v2.9", map[string][]string{
+		"chart-1": {"104.1.0+up1.1.0"},
+	})
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, "release.yaml"), []byte("chart-1:\n- 104.0.0+up1.0.0\nchart-2:\n- 104.0.0+up2.0.0\n"), 0644))
+	gittest.Run(t, localDir, "add", "-A")
+	gittest.Run(t, localDir, "commit", "-m", "release.yaml")
+
+	r := &Release{
+		git: &git.Git{
+			Dir:         localDir,
+			Branch:      "main",
+			Remotes:     map[string]string{upstreamDir: "upstream"},
+			UpstreamURL: upstreamDir,
+		},
+		VR:              &lifecycle.VersionRules{DevBranch: "dev-v2.9"},
+		Chart:           "chart-1",
+		ChartVersion:    "104.1.0+up1.1.0",
+		ReleaseYamlPath: filepath.Join(localDir, "release.yaml"),
+	}
+
+	plan, err := r.Plan(ctx, filesystem.GetFilesystem(localDir))
+	assert.NoError(t, err)
+	assert.Equal(t, "release", plan.Command)
+	assert.Len(t, plan.Branches, 1)
+	assert.False(t, plan.Branches[0].Create)
+	assert.Equal(t, []CommitPlan{{
+		Files: []string{
+			"assets/chart-1/chart-1-104.1.0+up1.1.0.tgz",
+			"charts/chart-1/104.1.0+up1.1.0/",
+			"release.yaml",
+			"index.yaml",
+		},
+		ReleaseYaml: options.ReleaseOptions{
+			"chart-1": {"104.1.0+up1.1.0"},
+			"chart-2": {"104.0.0+up2.0.0"},
+		},
+		IndexEntries: map[string][]string{"chart-1": {"104.1.0+up1.1.0"}},
+	}}, plan.Branches[0].Commits)
+
+	assertUntouched(t, localDir, "main\n")
+}
+
+func Test_PlanWrite(t *testing.T) {
+	plan := &Plan{
+		Command:   "auto-forward-port",
+		Branch:    "release-v2.9",
+		DevBranch: "dev-v2.9",
+		Branches: []BranchPlan{{
+			Name:        "auto-forward-port-chart-1-2.9",
+			Create:      true,
+			PushRemote:  "fork",
+			PullRequest: "[release-v2.9] forward-port chart-1",
+			Commits: []CommitPlan{
+				{
+					Message:     "cleaning release.yaml",
+					Files:       []string{"release.yaml"},
+					ReleaseYaml: options.ReleaseOptions{},
+				},
+				{
+					Message:      "forward-port chart-1 104.0.0+up1.0.0",
+					Files:        []string{"assets/chart-1/chart-1-104.0.0+up1.0.0.tgz", "charts/chart-1/104.0.0+up1.0.0/", "release.yaml", "index.yaml"},
+					ReleaseYaml:  options.ReleaseOptions{"chart-1": {"104.0.0+up1.0.0"}},
+					IndexEntries: map[string][]string{"chart-1": {"104.0.0+up1.0.0"}},
+				},
+			},
+		}},
+	}
+
+	t.Run("#1 text", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, plan.Write(&out, false))
+
+		expected := "auto-forward-port plan for release-v2.9 (assets from dev-v2.9)\n" +
+			"\n" +
+			"branch auto-forward-port-chart-1-2.9 (new, from release-v2.9)\n" +
+			"  commit \"cleaning release.yaml\"\n" +
+			"    release.yaml\n" +
+			"    release.yaml: {}\n" +
+			"  commit \"forward-port chart-1 104.0.0+up1.0.0\"\n" +
+			"    assets/chart-1/chart-1-104.0.0+up1.0.0.tgz\n" +
+			"    charts/chart-1/104.0.0+up1.0.0/\n" +
+			"    release.yaml\n" +
+			"    index.yaml\n" +
+			"    release.yaml: chart-1 [104.0.0+up1.0.0]\n" +
+			"    index.yaml: + chart-1 [104.0.0+up1.0.0]\n" +
+			"  push to fork\n" +
+			"  pull request \"[release-v2.9] forward-port chart-1\" into release-v2.9\n" +
+			"  delete local branch auto-forward-port-chart-1-2.9\n"
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("#2 json", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, plan.Write(&out, true))
+
+		got := &Plan{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), got))
+		assert.Equal(t, plan, got)
+	})
+
+	t.Run("#3 empty plan", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, (&Plan{Command: "auto-forward-port", Branch: "release-v2.9", DevBranch: "dev-v2.9"}).Write(&out, false))
+		assert.Equal(t, "auto-forward-port plan for release-v2.9 (assets from dev-v2.9)\n\nnothing to do\n", out.String())
+	})
+}
@@ -0,0 +1,150 @@
+package auto
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+)
+
+/**
+* prune.go removes the assets, unpacked charts and index.yaml entries
+* outside the lifecycle window of the current branch.
+**/
+
+// Prune holds the chart versions outside the lifecycle window to be removed from the current branch
+type Prune struct {
+	git           *git.Git
+	rootFs        billy.Filesystem
+	VR            *lifecycle.VersionRules
+	assetsToPrune map[string][]string
+}
+
+// InitPrune lists the chart versions outside the lifecycle window of the current branch, optionally filtered by a specific chart.
+// Chart versions in the keep allowlist of the branch version rules are never pruned.
+func InitPrune(ctx context.Context, ld *lifecycle.Dependencies, chart string) *Prune {
+	p := &Prune{
+		git:           ld.Git,
+		rootFs:        ld.RootFs,
+		VR:            ld.VR,
+		assetsToPrune: make(map[string][]string),
+	}
+
+	for asset, versions := range ld.AssetsVersionsMap {
+		if chart != "" && asset != chart {
+			continue
+		}
+		for _, version := range versions {
+			if ld.VR.CheckChartVersionForLifecycle(asset, version.Version) {
+				continue
+			}
+			if ld.VR.KeepOutOfLifecycle(asset, version.Version) {
+				logger.Log(ctx, slog.LevelInfo, "keeping allowlisted chart version", slog.String("chart", asset), slog.String("version", version.Version))
+				continue
+			}
+			p.assetsToPrune[asset] = append(p.assetsToPrune[asset], version.Version)
+		}
+	}
+
+	return p
+}
+
+// charts returns the charts with versions to prune sorted by name
+func (p *Prune) charts() []string {
+	charts := make([]string, 0, len(p.assetsToPrune))
+	for chart := range p.assetsToPrune {
+		charts = append(charts, chart)
+	}
+	sort.Strings(charts)
+	return charts
+}
+
+// Execute removes each chart version outside the lifecycle window,
+// tracks the removed versions in release.yaml and commits once per chart.
+func (p *Prune) Execute(ctx context.Context) error {
+	logger.Log(ctx, slog.LevelInfo, "pruning chart versions outside the lifecycle", slog.Any("charts", p.assetsToPrune))
+
+	if err := p.git.IsClean(ctx); err != nil {
+		return err
+	}
+
+	for _, chart := range p.charts() {
+		releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, p.rootFs, path.RepositoryReleaseYaml)
+		if err != nil {
+			return err
+		}
+		if releaseOpts == nil {
+			releaseOpts = options.ReleaseOptions{}
+		}
+
+		for _, version := range p.assetsToPrune[chart] {
+			logger.Log(ctx, slog.LevelInfo, "pruning", slog.String("chart", chart), slog.String("version", version))
+			if err := removeCharts(ctx, p.rootFs, []string{chart}, version); err != nil {
+				return fmt.Errorf("failed to prune %s %s: %w", chart, version, err)
+			}
+			// removed chart versions must be tracked in release.yaml to pass the validation
+			releaseOpts = releaseOpts.Append(chart, version)
+		}
+
+		releaseOpts.SortBySemver(ctx)
+		if err := releaseOpts.WriteToFile(ctx, p.rootFs, path.RepositoryReleaseYaml); err != nil {
+			return err
+		}
+
+		if err := p.git.AddAndCommit("lifecycle-prune " + chart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Plan computes the pruning without removing any file
+func (p *Prune) Plan(ctx context.Context) (*Plan, error) {
+	logger.Log(ctx, slog.LevelInfo, "planning lifecycle prune")
+
+	releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, p.rootFs, path.RepositoryReleaseYaml)
+	if err != nil {
+		return nil, err
+	}
+	if releaseOpts == nil {
+		releaseOpts = options.ReleaseOptions{}
+	}
+
+	plan := &Plan{
+		Command:  "lifecycle-prune",
+		Branch:   p.git.Branch,
+		Branches: []BranchPlan{},
+	}
+
+	branch := BranchPlan{Name: p.git.Branch}
+	for _, chart := range p.charts() {
+		files := []string{}
+		for _, version := range p.assetsToPrune[chart] {
+			assetPath, _ := mountAssetVersionPath(chart, version)
+			files = append(files, assetPath, path.RepositoryChartsDir+"/"+chart+"/"+version+"/")
+			releaseOpts = releaseOpts.Append(chart, version)
+		}
+		releaseOpts.SortBySemver(ctx)
+
+		branch.Commits = append(branch.Commits, CommitPlan{
+			Message:      "lifecycle-prune " + chart,
+			Files:        append(files, path.RepositoryReleaseYaml, path.RepositoryHelmIndexFile),
+			ReleaseYaml:  copyReleaseOptions(releaseOpts),
+			IndexRemoved: map[string][]string{chart: append([]string{}, p.assetsToPrune[chart]...)},
+		})
+	}
+
+	if len(branch.Commits) > 0 {
+		plan.Branches = append(plan.Branches, branch)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,153 @@
+package auto
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+func Test_Prune(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+	assets := map[string][]string{
+		"chart-1":  {"102.0.0+up0.9.0", "104.0.0+up1.0.0"},
+		"chart-2":  {"101.0.0+up1.0.0", "102.0.0+up1.1.0"},
+		"longhorn": {"105.0.0+up1.6.0"},
+	}
+	upstreamDir, localDir := newForwardPortTestRepos(t, "dev-v2.10", assets)
+
+	g := &git.Git{
+		Dir:         localDir,
+		Branch:      "main",
+		Remotes:     map[string]string{upstreamDir: "upstream"},
+		UpstreamURL: upstreamDir,
+	}
+	rootFs := filesystem.GetFilesystem(localDir)
+
+	// release the assets in the local branch with an empty release.yaml
+	f := &ForwardPort{git: g, rootFs: rootFs, VR: &lifecycle.VersionRules{DevBranch: "dev-v2.10"}}
+	assetsVersionsMap := make(map[string][]lifecycle.Asset)
+	for chart, versions := range assets {
+		for _, version := range versions {
+			assert.NoError(t, f.forwardPortAsset(ctx, chart, version))
+			assetsVersionsMap[chart] = append(assetsVersionsMap[chart], lifecycle.Asset{Version: version})
+		}
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, "release.yaml"), []byte("longhorn:\n- 105.0.0+up1.6.0\n"), 0644))
+	assert.NoError(t, g.AddAndCommit("charts repository"))
+	assert.NoError(t, exec.Command("git", "-C", localDir, "branch", "-D", "dev-v2.10").Run())
+
+	ld := &lifecycle.Dependencies{
+		RootFs:            rootFs,
+		AssetsVersionsMap: assetsVersionsMap,
+		Git:               g,
+		VR: &lifecycle.VersionRules{
+			Rules: map[string]lifecycle.Version{
+				"2.10": {Min: "105.0.0", Max: "106.0.0", Keep: map[string][]string{"chart-2": {"101.0.0+up1.0.0"}}},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+				"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+				"2.7":  {Min: "102.0.0", Max: "103.0.0"},
+				"2.6":  {Max: "102.0.0"},
+			},
+			BranchVersion: "2.10",
+		},
+	}
+
+	t.Run("#1 plan", func(t *testing.T) {
+		plan, err := InitPrune(ctx, ld, "").Plan(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "lifecycle-prune", plan.Command)
+		assert.Len(t, plan.Branches, 1)
+		assert.False(t, plan.Branches[0].Create)
+		assert.Equal(t, []CommitPlan{
+			{
+				Message: "lifecycle-prune chart-1",
+				Files: []string{
+					"assets/chart-1/chart-1-102.0.0+up0.9.0.tgz",
+					"charts/chart-1/102.0.0+up0.9.0/",
+					"release.yaml",
+					"index.yaml",
+				},
+				ReleaseYaml: options.ReleaseOptions{
+					"chart-1":  {"102.0.0+up0.9.0"},
+					"longhorn": {"105.0.0+up1.6.0"},
+				},
+				IndexRemoved: map[string][]string{"chart-1": {"102.0.0+up0.9.0"}},
+			},
+			{
+				Message: "lifecycle-prune chart-2",
+				Files: []string{
+					"assets/chart-2/chart-2-102.0.0+up1.1.0.tgz",
+					"charts/chart-2/102.0.0+up1.1.0/",
+					"release.yaml",
+					"index.yaml",
+				},
+				ReleaseYaml: options.ReleaseOptions{
+					"chart-1":  {"102.0.0+up0.9.0"},
+					"chart-2":  {"102.0.0+up1.1.0"},
+					"longhorn": {"105.0.0+up1.6.0"},
+				},
+				IndexRemoved: map[string][]string{"chart-2": {"102.0.0+up1.1.0"}},
+			},
+		}, plan.Branches[0].Commits)
+
+		var out bytes.Buffer
+		assert.NoError(t, plan.Write(&out, false))
+		assert.Contains(t, out.String(), "lifecycle-prune plan for main\n")
+		assert.Contains(t, out.String(), "    index.yaml: - chart-1 [102.0.0+up0.9.0]\n")
+
+		assertUntouched(t, localDir, "main\n")
+	})
+
+	t.Run("#2 plan a chart without versions to prune", func(t *testing.T) {
+		plan, err := InitPrune(ctx, ld, "longhorn").Plan(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, plan.Branches)
+	})
+
+	t.Run("#3 prune commits per chart", func(t *testing.T) {
+		assert.NoError(t, InitPrune(ctx, ld, "").Execute(ctx))
+		assertUntouched(t, localDir, "main\n")
+
+		out, err := exec.Command("git", "-C", localDir, "log", "-3", "--format=%s").Output()
+		assert.NoError(t, err)
+		assert.Equal(t, "lifecycle-prune chart-2\nlifecycle-prune chart-1\ncharts repository\n", string(out))
+
+		assert.NoFileExists(t, filepath.Join(localDir, "assets", "chart-1", "chart-1-102.0.0+up0.9.0.tgz"))
+		assert.NoDirExists(t, filepath.Join(localDir, "charts", "chart-1", "102.0.0+up0.9.0"))
+		assert.NoFileExists(t, filepath.Join(localDir, "assets", "chart-2", "chart-2-102.0.0+up1.1.0.tgz"))
+		assert.FileExists(t, filepath.Join(localDir, "assets", "chart-1", "chart-1-104.0.0+up1.0.0.tgz"))
+		assert.FileExists(t, filepath.Join(localDir, "assets", "chart-2", "chart-2-101.0.0+up1.0.0.tgz"))
+
+		index, err := helmRepo.LoadIndexFile(filepath.Join(localDir, "index.yaml"))
+		assert.NoError(t, err)
+		assert.False(t, index.Has("chart-1", "102.0.0+up0.9.0"))
+		assert.False(t, index.Has("chart-2", "102.0.0+up1.1.0"))
+		assert.True(t, index.Has("chart-1", "104.0.0+up1.0.0"))
+		assert.True(t, index.Has("chart-2", "101.0.0+up1.0.0"))
+		assert.True(t, index.Has("longhorn", "105.0.0+up1.6.0"))
+
+		releaseOpts, err := options.LoadReleaseOptionsFromFile(ctx, rootFs, "release.yaml")
+		assert.NoError(t, err)
+		assert.Equal(t, options.ReleaseOptions{
+			"chart-1":  {"102.0.0+up0.9.0"},
+			"chart-2":  {"102.0.0+up1.1.0"},
+			"longhorn": {"105.0.0+up1.6.0"},
+		}, releaseOpts)
+	})
+
+	t.Run("#4 unpacked chart not found", func(t *testing.T) {
+		assert.Error(t, InitPrune(ctx, ld, "chart-1").Execute(ctx))
+	})
+}
@@ -18,6 +18,9 @@ import (
 	helmChartutil "helm.sh/helm/v3/pkg/chartutil"
 )
 
+// errNoAssetToRelease is returned by InitRelease when the chart has no version to release
+var errNoAssetToRelease = errors.New("no asset version to release")
+
 // Release holds necessary metadata to release a chart version
 type Release struct {
 	git             *git.Git
@@ -47,7 +50,7 @@ func InitRelease(ctx context.Context, d *lifecycle.Dependencies, s *lifecycle.St
 	if !ok {
 		assetVersions, ok = s.AssetsToBeForwardPorted[r.Chart]
 		if !ok {
-			return nil, errors.New("no asset version to release for chart:" + r.Chart)
+			return nil, fmt.Errorf("%w for chart:%s", errNoAssetToRelease, r.Chart)
 		}
 	}
 
@@ -59,7 +62,7 @@ func InitRelease(ctx context.Context, d *lifecycle.Dependencies, s *lifecycle.St
 		}
 	}
 	if assetVersion == "" {
-		return nil, errors.New("no asset version to release for chart:" + r.Chart + " version:" + r.ChartVersion)
+		return nil, fmt.Errorf("%w for chart:%s version:%s", errNoAssetToRelease, r.Chart, r.ChartVersion)
 	}
 
 	r.AssetPath, r.AssetTgz = mountAssetVersionPath(r.Chart, assetVersion)
@@ -79,6 +82,33 @@ func InitRelease(ctx context.Context, d *lifecycle.Dependencies, s *lifecycle.St
 	return r, nil
 }
 
+// InitGroupRelease will create the Release of the chart and of each chart of its group
+// with the same version to be released, e.g. fleet-crd and fleet-agent along with fleet.
+// Charts of the group without that version to be released are skipped; any other error fails the whole group.
+func InitGroupRelease(ctx context.Context, d *lifecycle.Dependencies, s *lifecycle.Status, v, c, f string, groups ChartGroups) ([]*Release, error) {
+	r, err := InitRelease(ctx, d, s, v, c, f)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := []*Release{r}
+	for _, member := range groups.Members(c) {
+		if member == c {
+			continue
+		}
+		memberRelease, err := InitRelease(ctx, d, s, v, member, f)
+		if errors.Is(err, errNoAssetToRelease) {
+			logger.Log(ctx, slog.LevelInfo, "skipping chart of the group", slog.String("chart", member), logger.Err(err))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to init the release of %s of the group: %w", member, err)
+		}
+		releases = append(releases, memberRelease)
+	}
+	return releases, nil
+}
+
 // PullAsset will execute the release porting for a chart in the repository
 func (r *Release) PullAsset() error {
 	if err := r.git.FetchBranch(r.VR.DevBranch); err != nil {
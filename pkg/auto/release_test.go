@@ -4,8 +4,12 @@ import (
 	"context"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 )
 
@@ -259,6 +263,44 @@ func Test_UpdateReleaseYaml(t *testing.T) {
 
 }
 
+func Test_InitGroupRelease(t *testing.T) {
+	ctx := context.Background()
+	repoRoot := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, "release.yaml"), []byte(""), 0644))
+	// the asset paths are relative to the working directory
+	t.Chdir(repoRoot)
+
+	version := "105.0.0+up0.11.0"
+	d := &lifecycle.Dependencies{RootFs: filesystem.GetFilesystem(repoRoot), VR: &lifecycle.VersionRules{}}
+	s := &lifecycle.Status{
+		AssetsToBeReleased: map[string][]lifecycle.Asset{
+			"fleet":       {{Version: version}},
+			"fleet-crd":   {{Version: version}},
+			"fleet-agent": {{Version: "105.0.0+up0.10.0"}},
+		},
+	}
+	groups := ChartGroups{"fleet": {"fleet", "fleet-crd", "fleet-agent", "fleet-extra"}}
+
+	t.Run("#1 members without the version are skipped", func(t *testing.T) {
+		releases, err := InitGroupRelease(ctx, d, s, version, "fleet", "", groups)
+		assert.NoError(t, err)
+		var charts []string
+		for _, r := range releases {
+			charts = append(charts, r.Chart)
+		}
+		assert.Equal(t, []string{"fleet", "fleet-crd"}, charts)
+	})
+
+	t.Run("#2 other errors of a member fail the group", func(t *testing.T) {
+		// assets/fleet-crd is a file, so the released asset cannot be checked
+		assert.NoError(t, os.MkdirAll(filepath.Join(repoRoot, "assets"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, "assets", "fleet-crd"), []byte(""), 0644))
+		_, err := InitGroupRelease(ctx, d, s, version, "fleet", "", groups)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, errNoAssetToRelease)
+	})
+}
+
 func Test_mountAssetVersionPath(t *testing.T) {
 	type input struct {
 		chart, version string
@@ -3,6 +3,7 @@ package auto
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 
@@ -37,18 +38,22 @@ func removeCharts(ctx context.Context, rootFs billy.Filesystem, charts []string,
 // remove will check and remove assets/<chart>; charts/<chart>
 func remove(ctx context.Context, rootFs billy.Filesystem, chart, version string) error {
 	if chart == "" || version == "" {
-		return errors.New("create error this here")
+		return errors.New("chart and version must be provided")
 	}
 
 	// check if the charts dir exists
 	chartPath := "charts/" + chart + "/" + version
-	if exist, err := filesystem.PathExists(ctx, rootFs, chartPath); !exist || err != nil {
-		return errors.New("some error here")
+	if exist, err := filesystem.PathExists(ctx, rootFs, chartPath); err != nil {
+		return err
+	} else if !exist {
+		return fmt.Errorf("chart not found: %s", chartPath)
 	}
 	// check if the assets dir exists
 	assetPath := "assets/" + chart + "/" + chart + "-" + version + ".tgz"
-	if exist, err := filesystem.PathExists(ctx, rootFs, assetPath); !exist || err != nil {
-		return errors.New("some error here")
+	if exist, err := filesystem.PathExists(ctx, rootFs, assetPath); err != nil {
+		return err
+	} else if !exist {
+		return fmt.Errorf("asset not found: %s", assetPath)
 	}
 
 	// remove charts dir
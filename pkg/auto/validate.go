@@ -22,15 +22,10 @@ import (
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/options"
 	"github.com/rancher/charts-build-scripts/pkg/path"
-	"golang.org/x/oauth2"
 
 	helmRepo "helm.sh/helm/v3/pkg/repo"
 )
 
-// Referred to: https://github.com/rancher/charts
-const owner = "rancher"
-const repo = "charts"
-
 var (
 	errReleaseYaml       error = errors.New("release.yaml errors")
 	errModifiedChart     error = errors.New("released chart cannot be modified")
@@ -46,29 +41,18 @@ type validation struct {
 
 // loadPullRequestValidation will load the pull request validation struct with the pull request and its files.
 // it will also load the dependencies struct with the filesystem, assets versions map, version rules and methods to enforce the lifecycle rules in the given branch.
-func loadPullRequestValidation(token, prNum string, dep *lifecycle.Dependencies) (*validation, error) {
-	ctx := context.Background()
-
-	tokenSource := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tokenClient := oauth2.NewClient(ctx, tokenSource)
-	gitClient := github.NewClient(tokenClient)
-
+func loadPullRequestValidation(ctx context.Context, gh *Github, prNum string, dep *lifecycle.Dependencies) (*validation, error) {
 	pNum, err := strconv.Atoi(prNum)
 	if err != nil {
 		return nil, err
 	}
 
-	pr, resp, err := gitClient.PullRequests.Get(ctx, owner, repo, pNum)
+	pr, err := gh.getPullRequest(ctx, pNum)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to get pull request, status code: %s", resp.Status)
-	}
 
-	prFiles, _, err := gitClient.PullRequests.ListFiles(ctx, owner, repo, pNum, nil)
+	prFiles, err := gh.listPullRequestFiles(ctx, pNum)
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +64,9 @@ func loadPullRequestValidation(token, prNum string, dep *lifecycle.Dependencies)
 //   - Checkpoint 0: release.yaml file is valid
 //   - TODO: Checkpoint 1: Compare contents of assets/ to charts/
 //   - TODO: Checkpoint 2: Compare assets against index.yaml
-func ValidatePullRequest(ctx context.Context, token, prNum string, dep *lifecycle.Dependencies) error {
+func ValidatePullRequest(ctx context.Context, gh *Github, prNum string, dep *lifecycle.Dependencies) error {
 
-	v, err := loadPullRequestValidation(token, prNum, dep)
+	v, err := loadPullRequestValidation(ctx, gh, prNum, dep)
 	if err != nil {
 		return err
 	}
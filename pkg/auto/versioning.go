@@ -10,6 +10,7 @@ import (
 	"github.com/blang/semver"
 	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 )
 
 // versions holds all the version components required to calculte the next chart version.
@@ -195,10 +196,13 @@ func getLatestVersionFromIndex(versions []lifecycle.Asset) (string, string, bool
 	return latestRepoPrefix, latestVersion, found, nil
 }
 
-// getLatestVersionRecursively will get the latest non-RC version from the entry at the index.yaml
+// getLatestVersionRecursively will get the latest non pre-release version from the entry at the index.yaml
 func getLatestVersionRecursively(versions []lifecycle.Asset) string {
+	if len(versions) == 0 {
+		return ""
+	}
 	latestVersion := versions[0].Version
-	if strings.Contains(latestVersion, "-rc") {
+	if prerelease.IsPrerelease(latestVersion) {
 		return getLatestVersionRecursively(versions[1:])
 	}
 
@@ -216,6 +220,9 @@ func getCurrentRCsFromIndex(versions []lifecycle.Asset, toReleasePrefix string)
 		if !strings.Contains(v.Version, toReleasePrefix) {
 			break
 		}
+		if !prerelease.IsPrerelease(v.Version) {
+			continue
+		}
 
 		currentRC := rc{
 			repoPrefix: &version{},
@@ -258,6 +265,7 @@ func parseRepoPrefixVersionIfAny(unparsedVersion string) (repoPrefix, version st
 //   - semantic difference between the latest released version and the new upstream version.
 //   - optional manual override for the version.
 //     It will calculate automatically patch or minor increments.
+//   - the bumped prefix must stay within the branch version range of the rules.
 func (b *Bump) applyVersionRules(versionOverride string) error {
 
 	// get the repository major prefix version rule (i.e., 105; 104; 103...)
@@ -315,5 +323,7 @@ func (b *Bump) applyVersionRules(versionOverride string) error {
 	}
 
 	b.versions.toReleaseRepoPrefix.updateTxt()
-	return nil
+
+	// the bumped prefix must still belong to the branch version range (i.e., 105.x.y for 2.10)
+	return b.versionRules.CheckVersionInBranchRange(b.versions.toReleaseRepoPrefix.txt)
 }
@@ -423,3 +423,51 @@ func Test_calculateNextVersion(t *testing.T) {
 		})
 	}
 }
+
+func Test_getLatestVersionRecursively(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []lifecycle.Asset
+		expected string
+	}{
+		{
+			name:     "#1 latest is stable",
+			versions: []lifecycle.Asset{{Version: "108.0.1+up0.9.1"}, {Version: "108.0.0+up0.9.0"}},
+			expected: "108.0.1+up0.9.1",
+		},
+		{
+			name:     "#2 skip rc and beta in the +up build metadata",
+			versions: []lifecycle.Asset{{Version: "108.0.2+up0.9.2-rc.1"}, {Version: "108.0.2+up0.9.2-beta.1"}, {Version: "108.0.1+up0.9.1"}},
+			expected: "108.0.1+up0.9.1",
+		},
+		{
+			name:     "#3 only pre-releases",
+			versions: []lifecycle.Asset{{Version: "108.0.0+up0.9.0-alpha.1"}},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, getLatestVersionRecursively(tt.versions))
+		})
+	}
+}
+
+func Test_getCurrentRCsFromIndex(t *testing.T) {
+	versions := []lifecycle.Asset{
+		{Version: "108.0.0+up0.9.1-rc.2"},
+		{Version: "108.0.0+up0.9.1-beta.1"},
+		{Version: "108.0.0+up0.9.0"},
+		{Version: "107.0.0+up0.8.0-rc.1"},
+	}
+
+	rcs, err := getCurrentRCsFromIndex(versions, "108.0.0")
+	assert.NoError(t, err)
+
+	var got []string
+	for _, rc := range rcs {
+		got = append(got, rc.repoPrefix.txt+"+up"+rc.appVersion.txt)
+	}
+	assert.Equal(t, []string{"108.0.0+up0.9.1-rc.2", "108.0.0+up0.9.1-beta.1"}, got)
+}
@@ -54,8 +54,9 @@ func (c *AdditionalChart) ApplyMainChanges(ctx context.Context, pkgFs billy.File
 		return fmt.Errorf("encountered error while trying to copy CRDs from %s to %s: %s", mainChartWorkingDir, c.WorkingDir, err)
 	}
 	if c.CRDChartOptions.AddCRDValidationToMainChart {
-		if err := AddCRDValidationToChart(ctx, pkgFs, mainChartWorkingDir, c.WorkingDir, c.CRDChartOptions.CRDDirectory); err != nil {
-			return fmt.Errorf("encountered error while trying to add CRD validation to %s based on CRDs in %s: %s", mainChartWorkingDir, c.WorkingDir, err)
+		// the CRDs are read from the main chart, where they are until the end of this function, so charts using UseTarArchive are validated the same way
+		if err := AddCRDValidationToChart(ctx, pkgFs, mainChartWorkingDir, mainChartWorkingDir, path.ChartCRDDir); err != nil {
+			return fmt.Errorf("encountered error while trying to add CRD validation to %s based on CRDs in %s: %s", mainChartWorkingDir, filepath.Join(mainChartWorkingDir, path.ChartCRDDir), err)
 		}
 	}
 	if c.CRDChartOptions.UseTarArchive {
@@ -135,6 +136,12 @@ func (c *AdditionalChart) Prepare(ctx context.Context, rootFs, pkgFs billy.Files
 				return fmt.Errorf("encountered error while trying to pull upstream into %s: %s", mainChartWorkingDir, err)
 			}
 		}
+		if c.CRDChartOptions.GenerateFromMainChart {
+			// CRDs embedded in templates/ are moved to crds/; on make patch, the move is recorded in the main chart's patch
+			if err := helm.ExtractCRDsFromTemplates(ctx, pkgFs, mainChartWorkingDir); err != nil {
+				return fmt.Errorf("encountered error while trying to extract CRDs from the templates of %s: %s", mainChartWorkingDir, err)
+			}
+		}
 		exists, err := filesystem.PathExists(ctx, pkgFs, filepath.Join(mainChartWorkingDir, path.ChartCRDDir))
 		if err != nil {
 			return fmt.Errorf("encountered error while trying to check if %s exists: %s", filepath.Join(mainChartWorkingDir, path.ChartCRDDir), err)
@@ -143,7 +150,11 @@ func (c *AdditionalChart) Prepare(ctx context.Context, rootFs, pkgFs billy.Files
 			return fmt.Errorf("unable to prepare a CRD chart since there are no CRDs at %s", filepath.Join(mainChartWorkingDir, path.ChartCRDDir))
 		}
 
-		if err := GenerateCRDChartFromTemplate(ctx, pkgFs, c.WorkingDir, filepath.Join(path.PackageTemplatesDir, c.CRDChartOptions.TemplateDirectory), c.CRDChartOptions.CRDDirectory); err != nil {
+		if c.CRDChartOptions.GenerateFromMainChart {
+			if err := GenerateCRDChartFromMainChart(ctx, pkgFs, c.WorkingDir, mainChartWorkingDir, c.CRDChartOptions.CRDDirectory); err != nil {
+				return fmt.Errorf("encountered error while trying to generate CRD chart from main chart at %s: %s", mainChartWorkingDir, err)
+			}
+		} else if err := GenerateCRDChartFromTemplate(ctx, pkgFs, c.WorkingDir, filepath.Join(path.PackageTemplatesDir, c.CRDChartOptions.TemplateDirectory), c.CRDChartOptions.CRDDirectory); err != nil {
 			return fmt.Errorf("encountered error while trying to generate CRD chart from template at %s: %s", c.CRDChartOptions.TemplateDirectory, err)
 		}
 	} else {
@@ -203,6 +214,10 @@ func (c *AdditionalChart) GeneratePatch(ctx context.Context, rootFs, pkgFs billy
 		return fmt.Errorf("working directory %s has not been prepared yet", c.WorkingDir)
 	}
 
+	if c.CRDChartOptions != nil && c.CRDChartOptions.GenerateFromMainChart {
+		logger.Log(ctx, slog.LevelWarn, "patches are not supported for CRD charts generated from the main chart. Any local changes will be overridden; please make the changes directly on the main chart", slog.String("WorkingDir", c.WorkingDir))
+		return nil
+	}
 	if c.CRDChartOptions != nil {
 		logger.Log(ctx, slog.LevelWarn, "patches are not supported for CRD charts using CRDChartOptions. Any local changes will be overridden; please make the changes directly at %s", slog.String("TemplateDirectory", filepath.Join(path.PackageTemplatesDir, c.CRDChartOptions.TemplateDirectory)))
 		return nil
@@ -25,6 +25,8 @@ type Chart struct {
 	IgnoreDependencies []string `yaml:"ignoreDependencies"`
 	// ReplacePaths marks paths as those that should be replaced instead of patches. Consequently, these paths will exist in both generated-changes/excludes and generated-changes/overlay
 	ReplacePaths []string `yaml:"replacePaths"`
+	// GenerateValuesSchema infers a values.schema.json from the values.yaml of the chart when generating it
+	GenerateValuesSchema bool `yaml:"generateValuesSchema,omitempty"`
 
 	// The version of this chart in Upstream. This value is set to a non-nil value on Prepare.
 	// GenerateChart will fail if this value is not set (e.g. chart must be prepared first)
@@ -132,6 +134,20 @@ func (c *Chart) GenerateChart(ctx context.Context, rootFs, pkgFs billy.Filesyste
 	if c.UpstreamChartVersion == nil {
 		return fmt.Errorf("cannot generate chart since it has never been prepared: upstreamChartVersion is not set")
 	}
+	if c.GenerateValuesSchema {
+		restore, err := helm.GenerateValuesSchema(ctx, pkgFs, c.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("encountered error while trying to generate values schema for %s: %w", c.WorkingDir, err)
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				logger.Log(ctx, slog.LevelError, "failed to restore values schema", slog.String("WorkingDir", c.WorkingDir), logger.Err(err))
+			}
+		}()
+	}
+	if err := helm.ValidateValuesSchema(ctx, pkgFs, c.WorkingDir); err != nil {
+		return fmt.Errorf("encountered error while trying to validate values schema for %s: %w", c.WorkingDir, err)
+	}
 	if err := helm.ExportHelmChart(ctx, rootFs, pkgFs, c.WorkingDir, packageVersion, version, autoGenBumpVersion, *c.UpstreamChartVersion, omitBuildMetadataOnExport); err != nil {
 		return fmt.Errorf("encountered error while trying to export Helm chart for %s: %s", c.WorkingDir, err)
 	}
@@ -2,10 +2,10 @@ package charts
 
 import (
 	"context"
-	"strings"
 
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 )
 
 // CheckRCCharts checks for any charts that have RC versions
@@ -21,10 +21,10 @@ func CheckRCCharts(ctx context.Context, repoRoot string) (map[string][]string, e
 
 	rcChartVersionMap := make(map[string][]string, 0)
 
-	// Grab all charts that contain RC tags
+	// Grab all charts that contain pre-release versions
 	for chart := range releaseOptions {
 		for _, version := range releaseOptions[chart] {
-			if strings.Contains(version, "-rc") {
+			if prerelease.IsPrerelease(version) {
 				rcChartVersionMap[chart] = append(rcChartVersionMap[chart], version)
 			}
 		}
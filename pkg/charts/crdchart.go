@@ -1,20 +1,22 @@
 package charts
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
-	"gopkg.in/yaml.v2"
+	helmChart "helm.sh/helm/v3/pkg/chart"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	helmChartutil "helm.sh/helm/v3/pkg/chartutil"
 )
 
 // ValidateInstallCRDContentsFmt is the format for the contents of ChartValidateInstallCRDFile
@@ -54,26 +56,70 @@ func GenerateCRDChartFromTemplate(ctx context.Context, fs billy.Filesystem, dstH
 	return nil
 }
 
-// AddCRDValidationToChart adds the validate-install-crd.yaml to helmChartPathWithoutCRDs based on CRDs located in crdsDir within helmChartPathWithCRDs
+// crdChartReadmeFmt is the format of the README.md of CRD charts generated from the main chart
+const crdChartReadmeFmt = `# %s
+
+A Rancher chart that installs the CustomResourceDefinitions required by the %s chart.
+
+It is generated from the metadata of %s and must be installed before it and upgraded along with it.
+`
+
+// GenerateCRDChartFromMainChart creates the Chart.yaml, values.yaml and README.md of a CRD chart at dstHelmChartPath
+// from the metadata of the main chart at mainHelmChartPath. The CRD chart is named <main chart>-crd and hidden from the catalog.
+func GenerateCRDChartFromMainChart(ctx context.Context, fs billy.Filesystem, dstHelmChartPath, mainHelmChartPath, crdsDir string) error {
+	mainChartMetadata, err := helmChartutil.LoadChartfile(filesystem.GetAbsPath(fs, filepath.Join(mainHelmChartPath, "Chart.yaml")))
+	if err != nil {
+		return fmt.Errorf("could not load Chart.yaml of %s: %s", mainHelmChartPath, err)
+	}
+	name := mainChartMetadata.Name + "-crd"
+
+	annotations := map[string]string{
+		"catalog.cattle.io/hidden":       "true",
+		"catalog.cattle.io/release-name": name,
+	}
+	for _, annotation := range []string{"catalog.cattle.io/certified", "catalog.cattle.io/namespace"} {
+		if value, ok := mainChartMetadata.Annotations[annotation]; ok {
+			annotations[annotation] = value
+		}
+	}
+	crdChartMetadata := &helmChart.Metadata{
+		APIVersion:  helmChart.APIVersionV2,
+		Name:        name,
+		Description: fmt.Sprintf("Installs the CRDs for %s.", mainChartMetadata.Name),
+		Type:        "application",
+		Version:     mainChartMetadata.Version,
+		AppVersion:  mainChartMetadata.AppVersion,
+		Annotations: annotations,
+	}
+
+	logger.Log(ctx, slog.LevelInfo, "generating CRD chart from main chart", slog.String("name", name), slog.String("dstHelmChartPath", dstHelmChartPath))
+	if err := fs.MkdirAll(filepath.Join(dstHelmChartPath, crdsDir), os.ModePerm); err != nil {
+		return err
+	}
+	if err := helmChartutil.SaveChartfile(filesystem.GetAbsPath(fs, filepath.Join(dstHelmChartPath, "Chart.yaml")), crdChartMetadata); err != nil {
+		return fmt.Errorf("could not write Chart.yaml of %s: %s", dstHelmChartPath, err)
+	}
+	files := map[string]string{
+		"values.yaml": fmt.Sprintf("# %s has no values: it only installs the CRDs required by %s\n", name, mainChartMetadata.Name),
+		"README.md":   fmt.Sprintf(crdChartReadmeFmt, name, mainChartMetadata.Name, mainChartMetadata.Name),
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filesystem.GetAbsPath(fs, filepath.Join(dstHelmChartPath, file)), []byte(content), os.ModePerm); err != nil {
+			return fmt.Errorf("encountered error while writing into %s: %s", filepath.Join(dstHelmChartPath, file), err)
+		}
+	}
+	return nil
+}
+
+// AddCRDValidationToChart adds the validate-install-crd.yaml to helmChartPathWithoutCRDs based on CRDs located in crdsDir within helmChartPathWithCRDs.
+// Every served version of a CRD referenced by the rendered templates of helmChartPathWithoutCRDs is validated;
+// CRDs that are not referenced are validated on their first version.
 func AddCRDValidationToChart(ctx context.Context, fs billy.Filesystem, helmChartPathWithoutCRDs, helmChartPathWithCRDs, crdsDir string) error {
 	// Get the CRDs
 	logger.Log(ctx, slog.LevelDebug, "adding CRD validation to main chart", slog.String("ChartValidateInstallCRDFile", path.ChartValidateInstallCRDFile))
 
 	crdsDirpath := filepath.Join(helmChartPathWithCRDs, crdsDir)
-	var crdGVKs []string
-	type k8sCRDResource struct {
-		APIVersion *string `yaml:"apiVersion,omitempty"`
-		Spec       *struct {
-			Group *string `yaml:"group,omitempty"`
-			Names *struct {
-				Kind *string `yaml:"kind,omitempty"`
-			} `yaml:"names,omitempty"`
-			Version  *string `yaml:"version,omitempty"`
-			Versions *[]struct {
-				Name *string `yaml:"name,omitempty"`
-			} `yaml:"versions,omitempty"`
-		} `yaml:"spec,omitempty"`
-	}
+	var definitions []crds.CRD
 	err := filesystem.WalkDir(ctx, fs, crdsDirpath, func(ctx context.Context, fs billy.Filesystem, path string, isDir bool) error {
 		if isDir {
 			return nil
@@ -83,47 +129,20 @@ func AddCRDValidationToChart(ctx context.Context, fs billy.Filesystem, helmChart
 		if err != nil {
 			return fmt.Errorf("unable to read file %s: %s", absPath, err)
 		}
-		yamlDecoder := yaml.NewDecoder(bytes.NewReader(yamlFile))
-		var resource k8sCRDResource
-		for {
-			err := yamlDecoder.Decode(&resource)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return err
-			}
-			if resource.APIVersion == nil || resource.Spec == nil {
-				continue
-			}
-			if !strings.HasPrefix(*resource.APIVersion, "apiextensions.k8s.io") {
-				continue
-			}
-			spec := *resource.Spec
-			if spec.Group == nil {
-				continue
-			}
-			if spec.Names == nil || spec.Names.Kind == nil {
-				continue
-			}
-			if spec.Version == nil && (spec.Versions == nil || len(*spec.Versions) == 0 || (*spec.Versions)[0].Name == nil) {
-				continue
-			}
-			group := *spec.Group
-			var version string
-			if resource.Spec.Version != nil {
-				version = *spec.Version
-			} else {
-				version = *(*spec.Versions)[0].Name
-			}
-			kind := *spec.Names.Kind
-			crdGVKs = append(crdGVKs, fmt.Sprintf("%s/%s/%s", group, version, kind))
-		}
+		definitions = append(definitions, crds.Parse(path, yamlFile)...)
 		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("encountered error while trying to read CRDs from %s: %s", crdsDirpath, err)
 	}
+
+	referenced, err := referencedGVKs(fs, helmChartPathWithoutCRDs)
+	if err != nil {
+		logger.Log(ctx, slog.LevelWarn, "unable to render the chart to find the CRD versions it uses; only the first version of each CRD is validated",
+			slog.String("chart", helmChartPathWithoutCRDs), logger.Err(err))
+	}
+
+	crdGVKs := validateInstallCRDGVKs(definitions, referenced)
 	if len(crdGVKs) == 0 {
 		return fmt.Errorf("unable to pull any GroupVersionKinds for CRDs from %s to construct %s", crdsDirpath, path.ChartValidateInstallCRDFile)
 	}
@@ -142,6 +161,43 @@ func AddCRDValidationToChart(ctx context.Context, fs billy.Filesystem, helmChart
 	return nil
 }
 
+// referencedGVKs renders the chart offline with its default values and returns the GroupVersionKinds of its manifests
+func referencedGVKs(fs billy.Filesystem, helmChartPath string) (map[string]bool, error) {
+	chart, err := helmLoader.Load(filesystem.GetAbsPath(fs, helmChartPath))
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := helm.RenderChart(chart, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	return helm.RenderedGVKs(rendered), nil
+}
+
+// validateInstallCRDGVKs returns the <group>/<version>/<kind> to validate for each CRD:
+// its served versions referenced by the chart, or its first version if the chart references none
+func validateInstallCRDGVKs(definitions []crds.CRD, referenced map[string]bool) []string {
+	var gvks []string
+	for _, definition := range definitions {
+		if definition.Group == "" || definition.Kind == "" || len(definition.Versions) == 0 {
+			continue
+		}
+
+		var definitionGVKs []string
+		for _, version := range definition.ServedVersions() {
+			gvk := fmt.Sprintf("%s/%s/%s", definition.Group, version, definition.Kind)
+			if referenced[gvk] {
+				definitionGVKs = append(definitionGVKs, gvk)
+			}
+		}
+		if len(definitionGVKs) == 0 {
+			definitionGVKs = append(definitionGVKs, fmt.Sprintf("%s/%s/%s", definition.Group, definition.Versions[0].Name, definition.Kind))
+		}
+		gvks = append(gvks, definitionGVKs...)
+	}
+	return gvks
+}
+
 // RemoveCRDValidationFromChart removes the ChartValidateInstallCRDFile from a given chart
 func RemoveCRDValidationFromChart(fs billy.Filesystem, helmChartPath string) error {
 	if err := fs.Remove(filepath.Join(helmChartPath, path.ChartValidateInstallCRDFile)); err != nil {
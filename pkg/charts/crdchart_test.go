@@ -0,0 +1,204 @@
+package charts
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files under testdata")
+
+const testCRDs = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gitrepos.fleet.cattle.io
+spec:
+  group: fleet.cattle.io
+  names:
+    kind: GitRepo
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+  - name: v1
+    served: true
+    storage: true
+  - name: v0
+    served: false
+    storage: false
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: bundles.fleet.cattle.io
+spec:
+  group: fleet.cattle.io
+  names:
+    kind: Bundle
+  version: v1alpha1
+`
+
+// writeTestChart writes a main chart with the test CRDs in crds/ and the given template under dir
+func writeTestChart(t *testing.T, dir, template string) {
+	t.Helper()
+	files := map[string]string{
+		"Chart.yaml":             "apiVersion: v2\nname: fleet\nversion: 0.11.0\n",
+		"values.yaml":            "gitRepos:\n- name: local\n  repo: https://github.com/rancher/fleet-examples\n",
+		"crds/crds.yaml":         testCRDs,
+		"templates/gitrepo.yaml": template,
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	}
+}
+
+// assertGolden compares the content with testdata/<golden>, rewriting it with -update
+func assertGolden(t *testing.T, golden, content string) {
+	t.Helper()
+	goldenPath := filepath.Join("testdata", golden)
+	if *updateGolden {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(goldenPath), 0755))
+		assert.NoError(t, os.WriteFile(goldenPath, []byte(content), 0644))
+	}
+	expected, err := os.ReadFile(goldenPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), content)
+}
+
+func Test_AddCRDValidationToChart(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+
+	tests := []struct {
+		name     string
+		template string
+		golden   string
+	}{
+		{
+			name: "#1 served versions referenced by the rendered templates",
+			template: `{{- range .Values.gitRepos }}
+---
+apiVersion: fleet.cattle.io/v1
+kind: GitRepo
+metadata:
+  name: {{ .name }}
+spec:
+  repo: {{ .repo }}
+{{- end }}
+---
+apiVersion: fleet.cattle.io/v1alpha1
+kind: GitRepo
+metadata:
+  name: legacy
+`,
+			golden: "validate-install-crd/referenced.golden",
+		},
+		{
+			name: "#2 chart that does not render falls back to the first version",
+			template: `apiVersion: fleet.cattle.io/v1
+kind: GitRepo
+metadata:
+  name: {{ required "a name is required" .Values.name }}
+`,
+			golden: "validate-install-crd/not-rendered.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestChart(t, filepath.Join(dir, "charts"), tt.template)
+
+			fs := filesystem.GetFilesystem(dir)
+			assert.NoError(t, AddCRDValidationToChart(ctx, fs, "charts", "charts", path.ChartCRDDir))
+
+			content, err := os.ReadFile(filepath.Join(dir, "charts", path.ChartValidateInstallCRDFile))
+			assert.NoError(t, err)
+			assertGolden(t, tt.golden, string(content))
+		})
+	}
+}
+
+func Test_ApplyMainChanges_validateInstallCRD(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+
+	template := "apiVersion: fleet.cattle.io/v1\nkind: GitRepo\nmetadata:\n  name: local\n---\napiVersion: fleet.cattle.io/v1alpha1\nkind: GitRepo\nmetadata:\n  name: legacy\n"
+
+	for _, useTarArchive := range []bool{false, true} {
+		dir := t.TempDir()
+		writeTestChart(t, filepath.Join(dir, "charts"), template)
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, path.PackageOptionsFile), []byte("url: local\n"), 0644))
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "charts-crd", "templates"), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts-crd", "Chart.yaml"), []byte("apiVersion: v2\nname: fleet-crd\nversion: 0.11.0\n"), 0644))
+
+		c := &AdditionalChart{
+			WorkingDir: "charts-crd",
+			CRDChartOptions: &options.CRDChartOptions{
+				CRDDirectory:                "templates",
+				UseTarArchive:               useTarArchive,
+				AddCRDValidationToMainChart: true,
+			},
+		}
+		assert.NoError(t, c.ApplyMainChanges(ctx, filesystem.GetFilesystem(dir)))
+
+		content, err := os.ReadFile(filepath.Join(dir, "charts", path.ChartValidateInstallCRDFile))
+		assert.NoError(t, err)
+		assertGolden(t, "validate-install-crd/referenced.golden", string(content))
+		if useTarArchive {
+			assert.FileExists(t, filepath.Join(dir, "charts-crd", path.ChartExtraFileDir, path.ChartCRDTgzFilename))
+		}
+	}
+}
+
+func Test_Prepare_generateFromMainChart(t *testing.T) {
+	ctx := context.Background()
+	util.InitSoftErrorMode()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		path.PackageOptionsFile:          "url: local\nadditionalCharts:\n- workingDir: charts-crd\n  crdOptions:\n    generateFromMainChart: true\n    crdDirectory: templates\n",
+		"charts/Chart.yaml":              "apiVersion: v2\nname: fleet\nversion: 0.11.0\nappVersion: 0.11.0\nannotations:\n  catalog.cattle.io/certified: rancher\n  catalog.cattle.io/namespace: cattle-fleet-system\n  catalog.cattle.io/display-name: Fleet\n",
+		"charts/templates/gitrepos.yaml": "{{- if .Values.installCRDs }}\n" + strings.SplitN(testCRDs, "---\n", 2)[0] + "{{- end }}\n",
+		"charts/crds/bundles.yaml":       strings.SplitN(testCRDs, "---\n", 2)[1],
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	}
+
+	c := &AdditionalChart{
+		WorkingDir: "charts-crd",
+		CRDChartOptions: &options.CRDChartOptions{
+			GenerateFromMainChart: true,
+			CRDDirectory:          "templates",
+		},
+	}
+	upstreamVersion := "0.11.0"
+	pkgFs := filesystem.GetFilesystem(dir)
+	assert.NoError(t, c.Prepare(ctx, pkgFs, pkgFs, &upstreamVersion))
+	assert.NoError(t, c.ApplyMainChanges(ctx, pkgFs))
+
+	read := func(file string) string {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		assert.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, "annotations:\n  catalog.cattle.io/certified: rancher\n  catalog.cattle.io/hidden: \"true\"\n  catalog.cattle.io/namespace: cattle-fleet-system\n  catalog.cattle.io/release-name: fleet-crd\napiVersion: v2\nappVersion: 0.11.0\ndescription: Installs the CRDs for fleet.\nname: fleet-crd\ntype: application\nversion: 0.11.0\n", read("charts-crd/Chart.yaml"))
+	assert.Contains(t, read("charts-crd/README.md"), "required by the fleet chart")
+	assert.FileExists(t, filepath.Join(dir, "charts-crd", "values.yaml"))
+	assert.Contains(t, read("charts-crd/templates/gitrepos.fleet.cattle.io.yaml"), "name: gitrepos.fleet.cattle.io")
+	assert.Contains(t, read("charts-crd/templates/bundles.yaml"), "name: bundles.fleet.cattle.io")
+	assert.NoFileExists(t, filepath.Join(dir, "charts", "templates", "gitrepos.yaml"))
+	assert.NoDirExists(t, filepath.Join(dir, "charts", path.ChartCRDDir))
+}
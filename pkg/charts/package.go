@@ -35,6 +35,10 @@ type Package struct {
 	DoNotRelease bool `yaml:"doNotRelease,omitempty"`
 	// Auto triggers the auto chart bump
 	Auto bool `yaml:"auto,omitempty"`
+	// RemovedValues acknowledges, per chart name, the values.yaml keys removed on purpose since the previously released version
+	RemovedValues map[string][]string `yaml:"removedValues,omitempty"`
+	// CRDIncompatibilities acknowledges, per chart name, the incompatible CRD changes made on purpose since the previously released version
+	CRDIncompatibilities map[string][]string `yaml:"crdIncompatibilities,omitempty"`
 	// AutoGeneratedBumpVersion is the version that the package should be bumped to
 	// If present, this will override all other versions
 	AutoGeneratedBumpVersion *semver.Version
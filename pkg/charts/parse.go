@@ -134,12 +134,14 @@ func GetPackage(ctx context.Context, rootFs billy.Filesystem, name string) (*Pac
 	p := Package{
 		Chart: chart,
 
-		Name:             name,
-		Version:          version,
-		PackageVersion:   packageOpt.PackageVersion,
-		AdditionalCharts: additionalCharts,
-		DoNotRelease:     packageOpt.DoNotRelease,
-		Auto:             packageOpt.Auto,
+		Name:                 name,
+		Version:              version,
+		PackageVersion:       packageOpt.PackageVersion,
+		AdditionalCharts:     additionalCharts,
+		DoNotRelease:         packageOpt.DoNotRelease,
+		Auto:                 packageOpt.Auto,
+		RemovedValues:        packageOpt.RemovedValues,
+		CRDIncompatibilities: packageOpt.CRDIncompatibilities,
 
 		fs:     pkgFs,
 		rootFs: rootFs,
@@ -158,10 +160,11 @@ func GetChartFromOptions(ctx context.Context, opt options.ChartOptions) (Chart,
 		workingDir = "charts"
 	}
 	return Chart{
-		WorkingDir:         workingDir,
-		Upstream:           upstream,
-		IgnoreDependencies: opt.IgnoreDependencies,
-		ReplacePaths:       opt.ReplacePaths,
+		WorkingDir:           workingDir,
+		Upstream:             upstream,
+		IgnoreDependencies:   opt.IgnoreDependencies,
+		ReplacePaths:         opt.ReplacePaths,
+		GenerateValuesSchema: opt.GenerateValuesSchema,
 	}, nil
 }
 
@@ -199,14 +202,19 @@ func GetAdditionalChartFromOptions(ctx context.Context, opt options.AdditionalCh
 			return a, fmt.Errorf("CRD options cannot provide both a directory to place CRDs within and use tar archive")
 		}
 		templateDirectory := opt.CRDChartOptions.TemplateDirectory
-		if len(templateDirectory) == 0 {
-			return a, fmt.Errorf("CRD options must provide a template directory")
+		generateFromMainChart := opt.CRDChartOptions.GenerateFromMainChart
+		if len(templateDirectory) == 0 && !generateFromMainChart {
+			return a, fmt.Errorf("CRD options must provide a template directory or generate the CRD chart from the main chart")
+		}
+		if len(templateDirectory) != 0 && generateFromMainChart {
+			return a, fmt.Errorf("CRD options cannot provide both a template directory and generate the CRD chart from the main chart")
 		}
 		if crdDirectory == "" && useTarArchive {
 			crdDirectory = path.ChartCRDDir
 		}
 		a.CRDChartOptions = &options.CRDChartOptions{
 			TemplateDirectory:           templateDirectory,
+			GenerateFromMainChart:       generateFromMainChart,
 			CRDDirectory:                crdDirectory,
 			UseTarArchive:               useTarArchive,
 			AddCRDValidationToMainChart: opt.CRDChartOptions.AddCRDValidationToMainChart,
@@ -0,0 +1,255 @@
+package chartvalues
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// ChangeType classifies how a values key changed between two versions of a chart
+type ChangeType string
+
+// Values changes, only removals break the configuration of existing releases silently
+const (
+	Added          ChangeType = "added"
+	Removed        ChangeType = "removed"
+	TypeChanged    ChangeType = "type-changed"
+	DefaultChanged ChangeType = "default-changed"
+)
+
+// schemaFile is the optional JSON schema of the values of a chart
+const schemaFile = "values.schema.json"
+
+// Change is a key of the values.yaml, or a property of the values.schema.json, that changed between two versions of a chart.
+// Old and New are the json encoded defaults, or the types for type changes and changes of the schema.
+type Change struct {
+	Key  string
+	Type ChangeType
+	Old  string
+	New  string
+	// Schema is set for the changes of values.schema.json
+	Schema bool
+}
+
+// String describes the change, e.g. removed oldOption with default "" or values.schema.json: type of replicas changed from string to integer
+func (c Change) String() string {
+	detail := "with default"
+	if c.Schema {
+		detail = "of type"
+	}
+
+	var description string
+	switch c.Type {
+	case Added:
+		description = fmt.Sprintf("added %s %s %s", c.Key, detail, c.New)
+	case Removed:
+		description = fmt.Sprintf("removed %s %s %s", c.Key, detail, c.Old)
+	case TypeChanged:
+		description = fmt.Sprintf("type of %s changed from %s to %s", c.Key, c.Old, c.New)
+	case DefaultChanged:
+		description = fmt.Sprintf("default of %s changed from %s to %s", c.Key, c.Old, c.New)
+	}
+	if c.Schema {
+		return schemaFile + ": " + description
+	}
+	return description
+}
+
+// Flatten returns the leaves of the values by dotted path; lists and empty maps are leaves
+func Flatten(values map[string]interface{}) map[string]interface{} {
+	leaves := make(map[string]interface{})
+	flatten("", values, leaves)
+	return leaves
+}
+
+func flatten(prefix string, values map[string]interface{}, leaves map[string]interface{}) {
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(prefix+key+".", nested, leaves)
+			continue
+		}
+		leaves[prefix+key] = value
+	}
+}
+
+// Diff compares the flattened values.yaml and, when present, the values.schema.json of two versions of a chart.
+// The changes are sorted by key, the values.yaml changes first.
+func Diff(oldChart, newChart *chart.Chart) ([]Change, error) {
+	changes := diffValues(Flatten(oldChart.Values), Flatten(newChart.Values))
+
+	oldSchema, err := schemaTypes(oldChart.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s of %s %s: %w", schemaFile, oldChart.Name(), oldChart.Metadata.Version, err)
+	}
+	newSchema, err := schemaTypes(newChart.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s of %s %s: %w", schemaFile, newChart.Name(), newChart.Metadata.Version, err)
+	}
+	return append(changes, diffSchemas(oldSchema, newSchema)...), nil
+}
+
+// diffValues classifies the keys of the flattened values. A type change is only reported between two types
+// that are not null, since a null default only documents a key; it is reported as a default change otherwise.
+// A null or empty map default that gets nested keys, or the reverse, is not reported since the nested keys are.
+func diffValues(oldValues, newValues map[string]interface{}) []Change {
+	changes := []Change{}
+	for key, oldValue := range oldValues {
+		newValue, ok := newValues[key]
+		switch {
+		case !ok && hasNested(newValues, key):
+			if !isEmpty(oldValue) {
+				changes = append(changes, Change{Key: key, Type: TypeChanged, Old: typeOf(oldValue), New: typeOf(map[string]interface{}{})})
+			}
+		case !ok:
+			changes = append(changes, Change{Key: key, Type: Removed, Old: encode(oldValue)})
+		case oldValue != nil && newValue != nil && typeOf(oldValue) != typeOf(newValue):
+			changes = append(changes, Change{Key: key, Type: TypeChanged, Old: typeOf(oldValue), New: typeOf(newValue)})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, Change{Key: key, Type: DefaultChanged, Old: encode(oldValue), New: encode(newValue)})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; ok {
+			continue
+		}
+		switch {
+		case hasNested(oldValues, key):
+			if !isEmpty(newValue) {
+				changes = append(changes, Change{Key: key, Type: TypeChanged, Old: typeOf(map[string]interface{}{}), New: typeOf(newValue)})
+			}
+		default:
+			changes = append(changes, Change{Key: key, Type: Added, New: encode(newValue)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// hasNested returns whether the flattened values have a key nested under key
+func hasNested(values map[string]interface{}, key string) bool {
+	for k := range values {
+		if strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty returns whether a default is null or an empty map
+func isEmpty(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	return value == nil || (ok && len(m) == 0)
+}
+
+// diffSchemas classifies the properties of the flattened schemas
+func diffSchemas(oldTypes, newTypes map[string]string) []Change {
+	changes := []Change{}
+	for key, oldType := range oldTypes {
+		newType, ok := newTypes[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Type: Removed, Old: oldType, Schema: true})
+		case oldType != newType:
+			changes = append(changes, Change{Key: key, Type: TypeChanged, Old: oldType, New: newType, Schema: true})
+		}
+	}
+	for key, newType := range newTypes {
+		if _, ok := oldTypes[key]; !ok {
+			changes = append(changes, Change{Key: key, Type: Added, New: newType, Schema: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// jsonSchema is the subset of a values.schema.json needed to list its properties and their types
+type jsonSchema struct {
+	Type       interface{}            `json:"type"`
+	Properties map[string]*jsonSchema `json:"properties"`
+}
+
+// schemaTypes returns the type of every property of the schema by dotted path, e.g. image.tag: string
+func schemaTypes(data []byte) (map[string]string, error) {
+	types := make(map[string]string)
+	if len(data) == 0 {
+		return types, nil
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+	collectSchemaTypes("", &schema, types)
+	return types, nil
+}
+
+func collectSchemaTypes(prefix string, schema *jsonSchema, types map[string]string) {
+	for name, property := range schema.Properties {
+		if property == nil {
+			continue
+		}
+		types[prefix+name] = schemaType(property.Type)
+		collectSchemaTypes(prefix+name+".", property, types)
+	}
+}
+
+// schemaType returns a schema type, or the types of a list separated by |, or any if it is not set
+func schemaType(t interface{}) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var types []string
+		for _, item := range v {
+			types = append(types, fmt.Sprint(item))
+		}
+		sort.Strings(types)
+		return strings.Join(types, "|")
+	}
+	return "any"
+}
+
+// typeOf returns the json type of a decoded yaml value
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// encode returns the json encoding of a default value
+func encode(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// Unacknowledged returns the removals that are not acknowledged. A key acknowledges its own removal and the removal
+// of every key nested under it, e.g. legacy acknowledges legacy.enabled.
+func Unacknowledged(changes []Change, acknowledged []string) []Change {
+	unacknowledged := []Change{}
+	for _, change := range changes {
+		if change.Type != Removed || util.IsAcknowledged(change.Key, acknowledged, ".") {
+			continue
+		}
+		unacknowledged = append(unacknowledged, change)
+	}
+	return unacknowledged
+}
@@ -0,0 +1,145 @@
+package chartvalues
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func Test_Diff(t *testing.T) {
+	newChart := func(values map[string]interface{}, schema string) *chart.Chart {
+		c := &chart.Chart{Metadata: &chart.Metadata{Name: "fleet", Version: "105.0.0+up0.11.0"}, Values: values}
+		if schema != "" {
+			c.Schema = []byte(schema)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		oldChart  *chart.Chart
+		newChart  *chart.Chart
+		expected  []Change
+		expectErr bool
+	}{
+		{
+			name:     "#1 no changes",
+			oldChart: newChart(map[string]interface{}{"debug": false, "image": map[string]interface{}{"tag": "v1"}}, ""),
+			newChart: newChart(map[string]interface{}{"debug": false, "image": map[string]interface{}{"tag": "v1"}}, ""),
+			expected: []Change{},
+		},
+		{
+			name: "#2 added, removed, type and default changes",
+			oldChart: newChart(map[string]interface{}{
+				"debug":     false,
+				"replicas":  "1",
+				"image":     map[string]interface{}{"tag": "v1"},
+				"oldOption": "",
+				"nodes":     nil,
+			}, ""),
+			newChart: newChart(map[string]interface{}{
+				"debug":     false,
+				"replicas":  float64(1),
+				"image":     map[string]interface{}{"tag": "v2"},
+				"newOption": map[string]interface{}{"enabled": true},
+				"nodes":     []interface{}{"a"},
+			}, ""),
+			expected: []Change{
+				{Key: "image.tag", Type: DefaultChanged, Old: `"v1"`, New: `"v2"`},
+				{Key: "newOption.enabled", Type: Added, New: "true"},
+				{Key: "nodes", Type: DefaultChanged, Old: "null", New: `["a"]`},
+				{Key: "oldOption", Type: Removed, Old: `""`},
+				{Key: "replicas", Type: TypeChanged, Old: "string", New: "number"},
+			},
+		},
+		{
+			name:     "#3 empty map filled with nested keys",
+			oldChart: newChart(map[string]interface{}{"resources": map[string]interface{}{}}, ""),
+			newChart: newChart(map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "1"}}}, ""),
+			expected: []Change{{Key: "resources.limits.cpu", Type: Added, New: `"1"`}},
+		},
+		{
+			name:     "#4 scalar replaced by nested keys",
+			oldChart: newChart(map[string]interface{}{"proxy": "http://proxy"}, ""),
+			newChart: newChart(map[string]interface{}{"proxy": map[string]interface{}{"url": "http://proxy"}}, ""),
+			expected: []Change{
+				{Key: "proxy", Type: TypeChanged, Old: "string", New: "object"},
+				{Key: "proxy.url", Type: Added, New: `"http://proxy"`},
+			},
+		},
+		{
+			name:     "#5 schema changes",
+			oldChart: newChart(nil, `{"properties": {"replicas": {"type": "string"}, "legacy": {"type": "object", "properties": {"enabled": {"type": "boolean"}}}}}`),
+			newChart: newChart(nil, `{"properties": {"replicas": {"type": ["integer", "string"]}, "debug": {}}}`),
+			expected: []Change{
+				{Key: "debug", Type: Added, New: "any", Schema: true},
+				{Key: "legacy", Type: Removed, Old: "object", Schema: true},
+				{Key: "legacy.enabled", Type: Removed, Old: "boolean", Schema: true},
+				{Key: "replicas", Type: TypeChanged, Old: "string", New: "integer|string", Schema: true},
+			},
+		},
+		{
+			name:      "#6 invalid schema",
+			oldChart:  newChart(nil, `{"properties": `),
+			newChart:  newChart(nil, ""),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.oldChart, tt.newChart)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+}
+
+func Test_Unacknowledged(t *testing.T) {
+	changes := []Change{
+		{Key: "legacy.enabled", Type: Removed, Old: "false"},
+		{Key: "legacyMode", Type: Removed, Old: "false"},
+		{Key: "newOption", Type: Added, New: "true"},
+		{Key: "replicas", Type: Removed, Old: "integer", Schema: true},
+	}
+
+	tests := []struct {
+		name         string
+		acknowledged []string
+		expected     []Change
+	}{
+		{"#1 nothing acknowledged", nil, []Change{changes[0], changes[1], changes[3]}},
+		{"#2 parent key acknowledges nested keys only", []string{"legacy"}, []Change{changes[1], changes[3]}},
+		{"#3 every removal acknowledged", []string{"legacy.enabled", "legacyMode", "replicas"}, []Change{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Unacknowledged(changes, tt.acknowledged))
+		})
+	}
+}
+
+func Test_ChangeString(t *testing.T) {
+	tests := []struct {
+		change   Change
+		expected string
+	}{
+		{Change{Key: "newOption.enabled", Type: Added, New: "true"}, "added newOption.enabled with default true"},
+		{Change{Key: "oldOption", Type: Removed, Old: `""`}, `removed oldOption with default ""`},
+		{Change{Key: "replicas", Type: TypeChanged, Old: "string", New: "number"}, "type of replicas changed from string to number"},
+		{Change{Key: "image.tag", Type: DefaultChanged, Old: `"v1"`, New: `"v2"`}, `default of image.tag changed from "v1" to "v2"`},
+		{Change{Key: "legacy", Type: Removed, Old: "object", Schema: true}, "values.schema.json: removed legacy of type object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.change.String())
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package chartvalues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Comment markers of values.yaml read when generating a schema
+const (
+	// schemaAnnotation is followed by a yaml map of JSON schema keywords for the key, e.g. # @schema enum: [rancher, partner]
+	schemaAnnotation = "@schema"
+	// descriptionMarker starts a description of the key, as used by helm-docs, e.g. # -- Number of replicas
+	descriptionMarker = "--"
+)
+
+// jsonSchemaDraft is the JSON schema dialect of the generated schemas
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// GenerateSchema infers a JSON schema from a values.yaml: maps are objects with their keys as properties, lists are arrays,
+// and scalars get their yaml type, except null defaults that accept any value. The comments of a key can describe it with
+// a helm-docs "# -- <description>" line and add or override keywords with "# @schema <yaml map>" lines, e.g. enums.
+// Properties are not required and objects accept additional properties, so the schema does not reject existing configurations.
+func GenerateSchema(values []byte) (map[string]interface{}, error) {
+	schema := map[string]interface{}{"$schema": jsonSchemaDraft, "type": "object"}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(values, &document); err != nil {
+		return nil, err
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return schema, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("values must be a map, got %s", root.Tag)
+	}
+	if len(root.Content) > 0 {
+		properties, err := propertiesSchema("", root)
+		if err != nil {
+			return nil, err
+		}
+		schema["properties"] = properties
+	}
+	return schema, nil
+}
+
+// propertiesSchema returns the schema of each key of a yaml map
+func propertiesSchema(prefix string, node *yaml.Node) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		property, err := nodeSchema(prefix+key.Value+".", value)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range []string{key.HeadComment, key.LineComment, value.LineComment} {
+			if err := annotate(property, comment); err != nil {
+				return nil, fmt.Errorf("invalid %s annotation of %s: %w", schemaAnnotation, prefix+key.Value, err)
+			}
+		}
+		properties[key.Value] = property
+	}
+	return properties, nil
+}
+
+// nodeSchema infers the schema of a yaml value
+func nodeSchema(prefix string, node *yaml.Node) (map[string]interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return nodeSchema(prefix, node.Alias)
+	case yaml.MappingNode:
+		schema := map[string]interface{}{"type": "object"}
+		if len(node.Content) > 0 {
+			properties, err := propertiesSchema(prefix, node)
+			if err != nil {
+				return nil, err
+			}
+			schema["properties"] = properties
+		}
+		return schema, nil
+	case yaml.SequenceNode:
+		schema := map[string]interface{}{"type": "array"}
+		if itemsType := sequenceType(node); itemsType != "" {
+			schema["items"] = map[string]interface{}{"type": itemsType}
+		}
+		return schema, nil
+	}
+
+	if scalarType := scalarType(node); scalarType != "" {
+		return map[string]interface{}{"type": scalarType}, nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+// scalarType returns the JSON schema type of a yaml scalar, or "" for null
+func scalarType(node *yaml.Node) string {
+	if node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	switch node.ShortTag() {
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!null":
+		return ""
+	}
+	return "string"
+}
+
+// sequenceType returns the type shared by every item of a list of scalars, or "" if the items differ or are not scalars
+func sequenceType(node *yaml.Node) string {
+	itemsType := ""
+	for _, item := range node.Content {
+		t := scalarType(item)
+		if t == "" || (itemsType != "" && t != itemsType) {
+			return ""
+		}
+		itemsType = t
+	}
+	return itemsType
+}
+
+// annotate adds the description and the @schema keywords of a comment to the schema of a key;
+// like helm-docs, the lines following a description line continue it
+func annotate(schema map[string]interface{}, comment string) error {
+	var description []string
+	inDescription := false
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		switch {
+		case strings.HasPrefix(line, schemaAnnotation):
+			inDescription = false
+			keywords := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(strings.TrimPrefix(line, schemaAnnotation)), &keywords); err != nil {
+				return err
+			}
+			for keyword, value := range keywords {
+				schema[keyword] = value
+			}
+		case strings.HasPrefix(line, descriptionMarker):
+			inDescription = true
+			description = append(description, strings.TrimSpace(strings.TrimPrefix(line, descriptionMarker)))
+		case inDescription && line != "":
+			description = append(description, line)
+		default:
+			inDescription = false
+		}
+	}
+	if len(description) > 0 {
+		if _, ok := schema["description"]; !ok {
+			schema["description"] = strings.Join(description, " ")
+		}
+	}
+	return nil
+}
+
+// MergeSchemas merges the overlay schema, e.g. a values.schema.json shipped with the chart or added by generated-changes,
+// into the base schema: maps are merged recursively and the overlay wins on any other keyword
+func MergeSchemas(base, overlay map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for keyword, value := range base {
+		merged[keyword] = value
+	}
+	for keyword, value := range overlay {
+		baseMap, baseIsMap := merged[keyword].(map[string]interface{})
+		overlayMap, overlayIsMap := value.(map[string]interface{})
+		if baseIsMap && overlayIsMap {
+			merged[keyword] = MergeSchemas(baseMap, overlayMap)
+			continue
+		}
+		merged[keyword] = value
+	}
+	return merged
+}
+
+// EncodeSchema returns the indented values.schema.json of a schema
+func EncodeSchema(schema map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
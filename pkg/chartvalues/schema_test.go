@@ -0,0 +1,114 @@
+package chartvalues
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateSchema(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    string
+		expected  map[string]interface{}
+		expectErr bool
+	}{
+		{
+			name:     "#1 empty values",
+			values:   "",
+			expected: map[string]interface{}{"$schema": jsonSchemaDraft, "type": "object"},
+		},
+		{
+			name: "#2 types",
+			values: "replicas: 1\nratio: 0.5\ndebug: false\nname: fleet\nproxy: null\nresources: {}\n" +
+				"tolerations: []\nnamespaces: [a, b]\nmixed: [a, 1]\nimage:\n  repository: rancher/fleet\n  tag: &tag v0.11.0\nagentTag: *tag\n",
+			expected: map[string]interface{}{
+				"$schema": jsonSchemaDraft,
+				"type":    "object",
+				"properties": map[string]interface{}{
+					"replicas":    map[string]interface{}{"type": "integer"},
+					"ratio":       map[string]interface{}{"type": "number"},
+					"debug":       map[string]interface{}{"type": "boolean"},
+					"name":        map[string]interface{}{"type": "string"},
+					"proxy":       map[string]interface{}{},
+					"resources":   map[string]interface{}{"type": "object"},
+					"tolerations": map[string]interface{}{"type": "array"},
+					"namespaces":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					"mixed":       map[string]interface{}{"type": "array"},
+					"image": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"repository": map[string]interface{}{"type": "string"},
+							"tag":        map[string]interface{}{"type": "string"},
+						},
+					},
+					"agentTag": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+		{
+			name: "#3 descriptions and annotations",
+			values: "# -- Number of replicas\n# of the controller\n# @schema minimum: 1\nreplicas: 1\n" +
+				"certified: rancher # @schema {enum: [rancher, partner]}\n" +
+				"# @schema type: [string, \"null\"]\n# @schema description: Proxy URL\n# -- ignored since the annotation sets a description\nproxy:\n",
+			expected: map[string]interface{}{
+				"$schema": jsonSchemaDraft,
+				"type":    "object",
+				"properties": map[string]interface{}{
+					"replicas":  map[string]interface{}{"type": "integer", "minimum": 1, "description": "Number of replicas of the controller"},
+					"certified": map[string]interface{}{"type": "string", "enum": []interface{}{"rancher", "partner"}},
+					"proxy":     map[string]interface{}{"type": []interface{}{"string", "null"}, "description": "Proxy URL"},
+				},
+			},
+		},
+		{
+			name:      "#4 invalid annotation",
+			values:    "# @schema enum: [a\nname: a\n",
+			expectErr: true,
+		},
+		{
+			name:      "#5 values are not a map",
+			values:    "- a\n- b\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := GenerateSchema([]byte(tt.values))
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, schema)
+		})
+	}
+}
+
+func Test_MergeSchemas(t *testing.T) {
+	base := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": "integer"},
+			"image":    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"tag": map[string]interface{}{"type": "string"}}},
+		},
+	}
+	overlay := map[string]interface{}{
+		"required": []interface{}{"image"},
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": []interface{}{"integer", "string"}},
+			"image":    map[string]interface{}{"properties": map[string]interface{}{"tag": map[string]interface{}{"pattern": "^v"}}},
+		},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"image"},
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": []interface{}{"integer", "string"}},
+			"image":    map[string]interface{}{"type": "object", "properties": map[string]interface{}{"tag": map[string]interface{}{"type": "string", "pattern": "^v"}}},
+		},
+	}, MergeSchemas(base, overlay))
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, base["properties"].(map[string]interface{})["replicas"], "base is not modified")
+}
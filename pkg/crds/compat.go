@@ -0,0 +1,187 @@
+package crds
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rancher/charts-build-scripts/pkg/util"
+)
+
+// Rules broken by a new version of a CRD that existing custom resources may rely on
+const (
+	RemovedCRD       = "removed-crd"       // the CRD is no longer shipped
+	RemovedVersion   = "removed-version"   // a served version is removed or no longer served
+	StorageVersion   = "storage-version"   // the storage version changed
+	RemovedProperty  = "removed-property"  // a schema property is removed
+	RetypedProperty  = "retyped-property"  // a schema property changed its type
+	RequiredProperty = "required-property" // a schema property became required
+)
+
+// Incompatibility is a change between two versions of a CRD that can break existing custom resources
+type Incompatibility struct {
+	CRD     string `json:"crd"`
+	Version string `json:"version,omitempty"`
+	Rule    string `json:"rule"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Compare returns the incompatibilities of the new CRDs with the previously released ones, sorted by CRD:
+//
+//	a CRD or a served version is removed
+//	the storage version changes
+//	a schema property of a version served by both is removed, retyped or becomes required
+func Compare(oldCRDs, newCRDs []CRD) []Incompatibility {
+	incompatibilities := []Incompatibility{}
+
+	newByName := make(map[string]CRD, len(newCRDs))
+	for _, c := range newCRDs {
+		newByName[c.Name] = c
+	}
+
+	sorted := append([]CRD{}, oldCRDs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, oldCRD := range sorted {
+		newCRD, ok := newByName[oldCRD.Name]
+		if !ok {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				CRD:     oldCRD.Name,
+				Rule:    RemovedCRD,
+				Message: fmt.Sprintf("CRD %s is removed", oldCRD.Name),
+			})
+			continue
+		}
+		incompatibilities = append(incompatibilities, compareCRD(oldCRD, newCRD)...)
+	}
+
+	return incompatibilities
+}
+
+// compareCRD compares the versions and the schemas of two versions of the same CRD
+func compareCRD(oldCRD, newCRD CRD) []Incompatibility {
+	var incompatibilities []Incompatibility
+
+	newServed := newCRD.ServedVersions()
+	for _, served := range oldCRD.ServedVersions() {
+		if !slices.Contains(newServed, served) {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				CRD:     oldCRD.Name,
+				Version: served,
+				Rule:    RemovedVersion,
+				Message: fmt.Sprintf("served version %s is removed or no longer served", served),
+			})
+			continue
+		}
+
+		oldVersion, _ := oldCRD.Version(served)
+		newVersion, _ := newCRD.Version(served)
+		for _, change := range compareSchema("", oldVersion.Schema, newVersion.Schema) {
+			change.CRD = oldCRD.Name
+			change.Version = served
+			incompatibilities = append(incompatibilities, change)
+		}
+	}
+
+	if oldStorage, newStorage := oldCRD.StorageVersion(), newCRD.StorageVersion(); oldStorage != newStorage {
+		incompatibilities = append(incompatibilities, Incompatibility{
+			CRD:     oldCRD.Name,
+			Version: newStorage,
+			Rule:    StorageVersion,
+			Message: fmt.Sprintf("storage version changed from %s to %s", oldStorage, newStorage),
+		})
+	}
+
+	return incompatibilities
+}
+
+// compareSchema walks the old schema and reports the properties removed, retyped or newly required in the new one.
+// A new schema without properties does not constrain them, so nothing below it is reported.
+func compareSchema(schemaPath string, oldSchema, newSchema *Schema) []Incompatibility {
+	if oldSchema == nil || newSchema == nil {
+		return nil
+	}
+
+	var incompatibilities []Incompatibility
+	if oldSchema.Type != "" && newSchema.Type != "" && oldSchema.Type != newSchema.Type {
+		return append(incompatibilities, Incompatibility{
+			Rule:    RetypedProperty,
+			Path:    schemaPathOrRoot(schemaPath),
+			Message: fmt.Sprintf("type changed from %s to %s", oldSchema.Type, newSchema.Type),
+		})
+	}
+
+	for _, required := range newSchema.Required {
+		if !slices.Contains(oldSchema.Required, required) {
+			incompatibilities = append(incompatibilities, Incompatibility{
+				Rule:    RequiredProperty,
+				Path:    schemaPath + "." + required,
+				Message: fmt.Sprintf("%s became required", required),
+			})
+		}
+	}
+
+	if len(newSchema.Properties) > 0 {
+		names := make([]string, 0, len(oldSchema.Properties))
+		for name := range oldSchema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			newProperty, ok := newSchema.Properties[name]
+			if !ok {
+				incompatibilities = append(incompatibilities, Incompatibility{
+					Rule:    RemovedProperty,
+					Path:    schemaPath + "." + name,
+					Message: fmt.Sprintf("%s is removed", name),
+				})
+				continue
+			}
+			incompatibilities = append(incompatibilities, compareSchema(schemaPath+"."+name, oldSchema.Properties[name], newProperty)...)
+		}
+	}
+
+	return append(incompatibilities, compareSchema(schemaPath+"[]", oldSchema.Items, newSchema.Items)...)
+}
+
+// schemaPathOrRoot returns the schema path, or . for the root of the schema
+func schemaPathOrRoot(schemaPath string) string {
+	if schemaPath == "" {
+		return "."
+	}
+	return schemaPath
+}
+
+// Key identifies what the incompatibility is about: its CRD, version and schema path separated by spaces,
+// e.g. gitrepos.fleet.cattle.io v1alpha1 .spec.paused
+func (i Incompatibility) Key() string {
+	parts := []string{i.CRD}
+	if i.Version != "" {
+		parts = append(parts, i.Version)
+	}
+	if i.Path != "" {
+		parts = append(parts, i.Path)
+	}
+	return strings.Join(parts, " ")
+}
+
+// String returns a one line description of the incompatibility
+func (i Incompatibility) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Key(), i.Message, i.Rule)
+}
+
+// Unacknowledged returns the incompatibilities that are not acknowledged. An entry acknowledges the incompatibilities
+// whose key it equals or starts, e.g. gitrepos.fleet.cattle.io acknowledges every incompatibility of the CRD
+// and "gitrepos.fleet.cattle.io v1alpha1" only the ones of its v1alpha1 version.
+func Unacknowledged(incompatibilities []Incompatibility, acknowledged []string) []Incompatibility {
+	unacknowledged := []Incompatibility{}
+	for _, incompatibility := range incompatibilities {
+		if !util.IsAcknowledged(incompatibility.Key(), acknowledged, " ") {
+			unacknowledged = append(unacknowledged, incompatibility)
+		}
+	}
+	return unacknowledged
+}
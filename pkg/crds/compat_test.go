@@ -0,0 +1,137 @@
+package crds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compare(t *testing.T) {
+	schema := func() *Schema {
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"spec": {
+					Type:     "object",
+					Required: []string{"repo"},
+					Properties: map[string]*Schema{
+						"repo":  {Type: "string"},
+						"paths": {Type: "array", Items: &Schema{Type: "string"}},
+					},
+				},
+			},
+		}
+	}
+	gitRepo := func(modify func(c *CRD)) CRD {
+		c := CRD{
+			Name: "gitrepos.fleet.cattle.io",
+			Versions: []Version{
+				{Name: "v1alpha1", Served: true, Storage: true, Schema: schema()},
+				{Name: "v1", Served: true, Schema: schema()},
+			},
+		}
+		if modify != nil {
+			modify(&c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		old      []CRD
+		new      []CRD
+		expected []Incompatibility
+	}{
+		{
+			name: "#1 compatible: new optional property and new version",
+			old:  []CRD{gitRepo(nil)},
+			new: []CRD{gitRepo(func(c *CRD) {
+				c.Versions[1].Schema.Properties["spec"].Properties["branch"] = &Schema{Type: "string"}
+				c.Versions = append(c.Versions, Version{Name: "v2", Served: true})
+			})},
+			expected: []Incompatibility{},
+		},
+		{
+			name: "#2 removed CRD",
+			old:  []CRD{gitRepo(nil), {Name: "bundles.fleet.cattle.io"}},
+			new:  []CRD{gitRepo(nil)},
+			expected: []Incompatibility{
+				{CRD: "bundles.fleet.cattle.io", Rule: RemovedCRD, Message: "CRD bundles.fleet.cattle.io is removed"},
+			},
+		},
+		{
+			name: "#3 version no longer served and storage version changed",
+			old:  []CRD{gitRepo(nil)},
+			new: []CRD{gitRepo(func(c *CRD) {
+				c.Versions[0].Served = false
+				c.Versions[0].Storage = false
+				c.Versions[1].Storage = true
+			})},
+			expected: []Incompatibility{
+				{CRD: "gitrepos.fleet.cattle.io", Version: "v1alpha1", Rule: RemovedVersion, Message: "served version v1alpha1 is removed or no longer served"},
+				{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: StorageVersion, Message: "storage version changed from v1alpha1 to v1"},
+			},
+		},
+		{
+			name: "#4 removed, retyped and required properties",
+			old:  []CRD{gitRepo(nil)},
+			new: []CRD{gitRepo(func(c *CRD) {
+				spec := c.Versions[1].Schema.Properties["spec"]
+				delete(spec.Properties, "repo")
+				spec.Properties["paths"].Items = &Schema{Type: "object"}
+				spec.Required = append(spec.Required, "paths")
+			})},
+			expected: []Incompatibility{
+				{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: RequiredProperty, Path: ".spec.paths", Message: "paths became required"},
+				{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: RetypedProperty, Path: ".spec.paths[]", Message: "type changed from string to object"},
+				{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: RemovedProperty, Path: ".spec.repo", Message: "repo is removed"},
+			},
+		},
+		{
+			name: "#5 schema without properties does not constrain them",
+			old:  []CRD{gitRepo(nil)},
+			new: []CRD{gitRepo(func(c *CRD) {
+				c.Versions[1].Schema = &Schema{Type: "object"}
+			})},
+			expected: []Incompatibility{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Compare(tt.old, tt.new))
+		})
+	}
+}
+
+func Test_IncompatibilityString(t *testing.T) {
+	assert.Equal(t, "gitrepos.fleet.cattle.io v1 .spec.repo: repo is removed (removed-property)",
+		Incompatibility{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: RemovedProperty, Path: ".spec.repo", Message: "repo is removed"}.String())
+	assert.Equal(t, "bundles.fleet.cattle.io: CRD bundles.fleet.cattle.io is removed (removed-crd)",
+		Incompatibility{CRD: "bundles.fleet.cattle.io", Rule: RemovedCRD, Message: "CRD bundles.fleet.cattle.io is removed"}.String())
+}
+
+func Test_Unacknowledged(t *testing.T) {
+	incompatibilities := []Incompatibility{
+		{CRD: "bundles.fleet.cattle.io", Rule: RemovedCRD, Message: "CRD bundles.fleet.cattle.io is removed"},
+		{CRD: "gitrepos.fleet.cattle.io", Version: "v1alpha1", Rule: RemovedVersion, Message: "served version v1alpha1 is removed or no longer served"},
+		{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: RemovedProperty, Path: ".spec.repo", Message: "repo is removed"},
+	}
+
+	tests := []struct {
+		name         string
+		acknowledged []string
+		expected     []Incompatibility
+	}{
+		{"#1 nothing acknowledged", nil, incompatibilities},
+		{"#2 a CRD acknowledges all of its incompatibilities", []string{"gitrepos.fleet.cattle.io"}, incompatibilities[:1]},
+		{"#3 a version only acknowledges its own incompatibilities", []string{"gitrepos.fleet.cattle.io v1alpha1"}, []Incompatibility{incompatibilities[0], incompatibilities[2]}},
+		{"#4 exact keys", []string{"bundles.fleet.cattle.io", "gitrepos.fleet.cattle.io v1 .spec.repo"}, incompatibilities[1:2]},
+		{"#5 a name prefix is not a CRD", []string{"gitrepos.fleet"}, incompatibilities},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Unacknowledged(incompatibilities, tt.acknowledged))
+		})
+	}
+}
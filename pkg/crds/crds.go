@@ -0,0 +1,271 @@
+package crds
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// CRD is a CustomResourceDefinition (apiextensions.k8s.io/v1 or v1beta1) with the fields needed to compare and validate it
+type CRD struct {
+	Name     string
+	Group    string
+	Kind     string
+	Versions []Version
+	// File of the chart the CRD was loaded from
+	File string
+	// Document is the yaml document of the CRD
+	Document string
+}
+
+// Version is a version of a CRD with its OpenAPI v3 schema
+type Version struct {
+	Name    string
+	Served  bool
+	Storage bool
+	Schema  *Schema
+}
+
+// Schema is the subset of an OpenAPI v3 schema that is compared between CRD versions and validates custom resources
+type Schema struct {
+	Type                  string             `yaml:"type,omitempty"`
+	Properties            map[string]*Schema `yaml:"properties,omitempty"`
+	AdditionalProperties  *SchemaOrBool      `yaml:"additionalProperties,omitempty"`
+	Items                 *Schema            `yaml:"items,omitempty"`
+	Required              []string           `yaml:"required,omitempty"`
+	IntOrString           bool               `yaml:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool               `yaml:"x-kubernetes-preserve-unknown-fields,omitempty"`
+}
+
+// SchemaOrBool is an additionalProperties value, either a schema or a boolean allowing any additional property
+type SchemaOrBool struct {
+	Allows bool
+	Schema *Schema
+}
+
+// UnmarshalYAML decodes a schema or a boolean
+func (s *SchemaOrBool) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var allows bool
+	if err := unmarshal(&allows); err == nil {
+		s.Allows = allows
+		return nil
+	}
+	s.Allows = true
+	return unmarshal(&s.Schema)
+}
+
+// resource is the yaml representation of a CustomResourceDefinition of both apiextensions versions
+type resource struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+		// v1beta1 only
+		Version    string `yaml:"version"`
+		Validation *struct {
+			OpenAPIV3Schema *Schema `yaml:"openAPIV3Schema"`
+		} `yaml:"validation"`
+		Versions []struct {
+			Name    string `yaml:"name"`
+			Served  bool   `yaml:"served"`
+			Storage bool   `yaml:"storage"`
+			Schema  *struct {
+				OpenAPIV3Schema *Schema `yaml:"openAPIV3Schema"`
+			} `yaml:"schema"`
+		} `yaml:"versions"`
+	} `yaml:"spec"`
+}
+
+// templateLine matches a line holding only a template action, e.g. {{- if .Values.enabled }}
+var templateLine = regexp.MustCompile(`(?m)^\s*\{\{.*\}\}\s*$`)
+
+// documentSeparator splits a multi-document yaml file
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Parse returns the CustomResourceDefinitions of a multi-document yaml file.
+// Template actions on their own line are dropped, and documents that are still not valid yaml are skipped,
+// so CRDs wrapped in {{- if }} blocks in templates/ are found as well.
+func Parse(file string, data []byte) []CRD {
+	var crds []CRD
+
+	content := templateLine.ReplaceAllString(string(data), "")
+	for _, document := range documentSeparator.Split(content, -1) {
+		var r resource
+		if err := yaml.Unmarshal([]byte(document), &r); err != nil {
+			continue
+		}
+		if r.Kind != "CustomResourceDefinition" || !strings.HasPrefix(r.APIVersion, "apiextensions.k8s.io") || r.Metadata.Name == "" {
+			continue
+		}
+		crds = append(crds, r.toCRD(file, strings.TrimSpace(document)))
+	}
+	return crds
+}
+
+// Extract splits a template into the CustomResourceDefinitions that can be moved out of it and the remaining content.
+// A CRD is only extracted if no template action is left in it once the actions on their own line are dropped;
+// those lines stay in the remaining content so blocks such as {{- if }} ... {{- end }} remain balanced.
+// The remaining content is empty if only template actions and document separators are left.
+func Extract(file string, data []byte) ([]CRD, string) {
+	var extracted []CRD
+	var remaining []string
+	keep := false
+
+	for _, document := range documentSeparator.Split(string(data), -1) {
+		stripped := templateLine.ReplaceAllString(document, "")
+		if parsed := Parse(file, []byte(document)); len(parsed) == 1 && !strings.Contains(stripped, "{{") {
+			extracted = append(extracted, parsed[0])
+			var actions []string
+			for _, action := range templateLine.FindAllString(document, -1) {
+				actions = append(actions, strings.TrimSpace(action))
+			}
+			if len(actions) > 0 {
+				remaining = append(remaining, "\n"+strings.Join(actions, "\n")+"\n")
+			}
+			continue
+		}
+		if strings.TrimSpace(stripped) != "" {
+			keep = true
+		}
+		remaining = append(remaining, document)
+	}
+
+	if !keep {
+		return extracted, ""
+	}
+	return extracted, strings.Join(remaining, "---")
+}
+
+// toCRD converts the resource, applying the v1beta1 defaults: a single spec.version served and stored,
+// and spec.validation shared by the versions without their own schema
+func (r resource) toCRD(file, document string) CRD {
+	c := CRD{
+		Name:     r.Metadata.Name,
+		Group:    r.Spec.Group,
+		Kind:     r.Spec.Names.Kind,
+		File:     file,
+		Document: document,
+	}
+
+	var sharedSchema *Schema
+	if r.Spec.Validation != nil {
+		sharedSchema = r.Spec.Validation.OpenAPIV3Schema
+	}
+
+	if len(r.Spec.Versions) == 0 && r.Spec.Version != "" {
+		c.Versions = append(c.Versions, Version{Name: r.Spec.Version, Served: true, Storage: true, Schema: sharedSchema})
+	}
+	for _, v := range r.Spec.Versions {
+		version := Version{Name: v.Name, Served: v.Served, Storage: v.Storage, Schema: sharedSchema}
+		if v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+			version.Schema = v.Schema.OpenAPIV3Schema
+		}
+		c.Versions = append(c.Versions, version)
+	}
+	return c
+}
+
+// FromChart returns the CustomResourceDefinitions of a chart sorted by name,
+// found in crds/, in templates/ and in the files/crd-manifest.tgz archive of CRD charts using useTarArchive
+func FromChart(c *chart.Chart) ([]CRD, error) {
+	var crds []CRD
+
+	files := append(append([]*chart.File{}, c.Templates...), c.Files...)
+	for _, file := range files {
+		if file.Name == filepath.Join(path.ChartExtraFileDir, path.ChartCRDTgzFilename) {
+			archived, err := fromArchive(file.Name, file.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CRDs from %s: %w", file.Name, err)
+			}
+			crds = append(crds, archived...)
+			continue
+		}
+		if ext := filepath.Ext(file.Name); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		crds = append(crds, Parse(file.Name, file.Data)...)
+	}
+
+	sort.SliceStable(crds, func(i, j int) bool { return crds[i].Name < crds[j].Name })
+	return crds, nil
+}
+
+// fromArchive returns the CustomResourceDefinitions of the yaml files of a tgz archive
+func fromArchive(name string, data []byte) ([]CRD, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	var crds []CRD
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if ext := filepath.Ext(header.Name); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+		crds = append(crds, Parse(name+"/"+header.Name, content)...)
+	}
+	return crds, nil
+}
+
+// ServedVersions returns the names of the served versions of the CRD
+func (c CRD) ServedVersions() []string {
+	var served []string
+	for _, version := range c.Versions {
+		if version.Served {
+			served = append(served, version.Name)
+		}
+	}
+	return served
+}
+
+// StorageVersion returns the name of the storage version of the CRD, or "" if there is none
+func (c CRD) StorageVersion() string {
+	for _, version := range c.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
+
+// Version returns the version of the CRD with the given name
+func (c CRD) Version(name string) (Version, bool) {
+	for _, version := range c.Versions {
+		if version.Name == name {
+			return version, true
+		}
+	}
+	return Version{}, false
+}
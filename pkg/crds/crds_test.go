@@ -0,0 +1,166 @@
+package crds
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const v1CRD = `{{- if .Values.enabled }}
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gitrepos.fleet.cattle.io
+spec:
+  group: fleet.cattle.io
+  names:
+    kind: GitRepo
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+    schema:
+      openAPIV3Schema:
+        type: object
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [repo]
+            properties:
+              repo:
+                type: string
+{{- end }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: not-a-crd
+`
+
+const v1beta1CRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: bundles.fleet.cattle.io
+spec:
+  group: fleet.cattle.io
+  names:
+    kind: Bundle
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+`
+
+func Test_Parse(t *testing.T) {
+	t.Run("#1 v1 CRD in a template", func(t *testing.T) {
+		crds := Parse("templates/crds.yaml", []byte(v1CRD))
+		assert.Len(t, crds, 1)
+		assert.Equal(t, "gitrepos.fleet.cattle.io", crds[0].Name)
+		assert.Equal(t, "fleet.cattle.io", crds[0].Group)
+		assert.Equal(t, "GitRepo", crds[0].Kind)
+		assert.Equal(t, "templates/crds.yaml", crds[0].File)
+		assert.Equal(t, []string{"v1alpha1", "v1"}, crds[0].ServedVersions())
+		assert.Equal(t, "v1", crds[0].StorageVersion())
+		assert.Equal(t, &Schema{Type: "string"}, crds[0].Versions[1].Schema.Properties["spec"].Properties["repo"])
+	})
+
+	t.Run("#2 v1beta1 CRD with a single version", func(t *testing.T) {
+		crds := Parse("crds/bundles.yaml", []byte(v1beta1CRD))
+		assert.Len(t, crds, 1)
+		assert.Equal(t, []Version{{Name: "v1alpha1", Served: true, Storage: true, Schema: &Schema{Type: "object"}}}, crds[0].Versions)
+	})
+
+	t.Run("#3 not a CRD", func(t *testing.T) {
+		assert.Empty(t, Parse("templates/deployment.yaml", []byte("apiVersion: apps/v1\nkind: Deployment\n")))
+	})
+
+	t.Run("#4 additionalProperties schema or boolean", func(t *testing.T) {
+		crd := strings.Replace(v1beta1CRD, "      type: object\n", "      type: object\n      properties:\n        labels:\n          type: object\n          additionalProperties:\n            type: string\n        values:\n          type: object\n          additionalProperties: true\n        strict:\n          type: object\n          additionalProperties: false\n", 1)
+		crds := Parse("crds/bundles.yaml", []byte(crd))
+		assert.Len(t, crds, 1)
+		properties := crds[0].Versions[0].Schema.Properties
+		assert.Equal(t, &SchemaOrBool{Allows: true, Schema: &Schema{Type: "string"}}, properties["labels"].AdditionalProperties)
+		assert.Equal(t, &SchemaOrBool{Allows: true}, properties["values"].AdditionalProperties)
+		assert.Equal(t, &SchemaOrBool{Allows: false}, properties["strict"].AdditionalProperties)
+	})
+}
+
+func Test_FromChart(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	assert.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "crd-manifest/bundles.yaml", Mode: 0644, Size: int64(len(v1beta1CRD)), Typeflag: tar.TypeReg}))
+	_, err := tarWriter.Write([]byte(v1beta1CRD))
+	assert.NoError(t, err)
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+
+	c := &chart.Chart{
+		Templates: []*chart.File{{Name: "templates/crds.yaml", Data: []byte(v1CRD)}},
+		Files: []*chart.File{
+			{Name: "files/crd-manifest.tgz", Data: archive.Bytes()},
+			{Name: "README.md", Data: []byte("kind: CustomResourceDefinition")},
+		},
+	}
+
+	crds, err := FromChart(c)
+	assert.NoError(t, err)
+	assert.Len(t, crds, 2)
+	assert.Equal(t, "bundles.fleet.cattle.io", crds[0].Name)
+	assert.Equal(t, "files/crd-manifest.tgz/crd-manifest/bundles.yaml", crds[0].File)
+	assert.Equal(t, "gitrepos.fleet.cattle.io", crds[1].Name)
+}
+
+func Test_Extract(t *testing.T) {
+	const configMap = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"
+
+	tests := []struct {
+		name      string
+		data      string
+		extracted []string
+		remaining string
+	}{
+		{
+			name:      "#1 template holding only CRDs",
+			data:      "{{- if .Values.installCRDs }}\n" + v1beta1CRD + "---\n" + v1beta1CRD + "{{- end }}\n",
+			extracted: []string{"bundles.fleet.cattle.io", "bundles.fleet.cattle.io"},
+			remaining: "",
+		},
+		{
+			name:      "#2 template actions around the CRD are kept with the other resources",
+			data:      "{{- if .Values.installCRDs }}\n" + v1beta1CRD + "{{- end }}\n---\n" + configMap,
+			extracted: []string{"bundles.fleet.cattle.io"},
+			remaining: "\n{{- if .Values.installCRDs }}\n{{- end }}\n---\n" + configMap,
+		},
+		{
+			name:      "#3 CRD using template actions is not extracted",
+			data:      strings.Replace(v1beta1CRD, "  name: bundles.fleet.cattle.io\n", "  name: bundles.fleet.cattle.io\n  labels: {{ include \"labels\" . }}\n", 1),
+			extracted: nil,
+			remaining: strings.Replace(v1beta1CRD, "  name: bundles.fleet.cattle.io\n", "  name: bundles.fleet.cattle.io\n  labels: {{ include \"labels\" . }}\n", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extracted, remaining := Extract("templates/crds.yaml", []byte(tt.data))
+			var names []string
+			for _, crd := range extracted {
+				names = append(names, crd.Name)
+				assert.NotContains(t, crd.Document, "{{")
+			}
+			assert.Equal(t, tt.extracted, names)
+			assert.Equal(t, tt.remaining, remaining)
+		})
+	}
+}
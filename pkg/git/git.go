@@ -18,8 +18,14 @@ type Git struct {
 	Dir     string
 	Branch  string
 	Remotes map[string]string
+	// UpstreamURL is the URL of the upstream charts repository,
+	// when empty https://github.com/rancher/charts is assumed.
+	UpstreamURL string
 }
 
+// DefaultUpstreamURL is the upstream charts repository used when none is configured
+const DefaultUpstreamURL = "https://github.com/rancher/charts"
+
 // GitRepo uses singleton pattern to access a local git repository
 var GitRepo *Git
 
@@ -166,7 +172,7 @@ func (g *Git) getGitRemotes(ctx context.Context) (map[string]string, error) {
 func (g *Git) FetchAndPullBranch(ctx context.Context, branch string) error {
 	logger.Log(ctx, slog.LevelInfo, "fetching and pulling branch", slog.String("branch", branch))
 
-	upstreamRemote, err := g.getUpstreamRemote()
+	upstreamRemote, err := g.UpstreamRemote()
 	if err != nil {
 		return err
 	}
@@ -199,7 +205,7 @@ func (g *Git) FetchAndCheckoutBranch(ctx context.Context, branch string) error {
 
 // FetchBranch fetches a branch
 func (g *Git) FetchBranch(branch string) error {
-	upstreamRemote, err := g.getUpstreamRemote()
+	upstreamRemote, err := g.UpstreamRemote()
 	if err != nil {
 		return err
 	}
@@ -210,6 +216,17 @@ func (g *Git) FetchBranch(branch string) error {
 	return cmd.Run()
 }
 
+// FetchRemoteBranch fetches a branch from the upstream remote, only updating its remote-tracking branch
+// ex: git fetch <remote> <branch>
+func (g *Git) FetchRemoteBranch(branch string) error {
+	upstreamRemote, err := g.UpstreamRemote()
+	if err != nil {
+		return err
+	}
+
+	return exec.Command("git", "-C", g.Dir, "fetch", upstreamRemote, branch).Run()
+}
+
 // CheckoutBranch checks out a branch
 func (g *Git) CheckoutBranch(branch string) error {
 	cmd := exec.Command("git", "-C", g.Dir, "checkout", branch)
@@ -221,7 +238,7 @@ func (g *Git) CheckoutBranch(branch string) error {
 // CheckoutFile checks out a file in a branch
 // ex: git checkout <remote>/<branch> -- <file>
 func (g *Git) CheckoutFile(branch, file string) error {
-	upstreamRemote, err := g.getUpstreamRemote()
+	upstreamRemote, err := g.UpstreamRemote()
 	if err != nil {
 		return err
 	}
@@ -233,6 +250,18 @@ func (g *Git) CheckoutFile(branch, file string) error {
 	return cmd.Run()
 }
 
+// ShowFile returns the content of a file in a remote branch without checking it out
+// ex: git show <remote>/<branch>:<file>
+func (g *Git) ShowFile(branch, file string) ([]byte, error) {
+	upstreamRemote, err := g.UpstreamRemote()
+	if err != nil {
+		return nil, err
+	}
+
+	target := upstreamRemote + "/" + branch + ":" + file
+	return exec.Command("git", "-C", g.Dir, "show", target).Output()
+}
+
 // CreateAndCheckoutBranch creates and checks out to a given branch.
 // Equivalent to: git checkout -b <branch>
 func (g *Git) CreateAndCheckoutBranch(branch string) error {
@@ -289,7 +318,7 @@ func (g *Git) AddAndCommit(message string) error {
 	}
 
 	// Commit the staged changes
-	cmd2 := exec.Command("git", "commit", "-m", message)
+	cmd2 := exec.Command("git", "-C", g.Dir, "commit", "-m", message)
 	cmd2.Stdout = os.Stdout
 	cmd2.Stderr = os.Stderr
 	return cmd2.Run()
@@ -310,7 +339,7 @@ func (g *Git) DeleteBranch(branch string) error {
 
 // CheckFileExists checks if a file exists in the git repository for a specific branch
 func (g *Git) CheckFileExists(file, branch string) error {
-	upstreamRemote, err := g.getUpstreamRemote()
+	upstreamRemote, err := g.UpstreamRemote()
 	if err != nil {
 		return err
 	}
@@ -319,6 +348,20 @@ func (g *Git) CheckFileExists(file, branch string) error {
 	return exec.Command("git", "-C", g.Dir, "cat-file", "-e", target).Run()
 }
 
+// BranchExists checks if a local branch exists
+func (g *Git) BranchExists(branch string) bool {
+	return exec.Command("git", "-C", g.Dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
+// HeadCommit returns the commit hash of HEAD
+func (g *Git) HeadCommit() (string, error) {
+	output, err := exec.Command("git", "-C", g.Dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 // FullReset performs a hard reset, cleans the repository and restores it
 func (g *Git) FullReset() error {
 	if err := g.HardHEADReset(); err != nil {
@@ -335,11 +378,21 @@ func (g *Git) HardHEADReset() error {
 	return exec.Command("git", "-C", g.Dir, "reset", "--hard", "HEAD").Run()
 }
 
+// ResetHardTo = git reset --hard <commit>
+func (g *Git) ResetHardTo(commit string) error {
+	return exec.Command("git", "-C", g.Dir, "reset", "--hard", commit).Run()
+}
+
 // ForceClean = git clean -fdx
 func (g *Git) ForceClean() error {
 	return exec.Command("git", "-C", g.Dir, "clean", "-fdx").Run()
 }
 
+// CleanUntracked removes untracked files and directories, keeping the ignored ones
+func (g *Git) CleanUntracked() error {
+	return exec.Command("git", "-C", g.Dir, "clean", "-fd").Run()
+}
+
 // Restore = git restore .
 func (g *Git) Restore() error {
 	return exec.Command("git", "-C", g.Dir, "restore", ".").Run()
@@ -351,17 +404,20 @@ func (g *Git) ResetHEAD() error {
 	return exec.Command("git", "-C", g.Dir, "reset", "HEAD").Run()
 }
 
-func (g *Git) getUpstreamRemote() (string, error) {
-	upstreamRemote := g.Remotes["https://github.com/rancher/charts"]
-	if upstreamRemote == "" {
-		upstreamRemote = g.Remotes["git@github.com:rancher/charts.git"]
+// UpstreamRemote returns the name of the git remote pointing to the upstream charts repository
+func (g *Git) UpstreamRemote() (string, error) {
+	upstreamURLs := []string{DefaultUpstreamURL, "git@github.com:rancher/charts.git"}
+	if g.UpstreamURL != "" && g.UpstreamURL != DefaultUpstreamURL {
+		upstreamURLs = []string{g.UpstreamURL, g.UpstreamURL + ".git"}
 	}
 
-	if upstreamRemote == "" {
-		return "", errors.New("upstream remote not configured")
+	for _, upstreamURL := range upstreamURLs {
+		if upstreamRemote := g.Remotes[upstreamURL]; upstreamRemote != "" {
+			return upstreamRemote, nil
+		}
 	}
 
-	return upstreamRemote, nil
+	return "", errors.New("upstream remote not configured")
 }
 
 // Status prints the status of the git repository
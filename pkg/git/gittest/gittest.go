@@ -0,0 +1,59 @@
+// Package gittest creates local git repositories for tests, so the git helpers can be tested without a remote.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Run runs a git command at dir with a fixed identity and returns its output, failing the test on error
+func Run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.email=test@example.com", "-c", "user.name=test"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+// NewRepo initializes a git repository at dir on the given branch, with the files committed and a fixed identity configured
+func NewRepo(t *testing.T, dir, branch string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	Run(t, dir, "init", "-b", branch)
+	Run(t, dir, "config", "user.email", "test@example.com")
+	Run(t, dir, "config", "user.name", "test")
+	Commit(t, dir, "initial commit", files)
+}
+
+// Commit writes the files, keyed by their path relative to dir, and commits every change of the working tree
+func Commit(t *testing.T, dir, message string, files map[string]string) {
+	t.Helper()
+	for file, content := range files {
+		absPath := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(absPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Run(t, dir, "add", "-A")
+	Run(t, dir, "commit", "--allow-empty", "-m", message)
+}
+
+// Clone clones src at dst with src as the upstream remote, checked out at the given branch
+func Clone(t *testing.T, src, dst, branch string) {
+	t.Helper()
+	out, err := exec.Command("git", "clone", "-o", "upstream", "-b", branch, src, dst).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+	Run(t, dst, "config", "user.email", "test@example.com")
+	Run(t, dst, "config", "user.name", "test")
+}
@@ -5,12 +5,22 @@ import (
 )
 
 // CheckForValidForkRemote checks if the remote URL is a valid remote fork for the upstream URL.
+// Both URLs must be hosted on the same server (e.g. https://github.com) and point to the same repository name.
 func CheckForValidForkRemote(upstreamURL, remoteURL, repo string) bool {
 	if remoteURL == "" {
 		return false // Remote URL is empty
 	}
 	urlPart1, urlPart2 := extractCommonParts(upstreamURL, remoteURL)
-	return urlPart1 == "https://github.com" && urlPart2 == repo
+	return urlPart1 == extractHost(upstreamURL) && urlPart2 == repo
+}
+
+// extractHost returns the scheme and host of a Git URL (e.g. https://github.com)
+func extractHost(url string) string {
+	segments := strings.Split(url, "/")
+	if len(segments) < 3 {
+		return ""
+	}
+	return strings.Join(segments[:3], "/")
 }
 
 // extractCommonParts takes two Git URLs and returns the common prefix and suffix.
@@ -59,7 +59,16 @@ func Test_CheckForValidForkRemote(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "#6 - Fail",
+			name: "#6 - Success GitHub Enterprise",
+			args: args{
+				upstreamURL: "https://git.mirror.io/rancher/charts",
+				remoteURL:   "https://git.mirror.io/user/charts",
+				repo:        "charts",
+			},
+			want: true,
+		},
+		{
+			name: "#7 - Fail",
 			args: args{
 				upstreamURL: "https://github.com/rancher/charts",
 				remoteURL:   "",
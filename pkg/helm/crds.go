@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
@@ -77,3 +78,58 @@ func ArchiveCRDs(ctx context.Context, fs billy.Filesystem, srcHelmChartPath, src
 	logger.Log(ctx, slog.LevelDebug, "compressing CRDs", slog.String("srcCRDsDirPath", srcCRDsDirPath), slog.String("dstFilePath", dstFilePath))
 	return filesystem.ArchiveDir(ctx, fs, srcCRDsDirPath, dstFilePath)
 }
+
+// ExtractCRDsFromTemplates moves the CRDs embedded in the templates of a chart to its crds directory,
+// one file per CRD, and removes the templates that are left without any resource.
+// CRDs that still hold template actions, e.g. labels rendered from values, are left in the templates.
+func ExtractCRDsFromTemplates(ctx context.Context, fs billy.Filesystem, helmChartPath string) error {
+	templatesDirpath := filepath.Join(helmChartPath, path.ChartTemplatesDir)
+	exists, err := filesystem.PathExists(ctx, fs, templatesDirpath)
+	if err != nil {
+		return fmt.Errorf("error checking if templates directory exists: %s", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	return filesystem.WalkDir(ctx, fs, templatesDirpath, func(ctx context.Context, fs billy.Filesystem, templatePath string, isDir bool) error {
+		if isDir {
+			return nil
+		}
+		if ext := filepath.Ext(templatePath); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := os.ReadFile(filesystem.GetAbsPath(fs, templatePath))
+		if err != nil {
+			return fmt.Errorf("unable to read file %s: %s", templatePath, err)
+		}
+		extracted, remaining := crds.Extract(templatePath, data)
+		if len(extracted) == 0 {
+			return nil
+		}
+
+		crdsDirpath := filepath.Join(helmChartPath, path.ChartCRDDir)
+		if err := fs.MkdirAll(crdsDirpath, os.ModePerm); err != nil {
+			return err
+		}
+		for _, crd := range extracted {
+			crdFilepath := filepath.Join(crdsDirpath, crd.Name+".yaml")
+			logger.Log(ctx, slog.LevelInfo, "extracting CRD from template", slog.String("template", templatePath), slog.String("crdFilepath", crdFilepath))
+			if err := os.WriteFile(filesystem.GetAbsPath(fs, crdFilepath), []byte(crd.Document+"\n"), os.ModePerm); err != nil {
+				return fmt.Errorf("encountered error while writing into %s: %s", crdFilepath, err)
+			}
+		}
+		if left := crds.Parse(templatePath, []byte(remaining)); len(left) > 0 {
+			logger.Log(ctx, slog.LevelWarn, "CRDs using template actions are left in the main chart", slog.String("template", templatePath), slog.Int("count", len(left)))
+		}
+
+		if remaining == "" {
+			logger.Log(ctx, slog.LevelDebug, "deleting template left without resources", slog.String("template", templatePath))
+			if err := fs.Remove(templatePath); err != nil {
+				return err
+			}
+			return filesystem.PruneEmptyDirsInPath(ctx, fs, templatePath)
+		}
+		return os.WriteFile(filesystem.GetAbsPath(fs, templatePath), []byte(remaining), os.ModePerm)
+	})
+}
@@ -0,0 +1,48 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExtractCRDsFromTemplates(t *testing.T) {
+	util.InitSoftErrorMode()
+
+	bundles := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: bundles.fleet.cattle.io\nspec:\n  group: fleet.cattle.io\n  names:\n    kind: Bundle\n  versions:\n  - name: v1alpha1\n    served: true\n    storage: true\n"
+	gitrepos := "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: gitrepos.fleet.cattle.io\nspec:\n  group: fleet.cattle.io\n  names:\n    kind: GitRepo\n  versions:\n  - name: v1alpha1\n    served: true\n    storage: true\n"
+	deployment := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet-controller\n"
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":                  "apiVersion: v2\nname: fleet\nversion: 0.11.0\n",
+		"templates/crds/bundles.yaml": "{{- if .Values.installCRDs }}\n" + bundles + "{{- end }}\n",
+		"templates/deployment.yaml":   deployment + "---\n" + gitrepos,
+		"templates/_helpers.tpl":      "{{- define \"fleet.name\" -}}fleet{{- end }}\n",
+		"templates/labelled-crd.yaml": strings.Replace(gitrepos, "metadata:\n", "metadata:\n  labels: {{ include \"fleet.labels\" . }}\n", 1),
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, "charts", file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts", file), []byte(content), 0644))
+	}
+
+	assert.NoError(t, ExtractCRDsFromTemplates(context.Background(), filesystem.GetFilesystem(dir), "charts"))
+
+	read := func(file string) string {
+		content, err := os.ReadFile(filepath.Join(dir, "charts", file))
+		assert.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, bundles, read("crds/bundles.fleet.cattle.io.yaml"))
+	assert.Equal(t, gitrepos, read("crds/gitrepos.fleet.cattle.io.yaml"))
+	assert.Equal(t, deployment, read("templates/deployment.yaml"))
+	assert.Equal(t, files["templates/labelled-crd.yaml"], read("templates/labelled-crd.yaml"))
+	assert.Equal(t, files["templates/_helpers.tpl"], read("templates/_helpers.tpl"))
+	assert.NoDirExists(t, filepath.Join(dir, "charts", "templates", "crds"))
+}
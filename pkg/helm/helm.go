@@ -1,26 +1,39 @@
 package helm
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"reflect"
 	"sort"
-	"strconv"
-	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/go-git/go-billy/v5"
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 	helmRepo "helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
 )
 
-// CreateOrUpdateHelmIndex either creates or updates the index.yaml for the repository this package is within
+// CreateOrUpdateHelmIndex either creates or updates the index.yaml for the repository this package is within.
+// Only the archives of assets/ that changed since the existing index.yaml are loaded, see IndexAssets.
 func CreateOrUpdateHelmIndex(ctx context.Context, rootFs billy.Filesystem) error {
-	absRepositoryAssetsDir := filesystem.GetAbsPath(rootFs, path.RepositoryAssetsDir)
+	return createOrUpdateHelmIndex(ctx, rootFs, false)
+}
+
+// RebuildHelmIndex creates or updates the index.yaml like CreateOrUpdateHelmIndex, but loads every archive of assets/
+// again instead of trusting the existing entries, e.g. to verify that an incrementally updated index.yaml is accurate
+func RebuildHelmIndex(ctx context.Context, rootFs billy.Filesystem) error {
+	return createOrUpdateHelmIndex(ctx, rootFs, true)
+}
+
+func createOrUpdateHelmIndex(ctx context.Context, rootFs billy.Filesystem, fullRebuild bool) error {
 	absRepositoryHelmIndexFile := filesystem.GetAbsPath(rootFs, path.RepositoryHelmIndexFile)
 
 	var helmIndexFile *helmRepo.IndexFile
@@ -39,7 +52,7 @@ func CreateOrUpdateHelmIndex(ctx context.Context, rootFs billy.Filesystem) error
 	}
 
 	// Generate the current index file from the assets/ directory
-	newHelmIndexFile, err := helmRepo.IndexDirectory(absRepositoryAssetsDir, path.RepositoryAssetsDir)
+	newHelmIndexFile, err := IndexAssets(ctx, rootFs, helmIndexFile, fullRebuild)
 	if err != nil {
 		return fmt.Errorf("encountered error while trying to generate new Helm index: %s", err)
 	}
@@ -48,8 +61,23 @@ func CreateOrUpdateHelmIndex(ctx context.Context, rootFs billy.Filesystem) error
 	SortVersions(helmIndexFile)
 	SortVersions(newHelmIndexFile)
 
+	// Entries of unchanged archives whose metadata differs from the archive, and charts with duplicate or unsorted versions,
+	// are only found by a full rebuild
+	stale := 0
+	if fullRebuild {
+		stale = staleEntries(ctx, helmIndexFile, newHelmIndexFile)
+		if exists {
+			unordered, err := unorderedEntries(ctx, absRepositoryHelmIndexFile)
+			if err != nil {
+				return err
+			}
+			stale += unordered
+		}
+	}
+
 	// Update index
 	helmIndexFile, upToDate := UpdateIndex(ctx, helmIndexFile, newHelmIndexFile)
+	upToDate = upToDate && stale == 0
 
 	if upToDate {
 		logger.Log(ctx, slog.LevelInfo, "index.yaml is up-to-date")
@@ -113,11 +141,96 @@ func UpdateIndex(ctx context.Context, original, new *helmRepo.IndexFile) (*helmR
 		}
 	}
 
-	// Sort one more time for safety
-	new.SortEntries()
+	// Sort one more time for safety, with the RC handling of SortVersions
+	SortVersions(new)
 	return new, upToDate
 }
 
+// staleEntries returns the number of entries of the original index with the same digest as in the new index, but different metadata or URLs
+func staleEntries(ctx context.Context, original, new *helmRepo.IndexFile) int {
+	originalEntries := make(map[string]*helmRepo.ChartVersion)
+	for chartName, chartVersions := range original.Entries {
+		for _, chartVersion := range chartVersions {
+			originalEntries[chartName+"/"+chartVersion.Version] = chartVersion
+		}
+	}
+
+	stale := 0
+	for chartName, chartVersions := range new.Entries {
+		for _, chartVersion := range chartVersions {
+			originalChartVersion, ok := originalEntries[chartName+"/"+chartVersion.Version]
+			if !ok || originalChartVersion.Digest != chartVersion.Digest {
+				continue
+			}
+			if !SameIndexValue(originalChartVersion.Metadata, chartVersion.Metadata) || !reflect.DeepEqual(originalChartVersion.URLs, chartVersion.URLs) {
+				logger.Log(ctx, slog.LevelWarn, "index.yaml entry does not match its archive", slog.String("chartName", chartName), slog.String("version", chartVersion.Version))
+				stale++
+			}
+		}
+	}
+	return stale
+}
+
+// unorderedEntries returns the number of charts of the index file with duplicate versions or versions not sorted like SortVersions
+func unorderedEntries(ctx context.Context, indexFile string) (int, error) {
+	index, err := LoadIndexFileAsIs(indexFile)
+	if err != nil {
+		return 0, err
+	}
+
+	unordered := 0
+	for chartName, chartVersions := range index.Entries {
+		if duplicates, sorted := CheckVersionsOrder(chartVersions); len(duplicates) > 0 || !sorted {
+			logger.Log(ctx, slog.LevelWarn, "index.yaml chart has duplicate or unsorted versions", slog.String("chartName", chartName))
+			unordered++
+		}
+	}
+	return unordered, nil
+}
+
+// LoadIndexFileAsIs reads an index file without sorting its entries or dropping the invalid ones like helmRepo.LoadIndexFile does
+func LoadIndexFileAsIs(indexFile string) (*helmRepo.IndexFile, error) {
+	data, err := os.ReadFile(indexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", indexFile, err)
+	}
+	index := &helmRepo.IndexFile{}
+	if err := yaml.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", indexFile, err)
+	}
+	return index, nil
+}
+
+// CheckVersionsOrder returns the versions of a chart repeated in an index file, once per repetition, and whether its versions
+// are sorted like SortVersions sorts them. Empty entries are ignored.
+func CheckVersionsOrder(chartVersions helmRepo.ChartVersions) ([]string, bool) {
+	var versions helmRepo.ChartVersions
+	var duplicates []string
+	seen := make(map[string]bool)
+	for _, chartVersion := range chartVersions {
+		if chartVersion == nil || chartVersion.Metadata == nil {
+			continue
+		}
+		if seen[chartVersion.Version] {
+			duplicates = append(duplicates, chartVersion.Version)
+		}
+		seen[chartVersion.Version] = true
+		versions = append(versions, chartVersion)
+	}
+	return duplicates, VersionsSorted(versions)
+}
+
+// SameIndexValue compares two values as written in an index file, so unset and empty values are equal
+func SameIndexValue(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	empty := func(data []byte) bool { s := string(data); return s == "null" || s == "{}" || s == "[]" }
+	return bytes.Equal(aJSON, bJSON) || (empty(aJSON) && empty(bJSON))
+}
+
 // OpenIndexYaml will check and open the index.yaml file in the local repository at the default file path
 func OpenIndexYaml(ctx context.Context, rootFs billy.Filesystem) (*helmRepo.IndexFile, error) {
 	helmIndexFilePath := filesystem.GetAbsPath(rootFs, path.RepositoryHelmIndexFile)
@@ -142,12 +255,26 @@ func SortVersions(index *helmRepo.IndexFile) {
 	}
 }
 
+// VersionsSorted returns whether the chart versions are in the order SortVersions sorts them
+func VersionsSorted(versions helmRepo.ChartVersions) bool {
+	return sort.SliceIsSorted(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version)
+	})
+}
+
 // compareVersions compares two version strings for sorting
 // Returns true if versionA should come before versionB (descending order)
 func compareVersions(versionA, versionB string) bool {
 	// Parse both versions
-	baseA, rcA, isRCA := parseVersionWithRC(versionA)
-	baseB, rcB, isRCB := parseVersionWithRC(versionB)
+	rcA, isRCA := prerelease.Default().Parse(versionA)
+	rcB, isRCB := prerelease.Default().Parse(versionB)
+	baseA, baseB := versionA, versionB
+	if isRCA {
+		baseA = rcA.Base
+	}
+	if isRCB {
+		baseB = rcB.Base
+	}
 
 	// Parse base versions using semver
 	semverA, errA := semver.NewVersion(baseA)
@@ -174,43 +301,14 @@ func compareVersions(versionA, versionB string) bool {
 		return false // A is RC, B is stable - B comes first
 	}
 
-	// Both are RCs - higher RC number comes first (descending)
+	// Both are RCs - higher pre-release identifier and number comes first (descending)
 	if isRCA && isRCB {
-		return rcA > rcB
+		if rcA.Identifier != rcB.Identifier {
+			return rcA.Identifier > rcB.Identifier
+		}
+		return rcA.Number > rcB.Number
 	}
 
 	// Both are stable with same base version - they're equal
 	return false
 }
-
-// parseVersionWithRC extracts the base version and RC number from a version string
-// Example: "108.0.0+up0.9.0-rc.1" returns ("108.0.0+up0.9.0", 1, true)
-func parseVersionWithRC(version string) (baseVersion string, rcNumber int, isRC bool) {
-	// Split by '+' to separate version from build metadata
-	parts := strings.Split(version, "+")
-	if len(parts) != 2 {
-		return version, 0, false
-	}
-
-	baseVersionNum := parts[0]
-	buildMetadata := parts[1]
-
-	// Check if build metadata contains RC
-	if !strings.Contains(buildMetadata, "-rc.") {
-		return version, 0, false
-	}
-
-	// Extract RC number
-	rcParts := strings.Split(buildMetadata, "-rc.")
-	if len(rcParts) != 2 {
-		return version, 0, false
-	}
-
-	rcNum, err := strconv.Atoi(rcParts[1])
-	if err != nil {
-		return version, 0, false
-	}
-
-	// Return base version with the non-RC part of build metadata
-	return baseVersionNum + "+" + rcParts[0], rcNum, true
-}
@@ -1,6 +1,7 @@
 package helm
 
 import (
+	"reflect"
 	"testing"
 
 	helmRepo "helm.sh/helm/v3/pkg/repo"
@@ -95,3 +96,32 @@ func TestSortVersions(t *testing.T) {
 		})
 	}
 }
+
+func Test_CheckVersionsOrder(t *testing.T) {
+	entry := func(version string) *helmRepo.ChartVersion {
+		return &helmRepo.ChartVersion{Metadata: &chart.Metadata{Version: version}}
+	}
+
+	tests := []struct {
+		name               string
+		input              helmRepo.ChartVersions
+		expectedDuplicates []string
+		expectedSorted     bool
+	}{
+		{"#1 sorted", helmRepo.ChartVersions{entry("108.0.1+up0.9.1"), entry("108.0.1+up0.9.1-rc.1"), entry("108.0.0+up0.9.0")}, nil, true},
+		{"#2 unsorted", helmRepo.ChartVersions{entry("108.0.0+up0.9.0"), entry("108.0.1+up0.9.1")}, nil, false},
+		{"#3 duplicates", helmRepo.ChartVersions{entry("108.0.1+up0.9.1"), entry("108.0.1+up0.9.1"), entry("108.0.1+up0.9.1"), entry("108.0.0+up0.9.0")}, []string{"108.0.1+up0.9.1", "108.0.1+up0.9.1"}, true},
+		{"#4 empty entries are ignored", helmRepo.ChartVersions{entry("108.0.1+up0.9.1"), nil, {}, entry("108.0.0+up0.9.0")}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicates, sorted := CheckVersionsOrder(tt.input)
+			if !reflect.DeepEqual(duplicates, tt.expectedDuplicates) {
+				t.Errorf("duplicates = %v, expected %v", duplicates, tt.expectedDuplicates)
+			}
+			if sorted != tt.expectedSorted {
+				t.Errorf("sorted = %v, expected %v", sorted, tt.expectedSorted)
+			}
+		})
+	}
+}
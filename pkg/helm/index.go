@@ -0,0 +1,148 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+// archiveStat is the size, modification time and digest of an archive the last time it was indexed
+type archiveStat struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Digest  string    `json:"digest"`
+}
+
+// IndexAssets generates the index of the archives in assets/, like helmRepo.IndexDirectory does, without loading the archives
+// that did not change since the existing index was generated. An archive is unchanged if its size and modification time match
+// the ones cached in .charts-build-scripts, or else if its digest matches the digest of the existing entry at its URL; the
+// existing entry is then reused as-is. With fullRebuild, every archive is hashed and loaded again.
+func IndexAssets(ctx context.Context, rootFs billy.Filesystem, existing *helmRepo.IndexFile, fullRebuild bool) (*helmRepo.IndexFile, error) {
+	archives, err := AssetArchives(rootFs)
+	if err != nil {
+		return nil, err
+	}
+
+	existingEntries := make(map[string]*helmRepo.ChartVersion)
+	for _, chartVersions := range existing.Entries {
+		for _, chartVersion := range chartVersions {
+			if len(chartVersion.URLs) > 0 {
+				existingEntries[chartVersion.URLs[0]] = chartVersion
+			}
+		}
+	}
+	cache := loadIndexCache(ctx, rootFs)
+	if fullRebuild {
+		cache = map[string]archiveStat{}
+	}
+
+	index := helmRepo.NewIndexFile()
+	newCache := make(map[string]archiveStat, len(archives))
+	loaded := 0
+	for _, url := range archives {
+		archive := filesystem.GetAbsPath(rootFs, url)
+		info, err := os.Stat(archive)
+		if err != nil {
+			return nil, err
+		}
+		stat := archiveStat{Size: info.Size(), ModTime: info.ModTime()}
+		if cached, ok := cache[url]; ok && cached.Size == stat.Size && cached.ModTime.Equal(stat.ModTime) {
+			stat.Digest = cached.Digest
+		} else if stat.Digest, err = provenance.DigestFile(archive); err != nil {
+			return nil, err
+		}
+		newCache[url] = stat
+
+		if entry, ok := existingEntries[url]; ok && !fullRebuild && entry.Digest == stat.Digest {
+			index.Entries[entry.Name] = append(index.Entries[entry.Name], entry)
+			continue
+		}
+
+		logger.Log(ctx, slog.LevelDebug, "indexing archive", slog.String("url", url))
+		c, err := helmLoader.Load(archive)
+		if err != nil {
+			// like helmRepo.IndexDirectory, assume this is not a chart
+			logger.Log(ctx, slog.LevelDebug, "skipping archive that is not a chart", slog.String("url", url), logger.Err(err))
+			continue
+		}
+		loaded++
+		if err := index.MustAdd(c.Metadata, filepath.Base(url), filepath.Dir(url), stat.Digest); err != nil {
+			return nil, fmt.Errorf("failed adding %s to index: %w", url, err)
+		}
+	}
+	logger.Log(ctx, slog.LevelDebug, "indexed assets", slog.Int("archives", len(archives)), slog.Int("loaded", loaded))
+
+	writeIndexCache(ctx, rootFs, newCache)
+	return index, nil
+}
+
+// AssetArchives returns the URLs in index.yaml of the archives of assets/ and of its chart directories, sorted,
+// e.g. assets/fleet/fleet-105.0.0+up0.11.0.tgz
+func AssetArchives(rootFs billy.Filesystem) ([]string, error) {
+	absRepositoryAssetsDir := filesystem.GetAbsPath(rootFs, path.RepositoryAssetsDir)
+	archives, err := filepath.Glob(filepath.Join(absRepositoryAssetsDir, "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	moreArchives, err := filepath.Glob(filepath.Join(absRepositoryAssetsDir, "*", "*.tgz"))
+	if err != nil {
+		return nil, err
+	}
+	archives = append(archives, moreArchives...)
+
+	urls := make([]string, 0, len(archives))
+	for _, archive := range archives {
+		rel, err := filepath.Rel(absRepositoryAssetsDir, archive)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, filepath.ToSlash(filepath.Join(path.RepositoryAssetsDir, rel)))
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
+
+// loadIndexCache returns the archives stats cached by the last index generation, or none if the cache cannot be read
+func loadIndexCache(ctx context.Context, rootFs billy.Filesystem) map[string]archiveStat {
+	cache := map[string]archiveStat{}
+	data, err := os.ReadFile(filesystem.GetAbsPath(rootFs, path.IndexCacheFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Log(ctx, slog.LevelWarn, "failed to read index cache", logger.Err(err))
+		}
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		logger.Log(ctx, slog.LevelWarn, "ignoring invalid index cache", logger.Err(err))
+		return map[string]archiveStat{}
+	}
+	return cache
+}
+
+// writeIndexCache caches the archives stats for the next index generation; failing to do so only makes it slower
+func writeIndexCache(ctx context.Context, rootFs billy.Filesystem, cache map[string]archiveStat) {
+	absIndexCacheFile := filesystem.GetAbsPath(rootFs, path.IndexCacheFile)
+	data, err := json.Marshal(cache)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(absIndexCacheFile), os.ModePerm)
+	}
+	if err == nil {
+		err = os.WriteFile(absIndexCacheFile, data, 0644)
+	}
+	if err != nil {
+		logger.Log(ctx, slog.LevelWarn, "failed to write index cache", logger.Err(err))
+	}
+}
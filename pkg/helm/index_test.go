@@ -0,0 +1,137 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+func saveAsset(t *testing.T, repoRoot, name, version, appVersion string) string {
+	t.Helper()
+	c := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version, AppVersion: appVersion}}
+	archive, err := chartutil.Save(c, filepath.Join(repoRoot, "assets", name))
+	assert.NoError(t, err)
+	return archive
+}
+
+func Test_IndexAssets(t *testing.T) {
+	util.InitSoftErrorMode()
+	ctx := context.Background()
+	repoRoot := t.TempDir()
+	rootFs := filesystem.GetFilesystem(repoRoot)
+
+	saveAsset(t, repoRoot, "fleet", "105.0.0+up0.11.0", "0.11.0")
+	saveAsset(t, repoRoot, "fleet-crd", "105.0.0+up0.11.0", "0.11.0")
+	assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, "assets", "not-a-chart.tgz"), []byte("not a chart"), 0644))
+
+	expected, err := helmRepo.IndexDirectory(filepath.Join(repoRoot, "assets"), "assets")
+	assert.NoError(t, err)
+
+	// without an existing index every archive is loaded
+	index, err := IndexAssets(ctx, rootFs, helmRepo.NewIndexFile(), false)
+	assert.NoError(t, err)
+	assert.Len(t, index.Entries, 2)
+	for name, chartVersions := range expected.Entries {
+		assert.Len(t, index.Entries[name], 1)
+		assert.Equal(t, chartVersions[0].URLs, index.Entries[name][0].URLs)
+		assert.Equal(t, chartVersions[0].Digest, index.Entries[name][0].Digest)
+		assert.Equal(t, chartVersions[0].Metadata, index.Entries[name][0].Metadata)
+	}
+	assert.FileExists(t, filepath.Join(repoRoot, ".charts-build-scripts", "index-cache.json"))
+
+	// unchanged archives reuse the existing entries as-is, even if their metadata was edited
+	existing := index
+	existing.Entries["fleet"][0].Created = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing.Entries["fleet"][0].Metadata.AppVersion = "edited"
+	index, err = IndexAssets(ctx, rootFs, existing, false)
+	assert.NoError(t, err)
+	assert.Same(t, existing.Entries["fleet"][0], index.Entries["fleet"][0])
+	assert.Same(t, existing.Entries["fleet-crd"][0], index.Entries["fleet-crd"][0])
+
+	// a full rebuild loads every archive again
+	index, err = IndexAssets(ctx, rootFs, existing, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.11.0", index.Entries["fleet"][0].Metadata.AppVersion)
+
+	// changed archives are loaded again
+	assert.NoError(t, os.Remove(filepath.Join(repoRoot, "assets", "fleet", "fleet-105.0.0+up0.11.0.tgz")))
+	saveAsset(t, repoRoot, "fleet", "105.0.0+up0.11.0", "0.11.1")
+	saveAsset(t, repoRoot, "fleet", "105.1.0+up0.12.0", "0.12.0")
+	index, err = IndexAssets(ctx, rootFs, existing, false)
+	assert.NoError(t, err)
+	assert.Len(t, index.Entries["fleet"], 2)
+	for _, chartVersion := range index.Entries["fleet"] {
+		assert.NotSame(t, existing.Entries["fleet"][0], chartVersion)
+	}
+	assert.Same(t, existing.Entries["fleet-crd"][0], index.Entries["fleet-crd"][0])
+}
+
+func Test_CreateOrUpdateHelmIndex(t *testing.T) {
+	util.InitSoftErrorMode()
+	ctx := context.Background()
+	repoRoot := t.TempDir()
+	rootFs := filesystem.GetFilesystem(repoRoot)
+	indexPath := filepath.Join(repoRoot, "index.yaml")
+
+	saveAsset(t, repoRoot, "fleet", "105.0.0+up0.11.0", "0.11.0")
+	assert.NoError(t, CreateOrUpdateHelmIndex(ctx, rootFs))
+	index, err := helmRepo.LoadIndexFile(indexPath)
+	assert.NoError(t, err)
+	created := index.Entries["fleet"][0].Created
+
+	// a new version keeps the created timestamp of the unchanged ones
+	saveAsset(t, repoRoot, "fleet", "105.1.0+up0.12.0", "0.12.0")
+	assert.NoError(t, CreateOrUpdateHelmIndex(ctx, rootFs))
+	index, err = helmRepo.LoadIndexFile(indexPath)
+	assert.NoError(t, err)
+	assert.Len(t, index.Entries["fleet"], 2)
+	assert.Equal(t, "105.1.0+up0.12.0", index.Entries["fleet"][0].Version)
+	assert.True(t, created.Equal(index.Entries["fleet"][1].Created))
+
+	// an edited entry is only fixed by a rebuild, which keeps its created timestamp
+	index.Entries["fleet"][1].Metadata.AppVersion = "edited"
+	assert.NoError(t, index.WriteFile(indexPath, 0644))
+	assert.NoError(t, CreateOrUpdateHelmIndex(ctx, rootFs))
+	index, err = helmRepo.LoadIndexFile(indexPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "edited", index.Entries["fleet"][1].Metadata.AppVersion)
+
+	assert.NoError(t, RebuildHelmIndex(ctx, rootFs))
+	index, err = helmRepo.LoadIndexFile(indexPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.11.0", index.Entries["fleet"][1].Metadata.AppVersion)
+	assert.True(t, created.Equal(index.Entries["fleet"][1].Created))
+
+	// unsorted and duplicate versions are only fixed by a rebuild
+	unsorted := *index
+	unsorted.Entries = map[string]helmRepo.ChartVersions{
+		"fleet": {index.Entries["fleet"][1], index.Entries["fleet"][0], index.Entries["fleet"][1]},
+	}
+	data, err := yaml.Marshal(&unsorted)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(indexPath, data, 0644))
+	assert.NoError(t, CreateOrUpdateHelmIndex(ctx, rootFs))
+	written, err := os.ReadFile(indexPath)
+	assert.NoError(t, err)
+	assert.Equal(t, data, written)
+
+	assert.NoError(t, RebuildHelmIndex(ctx, rootFs))
+	written, err = os.ReadFile(indexPath)
+	assert.NoError(t, err)
+	rebuilt := &helmRepo.IndexFile{}
+	assert.NoError(t, yaml.Unmarshal(written, rebuilt))
+	assert.Len(t, rebuilt.Entries["fleet"], 2)
+	assert.Equal(t, "105.1.0+up0.12.0", rebuilt.Entries["fleet"][0].Version)
+	assert.Equal(t, "105.0.0+up0.11.0", rebuilt.Entries["fleet"][1].Version)
+	assert.True(t, created.Equal(rebuilt.Entries["fleet"][1].Created))
+}
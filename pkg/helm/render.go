@@ -0,0 +1,73 @@
+package helm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// manifestSeparator splits rendered templates into their manifests
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// RenderChart renders the templates of a chart offline, like helm template, with the chart default values
+// overridden by values. Cluster lookups return nothing and the default capabilities are used.
+func RenderChart(c *chart.Chart, values map[string]interface{}) (map[string]string, error) {
+	return RenderChartForKubeVersion(c, values, "")
+}
+
+// RenderChartForKubeVersion renders the templates of a chart offline like RenderChart,
+// with .Capabilities.KubeVersion set to kubeVersion, e.g. v1.30.0, unless it is empty
+func RenderChartForKubeVersion(c *chart.Chart, values map[string]interface{}, kubeVersion string) (map[string]string, error) {
+	capabilities := chartutil.DefaultCapabilities.Copy()
+	if kubeVersion != "" {
+		parsed, err := chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Kubernetes version %s: %w", kubeVersion, err)
+		}
+		capabilities.KubeVersion = *parsed
+	}
+
+	if err := chartutil.ProcessDependenciesWithMerge(c, values); err != nil {
+		return nil, fmt.Errorf("failed to process dependencies of %s: %w", c.Name(), err)
+	}
+
+	releaseOptions := chartutil.ReleaseOptions{Name: c.Name(), Namespace: "default", Revision: 1, IsInstall: true}
+	renderValues, err := chartutil.ToRenderValues(c, values, releaseOptions, capabilities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the values of %s: %w", c.Name(), err)
+	}
+
+	return engine.Render(c, renderValues)
+}
+
+// SplitManifests splits a rendered template into its manifests, separated by --- lines
+func SplitManifests(content string) []string {
+	return manifestSeparator.Split(content, -1)
+}
+
+// RenderedGVKs returns the <group>/<version>/<kind> of every manifest of the rendered templates,
+// e.g. fleet.cattle.io/v1alpha1/GitRepo; core resources have no group, e.g. /v1/ConfigMap
+func RenderedGVKs(rendered map[string]string) map[string]bool {
+	gvks := make(map[string]bool)
+	for _, content := range rendered {
+		for _, manifest := range SplitManifests(content) {
+			var resource struct {
+				APIVersion string `yaml:"apiVersion"`
+				Kind       string `yaml:"kind"`
+			}
+			if err := yaml.Unmarshal([]byte(manifest), &resource); err != nil || resource.APIVersion == "" || resource.Kind == "" {
+				continue
+			}
+			if !strings.Contains(resource.APIVersion, "/") {
+				resource.APIVersion = "/" + resource.APIVersion
+			}
+			gvks[resource.APIVersion+"/"+resource.Kind] = true
+		}
+	}
+	return gvks
+}
@@ -0,0 +1,27 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func Test_RenderChart(t *testing.T) {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "fleet", Version: "0.11.0"},
+		Values:   map[string]interface{}{"name": "local"},
+		Templates: []*chart.File{
+			{Name: "templates/gitrepo.yaml", Data: []byte("apiVersion: fleet.cattle.io/v1alpha1\nkind: GitRepo\nmetadata:\n  name: {{ .Values.name }}\n  namespace: {{ .Release.Namespace }}\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n")},
+			{Name: "templates/NOTES.txt", Data: []byte("Installed {{ .Chart.Name }}")},
+		},
+	}
+
+	rendered, err := RenderChart(c, map[string]interface{}{"name": "override"})
+	assert.NoError(t, err)
+	assert.Contains(t, rendered["fleet/templates/gitrepo.yaml"], "name: override\n  namespace: default")
+	assert.Equal(t, map[string]bool{
+		"fleet.cattle.io/v1alpha1/GitRepo": true,
+		"/v1/ConfigMap":                    true,
+	}, RenderedGVKs(rendered))
+}
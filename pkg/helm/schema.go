@@ -0,0 +1,100 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/chartvalues"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+
+	helmChart "helm.sh/helm/v3/pkg/chart"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	helmChartutil "helm.sh/helm/v3/pkg/chartutil"
+)
+
+// GenerateValuesSchema writes a values.schema.json inferred from the values.yaml of the chart, merged with the values.schema.json
+// the chart already has after its patches and overlay are applied; the existing schema wins on conflicts. It returns a function
+// restoring the previous values.schema.json, so the generated schema only ends up in the exported chart and never in a patch.
+func GenerateValuesSchema(ctx context.Context, fs billy.Filesystem, helmChartPath string) (func() error, error) {
+	noop := func() error { return nil }
+
+	valuesPath := filepath.Join(helmChartPath, helmChartutil.ValuesfileName)
+	exists, err := filesystem.PathExists(ctx, fs, valuesPath)
+	if err != nil || !exists {
+		return noop, err
+	}
+	values, err := os.ReadFile(filesystem.GetAbsPath(fs, valuesPath))
+	if err != nil {
+		return noop, err
+	}
+	schema, err := chartvalues.GenerateSchema(values)
+	if err != nil {
+		return noop, fmt.Errorf("failed to generate the schema of %s: %w", valuesPath, err)
+	}
+
+	schemaPath := filepath.Join(helmChartPath, helmChartutil.SchemafileName)
+	absSchemaPath := filesystem.GetAbsPath(fs, schemaPath)
+	existed, err := filesystem.PathExists(ctx, fs, schemaPath)
+	if err != nil {
+		return noop, err
+	}
+	var original []byte
+	if existed {
+		if original, err = os.ReadFile(absSchemaPath); err != nil {
+			return noop, err
+		}
+		overlay := map[string]interface{}{}
+		if err := json.Unmarshal(original, &overlay); err != nil {
+			return noop, fmt.Errorf("failed to parse %s: %w", schemaPath, err)
+		}
+		schema = chartvalues.MergeSchemas(schema, overlay)
+	}
+
+	data, err := chartvalues.EncodeSchema(schema)
+	if err != nil {
+		return noop, err
+	}
+	logger.Log(ctx, slog.LevelInfo, "generate values schema", slog.String("path", schemaPath), slog.Bool("merged", existed))
+	if err := os.WriteFile(absSchemaPath, data, 0644); err != nil {
+		return noop, err
+	}
+
+	restore := func() error {
+		if existed {
+			return os.WriteFile(absSchemaPath, original, 0644)
+		}
+		return os.Remove(absSchemaPath)
+	}
+	return restore, nil
+}
+
+// ValidateValuesSchema checks that the default values of the chart, and of each of its subcharts, are valid
+// against the values.schema.json of that chart, if any
+func ValidateValuesSchema(ctx context.Context, fs billy.Filesystem, helmChartPath string) error {
+	chart, err := helmLoader.Load(filesystem.GetAbsPath(fs, helmChartPath))
+	if err != nil {
+		return err
+	}
+	return validateDefaultValues(ctx, chart)
+}
+
+func validateDefaultValues(ctx context.Context, chart *helmChart.Chart) error {
+	if chart.Schema != nil {
+		logger.Log(ctx, slog.LevelDebug, "validate default values against schema", slog.String("chart", chart.Name()))
+		if err := helmChartutil.ValidateAgainstSingleSchema(chart.Values, chart.Schema); err != nil {
+			return fmt.Errorf("default values of %s do not match %s: %w", chart.Name(), helmChartutil.SchemafileName, err)
+		}
+	}
+	for _, dependency := range chart.Dependencies() {
+		if err := validateDefaultValues(ctx, dependency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateValuesSchema(t *testing.T) {
+	util.InitSoftErrorMode()
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "#1 generated schema",
+			files: map[string]string{
+				"values.yaml": "replicas: 1\n# @schema enum: [rancher, partner]\ncertified: rancher\n",
+			},
+			expected: "{\n  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n  \"properties\": {\n" +
+				"    \"certified\": {\n      \"enum\": [\n        \"rancher\",\n        \"partner\"\n      ],\n      \"type\": \"string\"\n    },\n" +
+				"    \"replicas\": {\n      \"type\": \"integer\"\n    }\n  },\n  \"type\": \"object\"\n}\n",
+		},
+		{
+			name: "#2 merged with the existing schema",
+			files: map[string]string{
+				"values.yaml":        "replicas: 1\n",
+				"values.schema.json": `{"properties": {"replicas": {"minimum": 1}}}`,
+			},
+			expected: "{\n  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n  \"properties\": {\n" +
+				"    \"replicas\": {\n      \"minimum\": 1,\n      \"type\": \"integer\"\n    }\n  },\n  \"type\": \"object\"\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, content := range tt.files {
+				assert.NoError(t, os.MkdirAll(filepath.Join(dir, "charts"), 0755))
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts", file), []byte(content), 0644))
+			}
+
+			restore, err := GenerateValuesSchema(context.Background(), filesystem.GetFilesystem(dir), "charts")
+			assert.NoError(t, err)
+			schema, err := os.ReadFile(filepath.Join(dir, "charts", "values.schema.json"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(schema))
+
+			assert.NoError(t, restore())
+			original, ok := tt.files["values.schema.json"]
+			if !ok {
+				assert.NoFileExists(t, filepath.Join(dir, "charts", "values.schema.json"))
+				return
+			}
+			schema, err = os.ReadFile(filepath.Join(dir, "charts", "values.schema.json"))
+			assert.NoError(t, err)
+			assert.Equal(t, original, string(schema))
+		})
+	}
+}
+
+func Test_ValidateValuesSchema(t *testing.T) {
+	util.InitSoftErrorMode()
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		expectErr string
+	}{
+		{
+			name:  "#1 no schema",
+			files: map[string]string{"values.yaml": "replicas: 1\n"},
+		},
+		{
+			name: "#2 valid default values",
+			files: map[string]string{
+				"values.yaml":        "replicas: 1\n",
+				"values.schema.json": `{"properties": {"replicas": {"type": "integer", "minimum": 1}}}`,
+			},
+		},
+		{
+			name: "#3 patched default values no longer match the schema",
+			files: map[string]string{
+				"values.yaml":        "replicas: \"1\"\n",
+				"values.schema.json": `{"properties": {"replicas": {"type": "integer"}}}`,
+			},
+			expectErr: "default values of fleet do not match values.schema.json",
+		},
+		{
+			name: "#4 invalid default values of a subchart",
+			files: map[string]string{
+				"values.yaml":                          "replicas: 1\n",
+				"charts/gitjob/Chart.yaml":             "apiVersion: v2\nname: gitjob\nversion: 0.1.0\n",
+				"charts/gitjob/values.yaml":            "debug: \"yes\"\n",
+				"charts/gitjob/values.schema.json":     `{"properties": {"debug": {"type": "boolean"}}}`,
+				"charts/gitjob/templates/service.yaml": "",
+			},
+			expectErr: "default values of gitjob do not match values.schema.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := map[string]string{"Chart.yaml": "apiVersion: v2\nname: fleet\nversion: 0.11.0\n"}
+			for file, content := range tt.files {
+				files[file] = content
+			}
+			for file, content := range files {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, "charts", file)), 0755))
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, "charts", file), []byte(content), 0644))
+			}
+
+			err := ValidateValuesSchema(context.Background(), filesystem.GetFilesystem(dir), "charts")
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package kubeapi
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
+
+// APIDeprecation is an API version of a kind deprecated, and possibly removed, in a Kubernetes version
+type APIDeprecation struct {
+	APIVersion   string
+	Kind         string
+	DeprecatedIn string
+	// RemovedIn is empty if the API version is not removed yet
+	RemovedIn string
+	// Replacement is the API version to migrate to, empty if the API has no replacement
+	Replacement string
+}
+
+// apiDeprecations are the deprecated API versions of the Kubernetes deprecated API migration guide
+var apiDeprecations = []APIDeprecation{
+	// v1.16
+	{"extensions/v1beta1", "DaemonSet", "v1.8", "v1.16", "apps/v1"},
+	{"extensions/v1beta1", "Deployment", "v1.8", "v1.16", "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", "v1.8", "v1.16", "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", "v1.9", "v1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", "v1.10", "v1.16", "policy/v1beta1"},
+	{"apps/v1beta1", "Deployment", "v1.9", "v1.16", "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", "v1.9", "v1.16", "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", "v1.9", "v1.16", "apps/v1"},
+	{"apps/v1beta2", "Deployment", "v1.9", "v1.16", "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", "v1.9", "v1.16", "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", "v1.9", "v1.16", "apps/v1"},
+	// v1.22
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "v1.16", "v1.22", "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "v1.16", "v1.22", "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "v1.19", "v1.22", "apiregistration.k8s.io/v1"},
+	{"authentication.k8s.io/v1beta1", "TokenReview", "v1.19", "v1.22", "authentication.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "LocalSubjectAccessReview", "v1.19", "v1.22", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "SelfSubjectAccessReview", "v1.19", "v1.22", "authorization.k8s.io/v1"},
+	{"authorization.k8s.io/v1beta1", "SubjectAccessReview", "v1.19", "v1.22", "authorization.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "v1.19", "v1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", "Lease", "v1.19", "v1.22", "coordination.k8s.io/v1"},
+	{"extensions/v1beta1", "Ingress", "v1.14", "v1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "v1.19", "v1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "v1.19", "v1.22", "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "v1.17", "v1.22", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "v1.14", "v1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "v1.19", "v1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", "v1.17", "v1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "v1.19", "v1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "v1.19", "v1.22", "storage.k8s.io/v1"},
+	// v1.25
+	{"batch/v1beta1", "CronJob", "v1.21", "v1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "v1.21", "v1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", "Event", "v1.19", "v1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "v1.22", "v1.25", "autoscaling/v2"},
+	{"policy/v1beta1", "PodDisruptionBudget", "v1.21", "v1.25", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "v1.21", "v1.25", ""},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "v1.20", "v1.25", "node.k8s.io/v1"},
+	// v1.26
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "v1.23", "v1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "v1.23", "v1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "v1.23", "v1.26", "autoscaling/v2"},
+	// v1.27
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "v1.24", "v1.27", "storage.k8s.io/v1"},
+	// v1.29
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "v1.26", "v1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	// v1.32
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", "v1.29", "v1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// Deprecation returns the deprecation of apiVersion and kind in kubeVersion, or nil if the API is not deprecated in that version
+func Deprecation(apiVersion, kind, kubeVersion string) (*APIDeprecation, error) {
+	target, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubernetes version %s: %w", kubeVersion, err)
+	}
+	for i, deprecation := range apiDeprecations {
+		if deprecation.APIVersion != apiVersion || deprecation.Kind != kind {
+			continue
+		}
+		if reachedMinor(target, deprecation.DeprecatedIn) {
+			return &apiDeprecations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Removed returns whether the API is removed in kubeVersion
+func (d APIDeprecation) Removed(kubeVersion string) bool {
+	target, err := semver.NewVersion(kubeVersion)
+	if err != nil || d.RemovedIn == "" {
+		return false
+	}
+	return reachedMinor(target, d.RemovedIn)
+}
+
+// String describes the deprecation, e.g. policy/v1beta1 PodDisruptionBudget is deprecated in v1.21 and removed in v1.25, use policy/v1
+func (d APIDeprecation) String() string {
+	message := fmt.Sprintf("%s %s is deprecated in %s", d.APIVersion, d.Kind, d.DeprecatedIn)
+	if d.RemovedIn != "" {
+		message += " and removed in " + d.RemovedIn
+	}
+	if d.Replacement != "" {
+		message += ", use " + d.Replacement
+	}
+	return message
+}
+
+// reachedMinor returns whether target is the same or a later minor version than minor, e.g. v1.25
+func reachedMinor(target *semver.Version, minor string) bool {
+	v := semver.MustParse(minor)
+	return target.Major() > v.Major() || (target.Major() == v.Major() && target.Minor() >= v.Minor())
+}
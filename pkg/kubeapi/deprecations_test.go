@@ -0,0 +1,46 @@
+package kubeapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Deprecation(t *testing.T) {
+	tests := []struct {
+		name        string
+		apiVersion  string
+		kind        string
+		kubeVersion string
+		deprecated  bool
+		removed     bool
+	}{
+		{"#1 served API", "policy/v1", "PodDisruptionBudget", "v1.30.0", false, false},
+		{"#2 before the deprecation", "policy/v1beta1", "PodSecurityPolicy", "v1.20.5", false, false},
+		{"#3 deprecated", "policy/v1beta1", "PodSecurityPolicy", "v1.21.0", true, false},
+		{"#4 removed", "policy/v1beta1", "PodSecurityPolicy", "v1.25.0", true, true},
+		{"#5 removed in a later version", "extensions/v1beta1", "Ingress", "v1.28.1", true, true},
+		{"#6 same API version of another kind", "extensions/v1beta1", "ConfigMap", "v1.28.1", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deprecation, err := Deprecation(tt.apiVersion, tt.kind, tt.kubeVersion)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.deprecated, deprecation != nil)
+			if deprecation != nil {
+				assert.Equal(t, tt.removed, deprecation.Removed(tt.kubeVersion))
+			}
+		})
+	}
+}
+
+func Test_APIDeprecation_String(t *testing.T) {
+	deprecation, err := Deprecation("policy/v1beta1", "PodDisruptionBudget", "v1.25.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21 and removed in v1.25, use policy/v1", deprecation.String())
+
+	deprecation, err = Deprecation("policy/v1beta1", "PodSecurityPolicy", "v1.25.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21 and removed in v1.25", deprecation.String())
+}
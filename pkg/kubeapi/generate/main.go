@@ -0,0 +1,261 @@
+// generate writes the OpenAPI v2 document of the built-in Kubernetes types vendored by pkg/kubeapi.
+// The definitions are derived from the Go types of the k8s.io/api version in go.mod, so the schema
+// of another Kubernetes version is added by running it with that version of k8s.io/api:
+//
+//	go get k8s.io/api@v0.30.0 k8s.io/client-go@v0.30.0 && go generate ./pkg/kubeapi && git checkout go.mod go.sum
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/rancher/charts-build-scripts/pkg/kubeapi"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// specialTypes have a custom json encoding that their Go type does not describe
+var specialTypes = map[string]*kubeapi.Schema{
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time":                          {Type: "string"},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime":                     {Type: "string"},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Duration":                      {Type: "string"},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.FieldsV1":                      {Type: "object", PreserveUnknownFields: true},
+	"k8s.io/apimachinery/pkg/api/resource.Quantity":                      {IntOrString: true},
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString":                    {IntOrString: true},
+	"k8s.io/apimachinery/pkg/runtime.RawExtension":                       {Type: "object", PreserveUnknownFields: true},
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1.JSON":      {},
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1.JSON": {},
+}
+
+var (
+	unmarshalerType  = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	objectMetaType   = reflect.TypeOf(metav1.ObjectMeta{})
+	outputDirectory  string
+	apiModule        = "k8s.io/api"
+	definitionPrefix = "io.k8s."
+)
+
+func main() {
+	flag.StringVar(&outputDirectory, "output", "schemas", "directory to write <kubernetes version>.json to")
+	flag.Parse()
+
+	kubeVersion, err := kubernetesVersion()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// CustomResourceDefinitions are not part of the client-go scheme
+	if err := apiextensionsv1.AddToScheme(scheme.Scheme); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	document := kubeapi.Document{Swagger: "2.0", Definitions: map[string]*kubeapi.Schema{}}
+	document.Info.Title = "Kubernetes"
+	document.Info.Version = kubeVersion.Original()
+
+	for gvk, t := range scheme.Scheme.AllKnownTypes() {
+		if gvk.Version == "__internal" || t.Kind() != reflect.Struct {
+			continue
+		}
+		// only resources, not the options and events registered in every group
+		if field, ok := t.FieldByName("ObjectMeta"); !ok || field.Type != objectMetaType {
+			continue
+		}
+		definition := definitionFor(t, document.Definitions)
+		schema := document.Definitions[strings.TrimPrefix(definition.Ref, "#/definitions/")]
+		schema.GroupVersionKind = append(schema.GroupVersionKind, kubeapi.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind})
+		sort.Slice(schema.GroupVersionKind, func(i, j int) bool {
+			return schema.GroupVersionKind[i].String() < schema.GroupVersionKind[j].String()
+		})
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	file := filepath.Join(outputDirectory, fmt.Sprintf("v%d.%d.json", kubeVersion.Major(), kubeVersion.Minor()))
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d definitions of Kubernetes %s to %s\n", len(document.Definitions), kubeVersion.Original(), file)
+}
+
+// kubernetesVersion returns the Kubernetes version of the k8s.io/api module built in, e.g. v1.31.3 for v0.31.3
+func kubernetesVersion() (*semver.Version, error) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, fmt.Errorf("unable to read the build info")
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != apiModule {
+			continue
+		}
+		v, err := semver.NewVersion(dep.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %s of %s: %w", dep.Version, apiModule, err)
+		}
+		return semver.NewVersion(fmt.Sprintf("v1.%d.%d", v.Minor(), v.Patch()))
+	}
+	return nil, fmt.Errorf("%s is not a dependency", apiModule)
+}
+
+// definitionFor returns the schema of a Go type; named structs are added to the definitions and referenced
+func definitionFor(t reflect.Type, definitions map[string]*kubeapi.Schema) *kubeapi.Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if special, ok := specialTypes[t.PkgPath()+"."+t.Name()]; ok {
+		copied := *special
+		return &copied
+	}
+	if t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(unmarshalerType) {
+		// custom encoding, e.g. JSONSchemaPropsOrBool
+		return &kubeapi.Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, definitions)
+		}
+		name := definitionName(t)
+		if _, ok := definitions[name]; !ok {
+			// registered before the fields for recursive types, e.g. JSONSchemaProps
+			definitions[name] = &kubeapi.Schema{}
+			*definitions[name] = *structSchema(t, definitions)
+		}
+		return &kubeapi.Schema{Ref: "#/definitions/" + name}
+	case reflect.Map:
+		return &kubeapi.Schema{Type: "object", AdditionalProperties: definitionFor(t.Elem(), definitions)}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// base64 encoded
+			return &kubeapi.Schema{Type: "string"}
+		}
+		return &kubeapi.Schema{Type: "array", Items: definitionFor(t.Elem(), definitions)}
+	case reflect.String:
+		return &kubeapi.Schema{Type: "string"}
+	case reflect.Bool:
+		return &kubeapi.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &kubeapi.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &kubeapi.Schema{Type: "number"}
+	}
+	return &kubeapi.Schema{}
+}
+
+// structSchema returns the object schema of a struct. Like the Kubernetes OpenAPI generator, fields without omitempty
+// or +optional are required, as well as those marked +required, and inlined structs, e.g. TypeMeta, add their fields to the object.
+func structSchema(t reflect.Type, definitions map[string]*kubeapi.Schema) *kubeapi.Schema {
+	schema := &kubeapi.Schema{Type: "object", Properties: map[string]*kubeapi.Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			inlined := structSchema(field.Type, definitions)
+			for property, propertySchema := range inlined.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, inlined.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = definitionFor(field.Type, definitions)
+		marker := fieldMarkers(t.PkgPath())[t.Name()+"."+field.Name]
+		if marker == requiredMarker || (marker != optionalMarker && !strings.Contains(options, "omitempty")) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// definitionName returns the OpenAPI definition name of a Go type, e.g. io.k8s.api.apps.v1.Deployment
+func definitionName(t reflect.Type) string {
+	pkg := strings.TrimPrefix(t.PkgPath(), "k8s.io/")
+	return definitionPrefix + strings.ReplaceAll(pkg, "/", ".") + "." + t.Name()
+}
+
+const (
+	optionalMarker = "+optional"
+	requiredMarker = "+required"
+)
+
+// markers caches the +optional and +required markers of the struct fields of each package, see fieldMarkers
+var markers = map[string]map[string]string{}
+
+// fieldMarkers returns the +optional or +required marker of the struct fields of a package by <struct>.<field>,
+// read from the comments of its source files
+func fieldMarkers(pkgPath string) map[string]string {
+	if fields, ok := markers[pkgPath]; ok {
+		return fields
+	}
+	fields := map[string]string{}
+	markers[pkgPath] = fields
+
+	dir, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkgPath).Output()
+	if err != nil {
+		return fields
+	}
+	packages, err := parser.ParseDir(token.NewFileSet(), strings.TrimSpace(string(dir)), nil, parser.ParseComments)
+	if err != nil {
+		return fields
+	}
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				typeSpec, ok := node.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || structType.Fields == nil {
+					return true
+				}
+				for _, field := range structType.Fields.List {
+					if field.Doc == nil {
+						continue
+					}
+					for _, marker := range []string{optionalMarker, requiredMarker} {
+						if !strings.Contains(field.Doc.Text(), marker) {
+							continue
+						}
+						for _, name := range field.Names {
+							fields[typeSpec.Name.Name+"."+name.Name] = marker
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+	return fields
+}
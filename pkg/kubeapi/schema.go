@@ -0,0 +1,288 @@
+package kubeapi
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+)
+
+//go:generate go run ./generate -output schemas
+
+// schemaFiles are the vendored OpenAPI v2 documents of the built-in Kubernetes types, one per Kubernetes minor version
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Schema is the subset of an OpenAPI v2 schema used to validate Kubernetes objects
+type Schema struct {
+	Type                  string             `json:"type,omitempty"`
+	Ref                   string             `json:"$ref,omitempty"`
+	Properties            map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties  *Schema            `json:"additionalProperties,omitempty"`
+	Items                 *Schema            `json:"items,omitempty"`
+	Required              []string           `json:"required,omitempty"`
+	IntOrString           bool               `json:"x-kubernetes-int-or-string,omitempty"`
+	PreserveUnknownFields bool               `json:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	GroupVersionKind      []GroupVersionKind `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+// GroupVersionKind identifies the kind a definition of the OpenAPI document is the schema of
+type GroupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// String returns the GroupVersionKind as <group>/<version>/<kind>, e.g. apps/v1/Deployment or /v1/ConfigMap
+func (gvk GroupVersionKind) String() string {
+	return gvk.Group + "/" + gvk.Version + "/" + gvk.Kind
+}
+
+// Document is an OpenAPI v2 document holding the definitions of the built-in types of a Kubernetes version
+type Document struct {
+	Swagger string `json:"swagger"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Definitions map[string]*Schema `json:"definitions"`
+
+	// kinds indexes the definitions by GroupVersionKind
+	kinds map[string]string
+}
+
+var (
+	documentsMutex sync.Mutex
+	documents      = map[string]*Document{}
+)
+
+// VendoredVersions returns the Kubernetes versions with a vendored schema, sorted, e.g. [v1.31]
+func VendoredVersions() []string {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.MustParse(versions[i]).LessThan(semver.MustParse(versions[j]))
+	})
+	return versions
+}
+
+// HasSchema returns whether the schema of the minor version of kubeVersion is vendored
+func HasSchema(kubeVersion string) (bool, error) {
+	target, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid Kubernetes version %s: %w", kubeVersion, err)
+	}
+	for _, version := range VendoredVersions() {
+		v := semver.MustParse(version)
+		if v.Major() == target.Major() && v.Minor() == target.Minor() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SchemaVersion returns the vendored schema used for kubeVersion: the one of the same or the closest lower minor version,
+// or the lowest vendored version if kubeVersion is older than all of them
+func SchemaVersion(kubeVersion string) (string, error) {
+	target, err := semver.NewVersion(kubeVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid Kubernetes version %s: %w", kubeVersion, err)
+	}
+	versions := VendoredVersions()
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no vendored Kubernetes schema")
+	}
+
+	selected := versions[0]
+	for _, version := range versions {
+		v := semver.MustParse(version)
+		if v.Major() < target.Major() || (v.Major() == target.Major() && v.Minor() <= target.Minor()) {
+			selected = version
+		}
+	}
+	return selected, nil
+}
+
+// LoadDocument returns the vendored OpenAPI document used for kubeVersion, see SchemaVersion
+func LoadDocument(kubeVersion string) (*Document, error) {
+	version, err := SchemaVersion(kubeVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	documentsMutex.Lock()
+	defer documentsMutex.Unlock()
+	if d, ok := documents[version]; ok {
+		return d, nil
+	}
+
+	data, err := schemaFiles.ReadFile(path.Join("schemas", version+".json"))
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("failed to parse the schema of Kubernetes %s: %w", version, err)
+	}
+	d.kinds = make(map[string]string)
+	for name, definition := range d.Definitions {
+		for _, gvk := range definition.GroupVersionKind {
+			d.kinds[gvk.String()] = name
+		}
+	}
+	documents[version] = d
+	return d, nil
+}
+
+// Lookup returns the schema of the built-in kind <group>/<version>/<kind>, or nil if the Kubernetes version does not serve it
+func (d *Document) Lookup(gvk string) *Schema {
+	name, ok := d.kinds[gvk]
+	if !ok {
+		return nil
+	}
+	return d.Definitions[name]
+}
+
+// resolve follows the $ref of a schema to its definition
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	return s
+}
+
+// Validate returns the violations of a decoded yaml or json object against the schema, e.g.
+// .spec.replicas: expected integer, got string. Refs are resolved against the document.
+func (d *Document) Validate(s *Schema, object interface{}) []string {
+	var violations []string
+	d.validate("", s, object, &violations)
+	return violations
+}
+
+func (d *Document) validate(fieldPath string, s *Schema, value interface{}, violations *[]string) {
+	s = d.resolve(s)
+	if s == nil || value == nil {
+		// no constraint, or a null value which is the same as an unset field
+		return
+	}
+	if s.IntOrString {
+		switch value.(type) {
+		case string, int, int64, float64:
+		default:
+			*violations = append(*violations, fmt.Sprintf("%s: expected integer or string, got %s", pathOrRoot(fieldPath), typeName(value)))
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: expected object, got %s", pathOrRoot(fieldPath), typeName(value)))
+			return
+		}
+		d.validateObject(fieldPath, s, object, violations)
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: expected array, got %s", pathOrRoot(fieldPath), typeName(value)))
+			return
+		}
+		for i, item := range array {
+			d.validate(fmt.Sprintf("%s[%d]", fieldPath, i), s.Items, item, violations)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: expected string, got %s", pathOrRoot(fieldPath), typeName(value)))
+		}
+	case "integer":
+		if !isInteger(value) {
+			*violations = append(*violations, fmt.Sprintf("%s: expected integer, got %s", pathOrRoot(fieldPath), typeName(value)))
+		}
+	case "number":
+		switch value.(type) {
+		case int, int64, float64:
+		default:
+			*violations = append(*violations, fmt.Sprintf("%s: expected number, got %s", pathOrRoot(fieldPath), typeName(value)))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: expected boolean, got %s", pathOrRoot(fieldPath), typeName(value)))
+		}
+	}
+}
+
+// validateObject validates the fields of an object: unknown fields are only reported if the schema has
+// properties and does not preserve unknown fields, as the API server prunes them from custom resources
+func (d *Document) validateObject(fieldPath string, s *Schema, object map[string]interface{}, violations *[]string) {
+	fields := make([]string, 0, len(object))
+	for field := range object {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		fieldSchema, ok := s.Properties[field]
+		switch {
+		case ok:
+			d.validate(fieldPath+"."+field, fieldSchema, object[field], violations)
+		case s.AdditionalProperties != nil:
+			d.validate(fieldPath+"."+field, s.AdditionalProperties, object[field], violations)
+		case len(s.Properties) > 0 && !s.PreserveUnknownFields:
+			*violations = append(*violations, fmt.Sprintf("%s: unknown field %s", pathOrRoot(fieldPath), field))
+		}
+	}
+	for _, field := range s.Required {
+		if _, ok := object[field]; !ok {
+			*violations = append(*violations, fmt.Sprintf("%s: missing required field %s", pathOrRoot(fieldPath), field))
+		}
+	}
+}
+
+// pathOrRoot returns the field path, or . for the object itself
+func pathOrRoot(fieldPath string) string {
+	if fieldPath == "" {
+		return "."
+	}
+	return fieldPath
+}
+
+// isInteger returns whether a decoded yaml or json value is an integer; json decodes every number to float64
+func isInteger(value interface{}) bool {
+	switch v := value.(type) {
+	case int, int64:
+		return true
+	case float64:
+		return v == float64(int64(v))
+	}
+	return false
+}
+
+// typeName returns the json type of a decoded value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
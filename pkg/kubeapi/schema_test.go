@@ -0,0 +1,124 @@
+package kubeapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_SchemaVersion(t *testing.T) {
+	vendored := VendoredVersions()
+	require.NotEmpty(t, vendored)
+	newest := vendored[len(vendored)-1]
+
+	tests := []struct {
+		name        string
+		kubeVersion string
+		expected    string
+		err         bool
+	}{
+		{"#1 older than every vendored schema", "v1.16.0", vendored[0], false},
+		{"#2 vendored version", newest + ".2", newest, false},
+		{"#3 newer than every vendored schema", "v1.99.0", newest, false},
+		{"#4 invalid version", "latest", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := SchemaVersion(tt.kubeVersion)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func Test_HasSchema(t *testing.T) {
+	vendored := VendoredVersions()
+	require.NotEmpty(t, vendored)
+	newest := vendored[len(vendored)-1]
+
+	tests := []struct {
+		name        string
+		kubeVersion string
+		expected    bool
+		err         bool
+	}{
+		{"#1 vendored version", newest + ".2", true, false},
+		{"#2 older than every vendored schema", "v1.16.0", false, false},
+		{"#3 newer than every vendored schema", "v1.99.0", false, false},
+		{"#4 invalid version", "latest", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendored, err := HasSchema(tt.kubeVersion)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, vendored)
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	document, err := LoadDocument("v1.31.0")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		gvk      string
+		manifest string
+		expected []string
+	}{
+		{
+			name:     "#1 valid deployment",
+			gvk:      "apps/v1/Deployment",
+			manifest: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\n  labels:\n    app: fleet\nspec:\n  replicas: 1\n  selector:\n    matchLabels:\n      app: fleet\n  template:\n    spec:\n      containers:\n      - name: fleet\n        image: rancher/fleet:v0.11.0\n        ports:\n        - containerPort: 8080\n        resources:\n          limits:\n            cpu: 500m\n            memory: 1\n",
+		},
+		{
+			name:     "#2 wrong types, unknown and missing fields",
+			gvk:      "apps/v1/Deployment",
+			manifest: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\nspec:\n  replicas: \"1\"\n  selector:\n    matchLabels:\n      app: fleet\n  template:\n    spec:\n      containers:\n      - image: rancher/fleet:v0.11.0\n        imagePullPolicy: Always\n        port: 8080\n",
+			expected: []string{
+				".spec.replicas: expected integer, got string",
+				".spec.template.spec.containers[0]: unknown field port",
+				".spec.template.spec.containers[0]: missing required field name",
+			},
+		},
+		{
+			name:     "#3 core kind with null field",
+			gvk:      "/v1/ConfigMap",
+			manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: fleet\n  annotations:\ndata:\n  enabled: true\n",
+			expected: []string{".data.enabled: expected string, got boolean"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := document.Lookup(tt.gvk)
+			require.NotNil(t, schema)
+
+			object := map[string]interface{}{}
+			require.NoError(t, yaml.Unmarshal([]byte(tt.manifest), &object))
+			assert.Equal(t, tt.expected, document.Validate(schema, object))
+		})
+	}
+}
+
+func Test_Lookup(t *testing.T) {
+	document, err := LoadDocument("v1.31.0")
+	require.NoError(t, err)
+
+	assert.NotNil(t, document.Lookup("apiextensions.k8s.io/v1/CustomResourceDefinition"))
+	assert.NotNil(t, document.Lookup("policy/v1/PodDisruptionBudget"))
+	assert.Nil(t, document.Lookup("policy/v1beta1/PodSecurityPolicy"))
+	assert.Nil(t, document.Lookup("fleet.cattle.io/v1alpha1/GitRepo"))
+}
@@ -36,8 +36,8 @@ type Dependencies struct {
 // WalkDirFunc is a function type that will be used to walk through the filesystem
 type WalkDirFunc func(ctx context.Context, fs billy.Filesystem, dirPath string, doFunc filesystem.RelativePathFunc) error
 
-// InitDependencies will check the filesystem, branch version,
-// git status, initialize the Dependencies struct and populate it.
+// InitDependencies will check the filesystem and branch version, initialize the Dependencies struct and populate it.
+// It does not require a clean git tree; commands that change the working tree must call CheckCleanTree.
 // If anything fails the operation will be aborted.
 func InitDependencies(ctx context.Context, rootFs billy.Filesystem, repoRoot, branchVersion, currentChart string, newChart bool) (*Dependencies, error) {
 	if newChart && currentChart == "" {
@@ -60,15 +60,6 @@ func InitDependencies(ctx context.Context, rootFs billy.Filesystem, repoRoot, br
 		Git:            git,
 	}
 
-	// Git tree must be clean before proceeding with removing charts
-	clean, err := git.StatusProcelain(ctx)
-	if err != nil {
-		return nil, err
-	}
-	if !clean {
-		return nil, errGitNotClean
-	}
-
 	// Initialize, load, and check version rules for the current branch
 	dep.VR, err = dep.rules(ctx, branchVersion, loadFromJSON)
 	if err != nil {
@@ -99,6 +90,18 @@ func InitDependencies(ctx context.Context, rootFs billy.Filesystem, repoRoot, br
 	return dep, nil
 }
 
+// CheckCleanTree returns an error if the local git repository has uncommitted changes
+func (ld *Dependencies) CheckCleanTree(ctx context.Context) error {
+	clean, err := ld.Git.StatusProcelain(ctx)
+	if err != nil {
+		return err
+	}
+	if !clean {
+		return errGitNotClean
+	}
+	return nil
+}
+
 func checkFilePaths(ctx context.Context, rootFs billy.Filesystem) error {
 	// Check if the assets folder and Helm index file exists in the repository
 	exists, err := filesystem.PathExists(ctx, rootFs, path.RepositoryAssetsDir)
@@ -0,0 +1,257 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+)
+
+// Output format of the lifecycle matrix report, besides json and yaml
+const OutputMarkdown = "markdown"
+
+// Issues flagged in a lifecycle matrix cell
+const (
+	MatrixMissing            = "missing"              // released and in the lifecycle but not in the development branch
+	MatrixOutOfLifecycle     = "out-of-lifecycle"     // present in the branch but outside its lifecycle window
+	MatrixForwardPortPending = "forward-port-pending" // in the development branch and the lifecycle but not forward-ported yet
+)
+
+// Matrix holds every chart version across the production and development branches of every branch version in the rules
+type Matrix struct {
+	Branches []MatrixBranch `json:"branches"` // sorted from the oldest to the newest branch version
+	Rows     []MatrixRow    `json:"rows"`     // sorted by chart and from the newest to the oldest version
+}
+
+// MatrixBranch is a branch version with its production and development branches
+type MatrixBranch struct {
+	Version    string `json:"version"`
+	ProdBranch string `json:"prod_branch"`
+	DevBranch  string `json:"dev_branch"`
+	NotFound   bool   `json:"not_found,omitempty"` // the branches could not be fetched, so their cells are empty
+}
+
+// MatrixRow is a chart version with its cell at each branch version
+type MatrixRow struct {
+	Chart   string                `json:"chart"`
+	Version string                `json:"version"`
+	Cells   map[string]MatrixCell `json:"cells"`
+}
+
+// MatrixCell tells where the chart version is present in a branch version and the issue found, if any
+type MatrixCell struct {
+	Prod  bool   `json:"prod"`
+	Dev   bool   `json:"dev"`
+	Issue string `json:"issue,omitempty"`
+}
+
+// LifecycleMatrix reads index.yaml from the production and development branches of every branch version in the rules
+// and builds the matrix of chart versions, optionally filtered by a specific chart.
+// Every cell is checked against the lifecycle of its branch version.
+func (ld *Dependencies) LifecycleMatrix(ctx context.Context, chart string) (*Matrix, error) {
+	branchVersions, err := ld.VR.sortedBranchVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &Matrix{Branches: []MatrixBranch{}, Rows: []MatrixRow{}}
+	prodAssets := make(map[string]map[string][]Asset)
+	devAssets := make(map[string]map[string][]Asset)
+	// every chart version released in any production branch
+	released := make(map[[2]string]bool)
+	rows := make(map[[2]string]*MatrixRow)
+
+	for _, branchVersion := range branchVersions {
+		branch := MatrixBranch{
+			Version:    branchVersion,
+			ProdBranch: ld.VR.ProdBranchPrefix + branchVersion,
+			DevBranch:  ld.VR.DevBranchPrefix + branchVersion,
+		}
+
+		prod, prodErr := getAssetsMapFromBranch(ctx, ld.Git, branch.ProdBranch)
+		dev, devErr := getAssetsMapFromBranch(ctx, ld.Git, branch.DevBranch)
+		if prodErr != nil || devErr != nil {
+			logger.Log(ctx, slog.LevelWarn, "skipping branch version", slog.String("branch-version", branchVersion), slog.Any("prod", prodErr), slog.Any("dev", devErr))
+			branch.NotFound = true
+			matrix.Branches = append(matrix.Branches, branch)
+			continue
+		}
+		matrix.Branches = append(matrix.Branches, branch)
+		prodAssets[branchVersion] = prod
+		devAssets[branchVersion] = dev
+
+		for _, assets := range []map[string][]Asset{prod, dev} {
+			for asset, versions := range assets {
+				if chart != "" && asset != chart {
+					continue
+				}
+				for _, version := range versions {
+					key := [2]string{asset, version.Version}
+					if _, ok := rows[key]; !ok {
+						rows[key] = &MatrixRow{Chart: asset, Version: version.Version, Cells: make(map[string]MatrixCell)}
+					}
+				}
+			}
+		}
+		for asset, versions := range prod {
+			for _, version := range versions {
+				released[[2]string{asset, version.Version}] = true
+			}
+		}
+	}
+
+	for key, row := range rows {
+		for _, branch := range matrix.Branches {
+			if branch.NotFound {
+				continue
+			}
+			vr := *ld.VR
+			vr.BranchVersion = branch.Version
+
+			cell := MatrixCell{
+				Prod: checkIfVersionIsReleased(row.Version, prodAssets[branch.Version][row.Chart]),
+				Dev:  checkIfVersionIsReleased(row.Version, devAssets[branch.Version][row.Chart]),
+			}
+			cell.Issue = matrixIssue(&vr, row.Chart, row.Version, cell, released[key])
+			row.Cells[branch.Version] = cell
+		}
+		matrix.Rows = append(matrix.Rows, *row)
+	}
+
+	sort.Slice(matrix.Rows, func(i, j int) bool {
+		if matrix.Rows[i].Chart != matrix.Rows[j].Chart {
+			return matrix.Rows[i].Chart < matrix.Rows[j].Chart
+		}
+		return newerVersion(matrix.Rows[i].Version, matrix.Rows[j].Version)
+	})
+
+	return matrix, nil
+}
+
+// matrixIssue checks the chart version presence in a branch version against its lifecycle:
+//
+//	present outside the lifecycle window and not in the keep allowlist: out-of-lifecycle
+//	released in any production branch and in the lifecycle but not in the development branch: missing
+//	in the development branch and the lifecycle, not released and from an older branch version: forward-port-pending
+func matrixIssue(vr *VersionRules, chart, version string, cell MatrixCell, released bool) string {
+	inLifecycle := vr.CheckChartVersionForLifecycle(chart, version)
+
+	switch {
+	case (cell.Prod || cell.Dev) && !inLifecycle && !vr.KeepOutOfLifecycle(chart, version):
+		return MatrixOutOfLifecycle
+	case inLifecycle && released && !cell.Dev:
+		return MatrixMissing
+	case inLifecycle && cell.Dev && !cell.Prod && !vr.CheckForRCVersion(version):
+		if toRelease, err := versionInRange(version, vr.Rules[vr.BranchVersion].Min, vr.Rules[vr.BranchVersion].Max); err == nil && !toRelease {
+			return MatrixForwardPortPending
+		}
+	}
+	return ""
+}
+
+// newerVersion returns true if version a is newer than b, falling back to a string comparison for invalid versions
+func newerVersion(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return va.GreaterThan(vb)
+}
+
+// Issues returns the number of cells flagged with each issue
+func (m *Matrix) Issues() map[string]int {
+	issues := make(map[string]int)
+	for _, row := range m.Rows {
+		for _, cell := range row.Cells {
+			if cell.Issue != "" {
+				issues[cell.Issue]++
+			}
+		}
+	}
+	return issues
+}
+
+// Write writes the matrix to w as markdown, json or yaml
+func (m *Matrix) Write(w io.Writer, format string) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		return writeStructured(w, format, m)
+	case OutputMarkdown:
+		return m.writeMarkdown(w)
+	default:
+		return fmt.Errorf("unknown output format %q; expected %s, %s or %s", format, OutputMarkdown, OutputJSON, OutputYAML)
+	}
+}
+
+// writeMarkdown writes the matrix as a markdown table followed by the list of issues
+func (m *Matrix) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("# Lifecycle matrix\n\n")
+	b.WriteString("| Chart | Version |")
+	for _, branch := range m.Branches {
+		fmt.Fprintf(&b, " %s |", branch.Version)
+	}
+	b.WriteString("\n|---|---|" + strings.Repeat("---|", len(m.Branches)) + "\n")
+
+	var issues []string
+	for _, row := range m.Rows {
+		fmt.Fprintf(&b, "| %s | %s |", row.Chart, row.Version)
+		for _, branch := range m.Branches {
+			cell, ok := row.Cells[branch.Version]
+			if !ok {
+				b.WriteString(" |")
+				continue
+			}
+			fmt.Fprintf(&b, " %s |", cell.markdown())
+			if cell.Issue != "" {
+				issues = append(issues, fmt.Sprintf("- `%s` `%s` at %s: %s", row.Chart, row.Version, branch.Version, cell.Issue))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	var notFound []string
+	for _, branch := range m.Branches {
+		if branch.NotFound {
+			notFound = append(notFound, branch.ProdBranch+", "+branch.DevBranch)
+		}
+	}
+	if len(notFound) > 0 {
+		b.WriteString("\nBranches not found: " + strings.Join(notFound, "; ") + "\n")
+	}
+
+	b.WriteString("\n## Issues\n\n")
+	if len(issues) == 0 {
+		b.WriteString("No issues found.\n")
+	} else {
+		b.WriteString(strings.Join(issues, "\n") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdown formats the cell as the branches the version is present in followed by its issue, e.g. "dev **forward-port-pending**"
+func (c MatrixCell) markdown() string {
+	var parts []string
+	if c.Prod {
+		parts = append(parts, "prod")
+	}
+	if c.Dev {
+		parts = append(parts, "dev")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "-")
+	}
+	if c.Issue != "" {
+		parts = append(parts, "**"+c.Issue+"**")
+	}
+	return strings.Join(parts, " ")
+}
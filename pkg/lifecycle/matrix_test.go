@@ -0,0 +1,116 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LifecycleMatrix(t *testing.T) {
+	ctx := context.Background()
+	index := func(versions ...string) string {
+		data := "apiVersion: v1\nentries:\n  fleet:\n"
+		for _, version := range versions {
+			data += "  - name: fleet\n    version: " + version + "\n"
+		}
+		return data
+	}
+	upstreamDir, localDir := newIndexTestRepos(t, map[string]string{
+		"release-v2.9":  index("104.0.0+up0.10.0"),
+		"dev-v2.9":      index("104.0.0+up0.10.0", "104.1.0+up0.10.1"),
+		"release-v2.10": index("105.0.0+up0.11.0", "101.0.0+up0.7.0"),
+		"dev-v2.10":     index("105.0.0+up0.11.0", "104.1.0+up0.10.1"),
+	})
+
+	ld := &Dependencies{
+		Git: &git.Git{
+			Dir:         localDir,
+			Branch:      "main",
+			Remotes:     map[string]string{upstreamDir: "upstream"},
+			UpstreamURL: upstreamDir,
+		},
+		VR: &VersionRules{
+			Rules: map[string]Version{
+				"2.7":  {Min: "102.0.0", Max: "103.0.0"},
+				"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+				"2.10": {Min: "105.0.0", Max: "106.0.0"},
+			},
+			BranchVersion:    "2.10",
+			ProdBranchPrefix: "release-v",
+			DevBranchPrefix:  "dev-v",
+		},
+	}
+
+	matrix, err := ld.LifecycleMatrix(ctx, "")
+	assert.NoError(t, err)
+
+	t.Run("#1 branches", func(t *testing.T) {
+		assert.Equal(t, []MatrixBranch{
+			{Version: "2.7", ProdBranch: "release-v2.7", DevBranch: "dev-v2.7", NotFound: true},
+			{Version: "2.8", ProdBranch: "release-v2.8", DevBranch: "dev-v2.8", NotFound: true},
+			{Version: "2.9", ProdBranch: "release-v2.9", DevBranch: "dev-v2.9"},
+			{Version: "2.10", ProdBranch: "release-v2.10", DevBranch: "dev-v2.10"},
+		}, matrix.Branches)
+	})
+
+	t.Run("#2 rows", func(t *testing.T) {
+		assert.Equal(t, []MatrixRow{
+			{Chart: "fleet", Version: "105.0.0+up0.11.0", Cells: map[string]MatrixCell{
+				"2.9":  {},
+				"2.10": {Prod: true, Dev: true},
+			}},
+			{Chart: "fleet", Version: "104.1.0+up0.10.1", Cells: map[string]MatrixCell{
+				"2.9":  {Dev: true},
+				"2.10": {Dev: true, Issue: MatrixForwardPortPending},
+			}},
+			{Chart: "fleet", Version: "104.0.0+up0.10.0", Cells: map[string]MatrixCell{
+				"2.9":  {Prod: true, Dev: true},
+				"2.10": {Issue: MatrixMissing},
+			}},
+			{Chart: "fleet", Version: "101.0.0+up0.7.0", Cells: map[string]MatrixCell{
+				"2.9":  {},
+				"2.10": {Prod: true, Issue: MatrixOutOfLifecycle},
+			}},
+		}, matrix.Rows)
+		assert.Equal(t, map[string]int{MatrixForwardPortPending: 1, MatrixMissing: 1, MatrixOutOfLifecycle: 1}, matrix.Issues())
+	})
+
+	t.Run("#3 markdown", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, matrix.Write(&out, OutputMarkdown))
+
+		expected := "# Lifecycle matrix\n\n" +
+			"| Chart | Version | 2.7 | 2.8 | 2.9 | 2.10 |\n" +
+			"|---|---|---|---|---|---|\n" +
+			"| fleet | 105.0.0+up0.11.0 | | | - | prod dev |\n" +
+			"| fleet | 104.1.0+up0.10.1 | | | dev | dev **forward-port-pending** |\n" +
+			"| fleet | 104.0.0+up0.10.0 | | | prod dev | - **missing** |\n" +
+			"| fleet | 101.0.0+up0.7.0 | | | - | prod **out-of-lifecycle** |\n" +
+			"\nBranches not found: release-v2.7, dev-v2.7; release-v2.8, dev-v2.8\n" +
+			"\n## Issues\n\n" +
+			"- `fleet` `104.1.0+up0.10.1` at 2.10: forward-port-pending\n" +
+			"- `fleet` `104.0.0+up0.10.0` at 2.10: missing\n" +
+			"- `fleet` `101.0.0+up0.7.0` at 2.10: out-of-lifecycle\n"
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("#4 json", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, matrix.Write(&out, OutputJSON))
+
+		got := &Matrix{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), got))
+		assert.Equal(t, matrix, got)
+	})
+
+	t.Run("#5 filter by chart", func(t *testing.T) {
+		filtered, err := ld.LifecycleMatrix(ctx, "rancher-webhook")
+		assert.NoError(t, err)
+		assert.Empty(t, filtered.Rows)
+	})
+}
@@ -0,0 +1,194 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats for the lifecycle status and the state diff
+const (
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTable = "table"
+)
+
+// CheckOutputFormat returns an error if the format is not one of the supported output formats
+func CheckOutputFormat(format string) error {
+	switch format {
+	case OutputJSON, OutputYAML, OutputTable:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q; expected %s, %s or %s", format, OutputJSON, OutputYAML, OutputTable)
+	}
+}
+
+// category is a named group of assets of the Status, named after its state.json key
+type category struct {
+	name   string
+	assets map[string][]Asset
+}
+
+// categories returns the Status categories in the order they are computed
+func (s *Status) categories() []category {
+	return []category{
+		{"in_lifecycle_current_branch", s.AssetsInLifecycleCurrentBranch},
+		{"out_lifecycle_current_branch", s.AssetsOutLifecycleCurrentBranch},
+		{"released_in_lifecycle", s.AssetsReleasedInLifecycle},
+		{"not_released_out_lifecycle", s.AssetsNotReleasedOutLifecycle},
+		{"not_released_in_lifecycle", s.AssetsNotReleasedInLifecycle},
+		{"released_out_lifecycle", s.AssetsReleasedOutLifecycle},
+		{"to_be_released", s.AssetsToBeReleased},
+		{"to_be_forward_ported", s.AssetsToBeForwardPorted},
+	}
+}
+
+// Write writes the Status categories to w as json, yaml or a table
+func (s *Status) Write(w io.Writer, format string) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		return writeStructured(w, format, s)
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CATEGORY\tCHART\tVERSIONS")
+		for _, c := range s.categories() {
+			for _, chart := range sortedCharts(c.assets) {
+				if len(c.assets[chart]) == 0 {
+					continue
+				}
+				versions := make([]string, 0, len(c.assets[chart]))
+				for _, asset := range c.assets[chart] {
+					versions = append(versions, asset.Version)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", c.name, chart, strings.Join(versions, " "))
+			}
+		}
+		return tw.Flush()
+	default:
+		return CheckOutputFormat(format)
+	}
+}
+
+// StateChange is an asset version that moved between categories from one state to another
+type StateChange struct {
+	Chart   string   `json:"chart"`
+	Version string   `json:"version"`
+	Left    []string `json:"left,omitempty"`    // categories the asset version is no longer in
+	Entered []string `json:"entered,omitempty"` // categories the asset version is now in
+}
+
+// DiffStates compares two lifecycle states, e.g. before and after a release,
+// and returns the asset versions that moved between categories sorted by chart and version
+func DiffStates(before, after *Status) []StateChange {
+	beforeCategories := assetCategories(before)
+	afterCategories := assetCategories(after)
+
+	keys := make(map[[2]string]bool)
+	for key := range beforeCategories {
+		keys[key] = true
+	}
+	for key := range afterCategories {
+		keys[key] = true
+	}
+
+	changes := []StateChange{}
+	for key := range keys {
+		left := difference(beforeCategories[key], afterCategories[key])
+		entered := difference(afterCategories[key], beforeCategories[key])
+		if len(left) == 0 && len(entered) == 0 {
+			continue
+		}
+		changes = append(changes, StateChange{Chart: key[0], Version: key[1], Left: left, Entered: entered})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Chart != changes[j].Chart {
+			return changes[i].Chart < changes[j].Chart
+		}
+		return changes[i].Version < changes[j].Version
+	})
+
+	return changes
+}
+
+// WriteStateChanges writes the state changes to w as json, yaml or a table
+func WriteStateChanges(w io.Writer, format string, changes []StateChange) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		return writeStructured(w, format, changes)
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CHART\tVERSION\tLEFT\tENTERED")
+		for _, change := range changes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", change.Chart, change.Version, joinOrDash(change.Left), joinOrDash(change.Entered))
+		}
+		return tw.Flush()
+	default:
+		return CheckOutputFormat(format)
+	}
+}
+
+// assetCategories maps each chart version of the state to the categories it is in
+func assetCategories(s *Status) map[[2]string][]string {
+	categories := make(map[[2]string][]string)
+	for _, c := range s.categories() {
+		for chart, assets := range c.assets {
+			for _, asset := range assets {
+				key := [2]string{chart, asset.Version}
+				categories[key] = append(categories[key], c.name)
+			}
+		}
+	}
+	return categories
+}
+
+// difference returns the elements of a that are not in b, keeping the order of a
+func difference(a, b []string) []string {
+	var diff []string
+	for _, e := range a {
+		if !slices.Contains(b, e) {
+			diff = append(diff, e)
+		}
+	}
+	return diff
+}
+
+// writeStructured writes v to w as indented json or yaml
+func writeStructured(w io.Writer, format string, v any) error {
+	if format == OutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// sortedCharts returns the charts of the assets map sorted by name
+func sortedCharts(assets map[string][]Asset) []string {
+	charts := make([]string, 0, len(assets))
+	for chart := range assets {
+		charts = append(charts, chart)
+	}
+	sort.Strings(charts)
+	return charts
+}
+
+// joinOrDash joins the categories or returns "-" if there are none
+func joinOrDash(categories []string) string {
+	if len(categories) == 0 {
+		return "-"
+	}
+	return strings.Join(categories, ",")
+}
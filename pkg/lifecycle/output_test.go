@@ -0,0 +1,129 @@
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_StatusWrite(t *testing.T) {
+	status := &Status{
+		AssetsInLifecycleCurrentBranch: map[string][]Asset{
+			"fleet":    {{Version: "105.0.1+up0.11.1"}, {Version: "105.0.0+up0.11.0"}},
+			"longhorn": {{Version: "105.0.0+up1.7.0"}},
+		},
+		AssetsOutLifecycleCurrentBranch: map[string][]Asset{"fleet": {{Version: "102.0.0+up0.8.0"}}},
+		AssetsToBeReleased:              map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}}},
+		AssetsToBeForwardPorted:         map[string][]Asset{"longhorn": {}},
+	}
+
+	t.Run("#1 table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, status.Write(&out, OutputTable))
+
+		expected := "CATEGORY                      CHART     VERSIONS\n" +
+			"in_lifecycle_current_branch   fleet     105.0.1+up0.11.1 105.0.0+up0.11.0\n" +
+			"in_lifecycle_current_branch   longhorn  105.0.0+up1.7.0\n" +
+			"out_lifecycle_current_branch  fleet     102.0.0+up0.8.0\n" +
+			"to_be_released                fleet     105.0.1+up0.11.1\n"
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("#2 json", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, status.Write(&out, OutputJSON))
+
+		got := &Status{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), got))
+		assert.Equal(t, status, got)
+	})
+
+	t.Run("#3 yaml", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, status.Write(&out, OutputYAML))
+
+		got := &Status{}
+		assert.NoError(t, yaml.Unmarshal(out.Bytes(), got))
+		assert.Equal(t, status, got)
+	})
+
+	t.Run("#4 unknown format", func(t *testing.T) {
+		assert.EqualError(t, status.Write(&bytes.Buffer{}, "xml"), `unknown output format "xml"; expected json, yaml or table`)
+	})
+}
+
+func Test_DiffStates(t *testing.T) {
+	before := &Status{
+		AssetsInLifecycleCurrentBranch: map[string][]Asset{"fleet": {{Version: "105.0.0+up0.11.0"}}},
+		AssetsNotReleasedInLifecycle:   map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}}},
+		AssetsToBeReleased:             map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}}},
+		AssetsReleasedInLifecycle:      map[string][]Asset{"fleet": {{Version: "105.0.0+up0.11.0"}}},
+	}
+	after := &Status{
+		AssetsInLifecycleCurrentBranch: map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}, {Version: "105.0.0+up0.11.0"}}},
+		AssetsReleasedInLifecycle:      map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}, {Version: "105.0.0+up0.11.0"}}},
+		AssetsToBeForwardPorted:        map[string][]Asset{"longhorn": {{Version: "105.0.0+up1.7.0"}}},
+	}
+
+	t.Run("#1 assets moved between categories", func(t *testing.T) {
+		assert.Equal(t, []StateChange{
+			{
+				Chart:   "fleet",
+				Version: "105.0.1+up0.11.1",
+				Left:    []string{"not_released_in_lifecycle", "to_be_released"},
+				Entered: []string{"in_lifecycle_current_branch", "released_in_lifecycle"},
+			},
+			{
+				Chart:   "longhorn",
+				Version: "105.0.0+up1.7.0",
+				Entered: []string{"to_be_forward_ported"},
+			},
+		}, DiffStates(before, after))
+	})
+
+	t.Run("#2 same state", func(t *testing.T) {
+		assert.Empty(t, DiffStates(after, after))
+	})
+
+	t.Run("#3 table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, WriteStateChanges(&out, OutputTable, DiffStates(before, after)))
+
+		expected := "CHART     VERSION           LEFT                                      ENTERED\n" +
+			"fleet     105.0.1+up0.11.1  not_released_in_lifecycle,to_be_released  in_lifecycle_current_branch,released_in_lifecycle\n" +
+			"longhorn  105.0.0+up1.7.0   -                                         to_be_forward_ported\n"
+		assert.Equal(t, expected, out.String())
+	})
+}
+
+func Test_LoadStateFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	t.Run("#1 missing state file", func(t *testing.T) {
+		_, err := LoadStateFile(stateFile)
+		assert.Error(t, err)
+	})
+
+	t.Run("#2 saved state is loaded", func(t *testing.T) {
+		status := &Status{
+			StateFile:          stateFile,
+			AssetsToBeReleased: map[string][]Asset{"fleet": {{Version: "105.0.1+up0.11.1"}}},
+		}
+		assert.NoError(t, status.SaveState())
+
+		loaded, err := LoadStateFile(stateFile)
+		assert.NoError(t, err)
+		assert.Equal(t, status, loaded)
+	})
+
+	t.Run("#3 invalid state file", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(stateFile, []byte("{"), 0644))
+		_, err := LoadStateFile(stateFile)
+		assert.Error(t, err)
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-git/go-billy/v5"
 	helmRepo "helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
 )
 
 // getAssetsMapFromIndex returns a map of assets with their version and
@@ -18,6 +19,23 @@ func getAssetsMapFromIndex(absRepositoryHelmIndexFile, currentChart string) (map
 		return nil, fmt.Errorf("encountered error while trying to load existing index file: %s", err)
 	}
 
+	return assetsMapFromIndexFile(helmIndexFile, currentChart)
+}
+
+// getAssetsMapFromIndexData is the same as getAssetsMapFromIndex for an index file
+// read from git instead of the filesystem
+func getAssetsMapFromIndexData(data []byte, currentChart string) (map[string][]Asset, error) {
+	helmIndexFile := &helmRepo.IndexFile{}
+	if err := yaml.Unmarshal(data, helmIndexFile); err != nil {
+		return nil, fmt.Errorf("encountered error while trying to parse index file: %s", err)
+	}
+	helmIndexFile.SortEntries()
+
+	return assetsMapFromIndexFile(helmIndexFile, currentChart)
+}
+
+// assetsMapFromIndexFile returns the map of assets versions of all charts or only of the current chart
+func assetsMapFromIndexFile(helmIndexFile *helmRepo.IndexFile, currentChart string) (map[string][]Asset, error) {
 	var assetsMap = make(map[string][]Asset)
 	var annotatedVersions []Asset
 
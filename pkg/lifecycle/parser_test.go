@@ -3,6 +3,7 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/go-git/go-billy/v5"
@@ -45,6 +46,29 @@ func Test_getAssetsMapFromIndex(t *testing.T) {
 	})
 }
 
+func Test_getAssetsMapFromIndexData(t *testing.T) {
+	data, err := os.ReadFile("mocks/test.yaml")
+	assert.NoError(t, err)
+
+	t.Run("Fail to parse Index File", func(t *testing.T) {
+		_, err := getAssetsMapFromIndexData([]byte("entries: ["), "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Load all charts successfully", func(t *testing.T) {
+		fromData, err := getAssetsMapFromIndexData(data, "")
+		assert.NoError(t, err)
+		fromFile, err := getAssetsMapFromIndex("mocks/test.yaml", "")
+		assert.NoError(t, err)
+		assert.Equal(t, fromFile, fromData)
+	})
+
+	t.Run("Fail to load target chart (chart-zero)", func(t *testing.T) {
+		_, err := getAssetsMapFromIndexData(data, "chart-zero")
+		assert.Error(t, err)
+	})
+}
+
 func Test_populateAssetsVersionsPath(t *testing.T) {
 	t.Run("Populate assets versions map successfully", func(t *testing.T) {
 		ctx := context.Background()
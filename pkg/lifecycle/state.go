@@ -3,6 +3,7 @@ package lifecycle
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 
@@ -30,7 +31,11 @@ func (s *Status) SaveState() error {
 // LoadState will load the lifecycle-status state from an existing state.json file at charts repo
 func LoadState(rootFs billy.Filesystem) (*Status, error) {
 	// get the absolute path for the state.json file
-	stateFilePath := filesystem.GetAbsPath(rootFs, path.RepositoryStateFile)
+	return LoadStateFile(filesystem.GetAbsPath(rootFs, path.RepositoryStateFile))
+}
+
+// LoadStateFile will load a lifecycle-status state from the given state.json file, e.g. a saved snapshot
+func LoadStateFile(stateFilePath string) (*Status, error) {
 	s := &Status{
 		StateFile: stateFilePath,
 	}
@@ -40,7 +45,7 @@ func LoadState(rootFs billy.Filesystem) (*Status, error) {
 		return nil, err
 	}
 	if !exist {
-		return nil, errors.New("state.json file does not exist")
+		return nil, fmt.Errorf("state file does not exist: %s", stateFilePath)
 	}
 
 	// Read the file content
@@ -2,9 +2,11 @@ package lifecycle
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 
-	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
 )
 
@@ -72,6 +74,34 @@ func createLogFiles(ctx context.Context, chart string) (*Logs, *Logs, *Logs, err
 	return cbLogs, pdLogs, rfLogs, nil
 }
 
+// CheckLifecycleStatus checks the lifecycle status of the assets without saving logs or the state file,
+// optionally filtered by a specific chart.
+func (ld *Dependencies) CheckLifecycleStatus(ctx context.Context, chart string) (*Status, error) {
+	status, err := ld.getStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status.filterByChart(chart)
+	return status, nil
+}
+
+// filterByChart keeps only the given chart in every assets map; an empty chart keeps all of them.
+func (s *Status) filterByChart(chart string) {
+	if chart == "" {
+		return
+	}
+
+	s.AssetsInLifecycleCurrentBranch = map[string][]Asset{chart: s.AssetsInLifecycleCurrentBranch[chart]}
+	s.AssetsOutLifecycleCurrentBranch = map[string][]Asset{chart: s.AssetsOutLifecycleCurrentBranch[chart]}
+	s.AssetsReleasedInLifecycle = map[string][]Asset{chart: s.AssetsReleasedInLifecycle[chart]}
+	s.AssetsNotReleasedOutLifecycle = map[string][]Asset{chart: s.AssetsNotReleasedOutLifecycle[chart]}
+	s.AssetsNotReleasedInLifecycle = map[string][]Asset{chart: s.AssetsNotReleasedInLifecycle[chart]}
+	s.AssetsReleasedOutLifecycle = map[string][]Asset{chart: s.AssetsReleasedOutLifecycle[chart]}
+	s.AssetsToBeReleased = map[string][]Asset{chart: s.AssetsToBeReleased[chart]}
+	s.AssetsToBeForwardPorted = map[string][]Asset{chart: s.AssetsToBeForwardPorted[chart]}
+}
+
 // CheckLifecycleStatusAndSave checks the lifecycle status of the assets
 // at 3 different levels prints to the console and saves to log files at 'logs/' folder.
 func (ld *Dependencies) CheckLifecycleStatusAndSave(ctx context.Context, chart string) (*Status, error) {
@@ -91,16 +121,7 @@ func (ld *Dependencies) CheckLifecycleStatusAndSave(ctx context.Context, chart s
 	defer rfLogs.File.Close()
 
 	// optional filter logs by specific chart
-	if chart != "" {
-		status.AssetsInLifecycleCurrentBranch = map[string][]Asset{chart: status.AssetsInLifecycleCurrentBranch[chart]}
-		status.AssetsOutLifecycleCurrentBranch = map[string][]Asset{chart: status.AssetsOutLifecycleCurrentBranch[chart]}
-		status.AssetsReleasedInLifecycle = map[string][]Asset{chart: status.AssetsReleasedInLifecycle[chart]}
-		status.AssetsNotReleasedOutLifecycle = map[string][]Asset{chart: status.AssetsNotReleasedOutLifecycle[chart]}
-		status.AssetsNotReleasedInLifecycle = map[string][]Asset{chart: status.AssetsNotReleasedInLifecycle[chart]}
-		status.AssetsReleasedOutLifecycle = map[string][]Asset{chart: status.AssetsReleasedOutLifecycle[chart]}
-		status.AssetsToBeReleased = map[string][]Asset{chart: status.AssetsToBeReleased[chart]}
-		status.AssetsToBeForwardPorted = map[string][]Asset{chart: status.AssetsToBeForwardPorted[chart]}
-	}
+	status.filterByChart(chart)
 
 	// ##############################################################################
 	// Save the logs for the current branch
@@ -160,7 +181,7 @@ func (s *Status) listCurrentAssetsVersionsOnTheCurrentBranch() {
 
 	for asset, versions := range s.ld.AssetsVersionsMap {
 		for _, version := range versions {
-			inLifecycle := s.ld.VR.CheckChartVersionForLifecycle(version.Version)
+			inLifecycle := s.ld.VR.CheckChartVersionForLifecycle(asset, version.Version)
 			if inLifecycle {
 				insideLifecycle[asset] = append(insideLifecycle[asset], version)
 			} else {
@@ -175,22 +196,12 @@ func (s *Status) listCurrentAssetsVersionsOnTheCurrentBranch() {
 	return
 }
 
-// listProdAndDevAssets will clone the charts repository at a temporary directory,
-// fetch and checkout in the production and development branches for the given version,
-// get the assets versions from the index.yaml file and compare the assets versions,
+// listProdAndDevAssets will fetch the production and development branches for the given version,
+// get the assets versions from their index.yaml files and compare the assets versions,
 // separating into 4 different maps for further analysis.
 func (s *Status) listProdAndDevAssets(ctx context.Context) error {
-
-	// Open current charts git repository
-	git, err := git.OpenGitRepo(ctx, s.ld.Git.Dir)
-	if err != nil {
-		return err
-	}
-
-	oldCurrentBranch := git.Branch
-
-	// Fetch, checkout and map assets versions in the production and development branches
-	releasedAssets, devAssets, err := s.getProdAndDevAssetsFromGit(ctx, git)
+	// Fetch and map assets versions in the production and development branches
+	releasedAssets, devAssets, err := s.getProdAndDevAssetsFromGit(ctx, s.ld.Git)
 	if err != nil {
 		return err
 	}
@@ -198,49 +209,42 @@ func (s *Status) listProdAndDevAssets(ctx context.Context) error {
 	// Compare the assets versions between the production and development branches
 	s.compareReleasedAndDevAssets(releasedAssets, devAssets)
 
-	return git.CheckoutBranch(oldCurrentBranch)
+	return nil
 }
 
-// getProdAndDevAssetsFromGit will fetch and checkout the production and development branches,
-// get the assets versions from the index.yaml file and return the maps for the assets versions.
+// getProdAndDevAssetsFromGit will fetch the production and development branches,
+// read their index.yaml files straight from the git object store and return the maps for the assets versions.
+// Nothing is checked out, so the working tree and the current branch are never touched.
 func (s *Status) getProdAndDevAssetsFromGit(ctx context.Context, git *git.Git) (map[string][]Asset, map[string][]Asset, error) {
-	// get filesystem and index file at the temporary directory
-	rootFs := filesystem.GetFilesystem(s.ld.Git.Dir)
-	helmIndexPath := filesystem.GetAbsPath(rootFs, path.RepositoryHelmIndexFile)
-
-	if s.ld.Git.Branch == s.ld.VR.ProdBranch {
-		// Fetch and checkout to the production branch
-		err := git.FetchAndPullBranch(ctx, s.ld.VR.ProdBranch)
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		// Fetch and checkout to the production branch
-		err := git.FetchAndCheckoutBranch(ctx, s.ld.VR.ProdBranch)
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
 	// Get the map for the released assets versions on the production branch
-	releasedAssets, err := getAssetsMapFromIndex(helmIndexPath, "")
+	releasedAssets, err := getAssetsMapFromBranch(ctx, git, s.ld.VR.ProdBranch)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Fetch and checkout to the development branch
-	err = git.FetchAndCheckoutBranch(ctx, s.ld.VR.DevBranch)
+	// Get the map for the development assets versions on the development branch
+	devAssets, err := getAssetsMapFromBranch(ctx, git, s.ld.VR.DevBranch)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Get the map for the development assets versions on the development branch
-	devAssets, err := getAssetsMapFromIndex(helmIndexPath, "")
+	return releasedAssets, devAssets, nil
+}
+
+// getAssetsMapFromBranch fetches the upstream branch and returns the assets versions map of its index.yaml
+func getAssetsMapFromBranch(ctx context.Context, git *git.Git, branch string) (map[string][]Asset, error) {
+	logger.Log(ctx, slog.LevelInfo, "fetching index.yaml", slog.String("branch", branch))
+
+	if err := git.FetchRemoteBranch(branch); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", branch, err)
+	}
+
+	data, err := git.ShowFile(branch, path.RepositoryHelmIndexFile)
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("failed to read %s from %s: %w", path.RepositoryHelmIndexFile, branch, err)
 	}
 
-	return releasedAssets, devAssets, nil
+	return getAssetsMapFromIndexData(data, "")
 }
 
 // compareReleasedAndDevAssets will compare the assets versions between
@@ -266,7 +270,7 @@ func (s *Status) compareReleasedAndDevAssets(releasedAssets, developmentAssets m
 			// check if the version is already released
 			released := checkIfVersionIsReleased(devVersion.Version, releasedVersions)
 			// check if the version is in the lifecycle
-			inLifecycle := s.ld.VR.CheckChartVersionForLifecycle(devVersion.Version)
+			inLifecycle := s.ld.VR.CheckChartVersionForLifecycle(devAsset, devVersion.Version)
 
 			switch {
 			case released && inLifecycle:
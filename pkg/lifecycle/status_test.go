@@ -0,0 +1,68 @@
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/git"
+	"github.com/rancher/charts-build-scripts/pkg/git/gittest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newIndexTestRepos creates an upstream repository with a branch for each given index.yaml
+// and a local clone of it checked out at main.
+func newIndexTestRepos(t *testing.T, indexes map[string]string) (string, string) {
+	t.Helper()
+	upstreamDir := filepath.Join(t.TempDir(), "upstream")
+	localDir := filepath.Join(t.TempDir(), "local")
+
+	gittest.NewRepo(t, upstreamDir, "main", map[string]string{"README.md": "charts\n"})
+	for branch, index := range indexes {
+		gittest.Run(t, upstreamDir, "checkout", "-b", branch, "main")
+		gittest.Commit(t, upstreamDir, "index.yaml", map[string]string{"index.yaml": index})
+	}
+	gittest.Run(t, upstreamDir, "checkout", "main")
+	gittest.Clone(t, upstreamDir, localDir, "main")
+
+	return upstreamDir, localDir
+}
+
+func Test_getProdAndDevAssetsFromGit(t *testing.T) {
+	ctx := context.Background()
+	upstreamDir, localDir := newIndexTestRepos(t, map[string]string{
+		"release-v2.9": "apiVersion: v1\nentries:\n  chart-one:\n  - name: chart-one\n    version: 104.0.0+up1.0.0\n",
+		"dev-v2.9":     "apiVersion: v1\nentries:\n  chart-one:\n  - name: chart-one\n    version: 104.0.0+up1.0.0\n  - name: chart-one\n    version: 104.1.0+up1.1.0\n  chart-two:\n  - name: chart-two\n    version: 104.0.0+up2.0.0\n",
+	})
+	// the working tree is dirty
+	assert.NoError(t, os.WriteFile(filepath.Join(localDir, "README.md"), []byte("changed\n"), 0644))
+
+	g := &git.Git{
+		Dir:         localDir,
+		Branch:      "main",
+		Remotes:     map[string]string{upstreamDir: "upstream"},
+		UpstreamURL: upstreamDir,
+	}
+	s := &Status{ld: &Dependencies{Git: g, VR: &VersionRules{ProdBranch: "release-v2.9", DevBranch: "dev-v2.9"}}}
+
+	t.Run("#1 read index.yaml from the fetched branches", func(t *testing.T) {
+		released, dev, err := s.getProdAndDevAssetsFromGit(ctx, g)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]Asset{"chart-one": {{Version: "104.0.0+up1.0.0"}}}, released)
+		assert.Equal(t, map[string][]Asset{
+			"chart-one": {{Version: "104.1.0+up1.1.0"}, {Version: "104.0.0+up1.0.0"}},
+			"chart-two": {{Version: "104.0.0+up2.0.0"}},
+		}, dev)
+
+		// nothing was checked out and the changes were kept
+		assert.Equal(t, "main\n", gittest.Run(t, localDir, "branch", "--format=%(refname:short)"))
+		assert.Equal(t, " M README.md\n", gittest.Run(t, localDir, "status", "--porcelain"))
+	})
+
+	t.Run("#2 branch not found", func(t *testing.T) {
+		s.ld.VR.DevBranch = "dev-v3.0"
+		_, _, err := s.getProdAndDevAssetsFromGit(ctx, g)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,257 @@
+package lifecycle
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver"
+)
+
+// Upgrade path rules checked for every chart in the index.yaml
+const (
+	UpgradePathScheme            = "scheme"             // the version does not follow the 10X.Y.Z+upA.B.C repo-prefix scheme
+	UpgradePathNotMonotonic      = "not-monotonic"      // a newer repo prefix holds an older upstream version
+	UpgradePathDuplicateUpstream = "duplicate-upstream" // the same upstream version is released under different repo prefixes
+	UpgradePathCRDMismatch       = "crd-mismatch"       // the CRD chart versions do not match the versions of its main chart
+	UpgradePathNewerBranch       = "newer-branch"       // the version belongs to a newer branch line than the current branch
+)
+
+// UpgradePathViolation is a broken upgrade path rule with the index.yaml entries that break it
+type UpgradePathViolation struct {
+	Chart    string   `json:"chart"`
+	Rule     string   `json:"rule"`
+	Versions []string `json:"versions"`
+	Message  string   `json:"message"`
+}
+
+// repoPrefixVersion is a chart version split into its repository prefix and upstream version
+type repoPrefixVersion struct {
+	version  string
+	prefix   *semver.Version
+	upstream *semver.Version
+}
+
+// ValidateUpgradePaths checks the upgrade path of every chart in the index.yaml of the current branch,
+// optionally filtered by a specific chart and its CRD chart:
+//
+//	every version follows the 10X.Y.Z+upA.B.C repo-prefix scheme
+//	a newer repo prefix never holds an older upstream version
+//	an upstream version is never released under two different repo prefixes
+//	a CRD chart (e.g. fleet-crd) has the same versions as its main chart (e.g. fleet)
+//	the branch does not hold versions of a newer branch line
+func (ld *Dependencies) ValidateUpgradePaths(chart string) []UpgradePathViolation {
+	violations := validateUpgradePaths(ld.VR, ld.AssetsVersionsMap)
+	if chart == "" {
+		return violations
+	}
+
+	filtered := []UpgradePathViolation{}
+	for _, violation := range violations {
+		if violation.Chart == chart || violation.Chart == chart+"-crd" || violation.Chart+"-crd" == chart {
+			filtered = append(filtered, violation)
+		}
+	}
+	return filtered
+}
+
+// validateUpgradePaths returns the upgrade path violations of the assets sorted by chart
+func validateUpgradePaths(vr *VersionRules, assets map[string][]Asset) []UpgradePathViolation {
+	violations := []UpgradePathViolation{}
+
+	for _, chart := range sortedCharts(assets) {
+		var versions []repoPrefixVersion
+		for _, asset := range assets[chart] {
+			v, err := parseRepoPrefixVersion(asset.Version)
+			if err != nil {
+				violations = append(violations, UpgradePathViolation{
+					Chart:    chart,
+					Rule:     UpgradePathScheme,
+					Versions: []string{asset.Version},
+					Message:  err.Error(),
+				})
+				continue
+			}
+			versions = append(versions, v)
+		}
+
+		violations = append(violations, checkMonotonic(chart, versions)...)
+		violations = append(violations, checkDuplicateUpstream(chart, versions)...)
+		violations = append(violations, checkCRDVersions(chart, assets)...)
+		violations = append(violations, checkNewerBranch(vr, chart, assets[chart])...)
+	}
+
+	return violations
+}
+
+// parseRepoPrefixVersion splits a 10X.Y.Z+upA.B.C chart version into its repo prefix and upstream version
+func parseRepoPrefixVersion(version string) (repoPrefixVersion, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return repoPrefixVersion{}, fmt.Errorf("invalid semver version %s: %w", version, err)
+	}
+	upstream, found := strings.CutPrefix(v.Metadata(), "up")
+	if !found {
+		return repoPrefixVersion{}, fmt.Errorf("version %s has no +up upstream version", version)
+	}
+	upstreamVersion, err := semver.NewVersion(upstream)
+	if err != nil {
+		return repoPrefixVersion{}, fmt.Errorf("invalid upstream version %s in %s: %w", upstream, version, err)
+	}
+	// the build metadata is ignored when comparing versions, so v is compared as the repo prefix
+	return repoPrefixVersion{version: version, prefix: v, upstream: upstreamVersion}, nil
+}
+
+// checkMonotonic walks the versions from the oldest to the newest repo prefix and
+// reports every version with an older upstream version than a version with an older repo prefix
+func checkMonotonic(chart string, versions []repoPrefixVersion) []UpgradePathViolation {
+	sorted := make([]repoPrefixVersion, len(versions))
+	copy(sorted, versions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].prefix.LessThan(sorted[j].prefix)
+	})
+
+	var violations []UpgradePathViolation
+	var newest *repoPrefixVersion // the newest upstream version of the older repo prefixes
+	for i := 0; i < len(sorted); {
+		// versions with the same repo prefix, e.g. an rc and its final release, are not compared with each other
+		j := i
+		for j < len(sorted) && sorted[j].prefix.Equal(sorted[i].prefix) {
+			j++
+		}
+		group := sorted[i:j]
+
+		for _, v := range group {
+			if newest != nil && v.upstream.LessThan(newest.upstream) {
+				violations = append(violations, UpgradePathViolation{
+					Chart:    chart,
+					Rule:     UpgradePathNotMonotonic,
+					Versions: []string{newest.version, v.version},
+					Message:  fmt.Sprintf("%s has an older upstream version than %s", v.version, newest.version),
+				})
+			}
+		}
+		for k := range group {
+			if newest == nil || group[k].upstream.GreaterThan(newest.upstream) {
+				newest = &group[k]
+			}
+		}
+		i = j
+	}
+	return violations
+}
+
+// checkDuplicateUpstream reports every upstream version released under more than one repo prefix
+func checkDuplicateUpstream(chart string, versions []repoPrefixVersion) []UpgradePathViolation {
+	byUpstream := make(map[string][]string)
+	var upstreams []string
+	for _, v := range versions {
+		upstream := v.upstream.Original()
+		if _, ok := byUpstream[upstream]; !ok {
+			upstreams = append(upstreams, upstream)
+		}
+		byUpstream[upstream] = append(byUpstream[upstream], v.version)
+	}
+
+	var violations []UpgradePathViolation
+	for _, upstream := range upstreams {
+		if len(byUpstream[upstream]) < 2 {
+			continue
+		}
+		violations = append(violations, UpgradePathViolation{
+			Chart:    chart,
+			Rule:     UpgradePathDuplicateUpstream,
+			Versions: byUpstream[upstream],
+			Message:  fmt.Sprintf("upstream version %s is released under %d repo prefixes", upstream, len(byUpstream[upstream])),
+		})
+	}
+	return violations
+}
+
+// checkCRDVersions reports the versions of a CRD chart that are not in its main chart and the other way around
+func checkCRDVersions(chart string, assets map[string][]Asset) []UpgradePathViolation {
+	mainChart, isCRD := strings.CutSuffix(chart, "-crd")
+	if !isCRD {
+		return nil
+	}
+	mainAssets, ok := assets[mainChart]
+	if !ok {
+		return nil
+	}
+
+	var violations []UpgradePathViolation
+	if missing := missingVersions(assets[chart], mainAssets); len(missing) > 0 {
+		violations = append(violations, UpgradePathViolation{
+			Chart:    chart,
+			Rule:     UpgradePathCRDMismatch,
+			Versions: missing,
+			Message:  fmt.Sprintf("versions of %s not found in %s", chart, mainChart),
+		})
+	}
+	if missing := missingVersions(mainAssets, assets[chart]); len(missing) > 0 {
+		violations = append(violations, UpgradePathViolation{
+			Chart:    chart,
+			Rule:     UpgradePathCRDMismatch,
+			Versions: missing,
+			Message:  fmt.Sprintf("versions of %s not found in %s", mainChart, chart),
+		})
+	}
+	return violations
+}
+
+// missingVersions returns the versions of a that are not in b
+func missingVersions(a, b []Asset) []string {
+	var missing []string
+	for _, asset := range a {
+		if !checkIfVersionIsReleased(asset.Version, b) {
+			missing = append(missing, asset.Version)
+		}
+	}
+	return missing
+}
+
+// checkNewerBranch reports the versions at or above the max version of the current branch
+func checkNewerBranch(vr *VersionRules, chart string, assets []Asset) []UpgradePathViolation {
+	if vr == nil || vr.Rules[vr.BranchVersion].Max == "" {
+		return nil
+	}
+	max := vr.Rules[vr.BranchVersion].Max
+
+	var violations []UpgradePathViolation
+	for _, asset := range assets {
+		cmp, err := compareVersions(asset.Version, max)
+		if err != nil || cmp < 0 {
+			continue
+		}
+		branch := vr.BranchOf(asset.Version)
+		if branch == "" {
+			branch = "unknown"
+		}
+		violations = append(violations, UpgradePathViolation{
+			Chart:    chart,
+			Rule:     UpgradePathNewerBranch,
+			Versions: []string{asset.Version},
+			Message:  fmt.Sprintf("%s belongs to branch %s, newer than %s (max %s)", asset.Version, branch, vr.BranchVersion, max),
+		})
+	}
+	return violations
+}
+
+// WriteUpgradePathViolations writes the upgrade path violations to w as json, yaml or a table
+func WriteUpgradePathViolations(w io.Writer, format string, violations []UpgradePathViolation) error {
+	switch format {
+	case OutputJSON, OutputYAML:
+		return writeStructured(w, format, violations)
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CHART\tRULE\tVERSIONS\tMESSAGE")
+		for _, violation := range violations {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", violation.Chart, violation.Rule, strings.Join(violation.Versions, " "), violation.Message)
+		}
+		return tw.Flush()
+	default:
+		return CheckOutputFormat(format)
+	}
+}
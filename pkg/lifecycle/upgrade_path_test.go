@@ -0,0 +1,118 @@
+package lifecycle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validateUpgradePaths(t *testing.T) {
+	vr := &VersionRules{
+		BranchVersion: "2.9",
+		Rules: map[string]Version{
+			"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+			"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			"2.10": {Min: "105.0.0", Max: "106.0.0"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		assets   map[string][]Asset
+		expected []UpgradePathViolation
+	}{
+		{
+			name: "#1 valid upgrade path with rc and crd chart",
+			assets: map[string][]Asset{
+				"fleet":     {{Version: "104.1.0+up0.10.1"}, {Version: "104.1.0+up0.10.1-rc.1"}, {Version: "104.0.0+up0.10.0"}, {Version: "103.0.0+up0.9.0"}},
+				"fleet-crd": {{Version: "104.1.0+up0.10.1"}, {Version: "104.1.0+up0.10.1-rc.1"}, {Version: "104.0.0+up0.10.0"}, {Version: "103.0.0+up0.9.0"}},
+			},
+			expected: []UpgradePathViolation{},
+		},
+		{
+			name: "#2 invalid scheme",
+			assets: map[string][]Asset{
+				"rancher-webhook": {{Version: "104.0.0"}, {Version: "v0.5.0"}},
+			},
+			expected: []UpgradePathViolation{
+				{Chart: "rancher-webhook", Rule: UpgradePathScheme, Versions: []string{"104.0.0"}, Message: "version 104.0.0 has no +up upstream version"},
+				{Chart: "rancher-webhook", Rule: UpgradePathScheme, Versions: []string{"v0.5.0"}, Message: "version v0.5.0 has no +up upstream version"},
+			},
+		},
+		{
+			name: "#3 newer repo prefix with older upstream version",
+			assets: map[string][]Asset{
+				"longhorn": {{Version: "104.1.0+up1.6.0"}, {Version: "104.0.1+up1.7.1"}, {Version: "104.0.0+up1.7.0"}},
+			},
+			expected: []UpgradePathViolation{
+				{Chart: "longhorn", Rule: UpgradePathNotMonotonic, Versions: []string{"104.0.1+up1.7.1", "104.1.0+up1.6.0"}, Message: "104.1.0+up1.6.0 has an older upstream version than 104.0.1+up1.7.1"},
+			},
+		},
+		{
+			name: "#4 upstream version under two repo prefixes",
+			assets: map[string][]Asset{
+				"longhorn": {{Version: "104.0.1+up1.7.0"}, {Version: "104.0.0+up1.7.0"}},
+			},
+			expected: []UpgradePathViolation{
+				{Chart: "longhorn", Rule: UpgradePathDuplicateUpstream, Versions: []string{"104.0.1+up1.7.0", "104.0.0+up1.7.0"}, Message: "upstream version 1.7.0 is released under 2 repo prefixes"},
+			},
+		},
+		{
+			name: "#5 crd chart versions do not match the main chart",
+			assets: map[string][]Asset{
+				"fleet":     {{Version: "104.1.0+up0.10.1"}, {Version: "104.0.0+up0.10.0"}},
+				"fleet-crd": {{Version: "104.0.0+up0.10.0"}, {Version: "103.0.0+up0.9.0"}},
+			},
+			expected: []UpgradePathViolation{
+				{Chart: "fleet-crd", Rule: UpgradePathCRDMismatch, Versions: []string{"103.0.0+up0.9.0"}, Message: "versions of fleet-crd not found in fleet"},
+				{Chart: "fleet-crd", Rule: UpgradePathCRDMismatch, Versions: []string{"104.1.0+up0.10.1"}, Message: "versions of fleet not found in fleet-crd"},
+			},
+		},
+		{
+			name: "#6 version of a newer branch line",
+			assets: map[string][]Asset{
+				"fleet": {{Version: "105.0.0+up0.11.0"}, {Version: "104.0.0+up0.10.0"}},
+			},
+			expected: []UpgradePathViolation{
+				{Chart: "fleet", Rule: UpgradePathNewerBranch, Versions: []string{"105.0.0+up0.11.0"}, Message: "105.0.0+up0.11.0 belongs to branch 2.10, newer than 2.9 (max 105.0.0)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, validateUpgradePaths(vr, tt.assets))
+		})
+	}
+}
+
+func Test_ValidateUpgradePaths(t *testing.T) {
+	ld := &Dependencies{
+		VR: &VersionRules{BranchVersion: "2.9", Rules: map[string]Version{"2.9": {Min: "104.0.0", Max: "105.0.0"}}},
+		AssetsVersionsMap: map[string][]Asset{
+			"fleet":     {{Version: "104.1.0+up0.10.1"}, {Version: "104.0.0+up0.10.0"}},
+			"fleet-crd": {{Version: "104.0.0+up0.10.0"}},
+			"longhorn":  {{Version: "104.0.0"}},
+		},
+	}
+
+	t.Run("#1 filter by main chart keeps the crd chart", func(t *testing.T) {
+		violations := ld.ValidateUpgradePaths("fleet")
+		assert.Len(t, violations, 1)
+		assert.Equal(t, "fleet-crd", violations[0].Chart)
+	})
+
+	t.Run("#2 all charts", func(t *testing.T) {
+		assert.Len(t, ld.ValidateUpgradePaths(""), 2)
+	})
+
+	t.Run("#3 table", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, WriteUpgradePathViolations(&out, OutputTable, ld.ValidateUpgradePaths("longhorn")))
+
+		expected := "CHART     RULE    VERSIONS  MESSAGE\n" +
+			"longhorn  scheme  104.0.0   version 104.0.0 has no +up upstream version\n"
+		assert.Equal(t, expected, out.String())
+	})
+}
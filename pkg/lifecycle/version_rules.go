@@ -7,29 +7,46 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/Masterminds/semver"
 	"github.com/go-git/go-billy/v5"
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 )
 
 var (
 	errorNoBranchVersion         error = errors.New("branch version not provided")
 	errorBranchVersionNotInRules error = errors.New("the given branch version is not defined in the rules")
+	errorInconsistentRules       error = errors.New("inconsistent version rules")
 )
 
+// defaultRetention is how many previous branch versions a branch keeps when the rules do not say otherwise
+const defaultRetention = 2
+
 // Version holds the maximum and minimum limits allowed for a specific branch version
 type Version struct {
 	Min string `json:"min"`
 	Max string `json:"max"`
+	// Retention is how many previous branch versions are kept in this branch lifecycle
+	Retention *int `json:"retention,omitempty"`
+	// Charts overrides the retention of specific charts in this branch lifecycle
+	Charts map[string]int `json:"charts,omitempty"`
+	// Keep lists chart versions intentionally kept in this branch even outside the lifecycle window;
+	// an empty list keeps every version of the chart
+	Keep map[string][]string `json:"keep,omitempty"`
 }
 
 // VersionRules will hold all the necessary information to check which assets versions are allowed to be in the repository
 type VersionRules struct {
 	Rules            map[string]Version `json:"rules"`
+	Retention        *int               `json:"retention,omitempty"` // default retention for every branch
+	Charts           map[string]int     `json:"charts,omitempty"`    // default retention overrides for specific charts
 	BranchVersion    string             `json:"branch-version,omitempty"`
 	MinVersion       int                `json:"min-version,omitempty"`
 	MaxVersion       int                `json:"max-version,omitempty"`
@@ -55,6 +72,10 @@ func (d *Dependencies) rules(ctx context.Context, branchVersion string, jsonLoad
 		return nil, errorBranchVersionNotInRules
 	}
 
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
 	v.BranchVersion = branchVersion
 
 	// Calculate the min and maximum versions allowed for the current branch version lifecycle
@@ -93,15 +114,208 @@ func loadFromJSON(ctx context.Context, fs billy.Filesystem) (*VersionRules, erro
 	return vr, nil
 }
 
+// LoadVersionRules loads and validates the version rules of the charts repository without selecting a branch version.
+// It returns nil if the repository has no version_rules.json.
+func LoadVersionRules(ctx context.Context, fs billy.Filesystem) (*VersionRules, error) {
+	v, err := loadFromJSON(ctx, fs)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// BranchOf returns the branch version whose range holds the chart version, or "" if none does
+func (v *VersionRules) BranchOf(chartVersion string) string {
+	for branch, rule := range v.Rules {
+		if inRange, err := versionInRange(chartVersion, rule.Min, rule.Max); err == nil && inRange {
+			return branch
+		}
+	}
+	return ""
+}
+
+// NewerBranches returns the branch versions newer than branch, from the oldest to the newest
+func (v *VersionRules) NewerBranches(branch string) []string {
+	branches, err := v.sortedBranchVersions()
+	if err != nil {
+		return nil
+	}
+	for i, b := range branches {
+		if b == branch {
+			return branches[i+1:]
+		}
+	}
+	return nil
+}
+
+// Validate checks that the rules are consistent:
+// every branch version is <major>.<minor>, every range has min < max,
+// the ranges of consecutive branch versions do not overlap and have no gaps,
+// and the retentions are not negative.
+func (v *VersionRules) Validate() error {
+	branches, err := v.sortedBranchVersions()
+	if err != nil {
+		return err
+	}
+
+	if err := checkRetention("default", v.Retention, v.Charts); err != nil {
+		return err
+	}
+
+	for i, branch := range branches {
+		rule := v.Rules[branch]
+		if err := checkRetention(branch, rule.Retention, rule.Charts); err != nil {
+			return err
+		}
+
+		if rule.Min != "" && rule.Max != "" {
+			cmp, err := compareVersions(rule.Min, rule.Max)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", errorInconsistentRules, branch, err)
+			}
+			if cmp >= 0 {
+				return fmt.Errorf("%w: %s: min %s must be lower than max %s", errorInconsistentRules, branch, rule.Min, rule.Max)
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+		previous := branches[i-1]
+		previousMax := v.Rules[previous].Max
+		if previousMax == "" || rule.Min == "" {
+			return fmt.Errorf("%w: %s and %s: only the oldest branch can have no min and only the newest branch can have no max", errorInconsistentRules, previous, branch)
+		}
+		cmp, err := compareVersions(previousMax, rule.Min)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errorInconsistentRules, branch, err)
+		}
+		if cmp > 0 {
+			return fmt.Errorf("%w: %s (max %s) overlaps %s (min %s)", errorInconsistentRules, previous, previousMax, branch, rule.Min)
+		}
+		if cmp < 0 {
+			return fmt.Errorf("%w: gap between %s (max %s) and %s (min %s)", errorInconsistentRules, previous, previousMax, branch, rule.Min)
+		}
+	}
+
+	return nil
+}
+
+// checkRetention returns an error if any of the retentions is negative
+func checkRetention(branch string, retention *int, charts map[string]int) error {
+	if retention != nil && *retention < 0 {
+		return fmt.Errorf("%w: %s: negative retention %d", errorInconsistentRules, branch, *retention)
+	}
+	for chart, r := range charts {
+		if r < 0 {
+			return fmt.Errorf("%w: %s: negative retention %d for chart %s", errorInconsistentRules, branch, r, chart)
+		}
+	}
+	return nil
+}
+
+// sortedBranchVersions returns the branch versions of the rules from the oldest to the newest
+func (v *VersionRules) sortedBranchVersions() ([]string, error) {
+	type branch struct {
+		name         string
+		major, minor int
+	}
+
+	branches := make([]branch, 0, len(v.Rules))
+	for name := range v.Rules {
+		parts := strings.Split(name, ".")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%w: invalid branch version %q", errorInconsistentRules, name)
+		}
+		major, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid branch version %q", errorInconsistentRules, name)
+		}
+		minor, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid branch version %q", errorInconsistentRules, name)
+		}
+		branches = append(branches, branch{name: name, major: major, minor: minor})
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].major != branches[j].major {
+			return branches[i].major < branches[j].major
+		}
+		return branches[i].minor < branches[j].minor
+	})
+
+	names := make([]string, 0, len(branches))
+	for _, b := range branches {
+		names = append(names, b.name)
+	}
+	return names, nil
+}
+
+// retention returns how many previous branch versions the current branch keeps for the given chart.
+// The most specific rule wins: branch chart override, default chart override, branch retention, default retention.
+func (v *VersionRules) retention(chart string) int {
+	rule := v.Rules[v.BranchVersion]
+
+	if r, ok := rule.Charts[chart]; ok && chart != "" {
+		return r
+	}
+	if r, ok := v.Charts[chart]; ok && chart != "" {
+		return r
+	}
+	if rule.Retention != nil {
+		return *rule.Retention
+	}
+	if v.Retention != nil {
+		return *v.Retention
+	}
+	return defaultRetention
+}
+
+// KeepOutOfLifecycle checks if the chart version is in the keep allowlist of the current branch
+func (v *VersionRules) KeepOutOfLifecycle(chart, version string) bool {
+	versions, ok := v.Rules[v.BranchVersion].Keep[chart]
+	if !ok {
+		return false
+	}
+
+	return len(versions) == 0 || slices.Contains(versions, version)
+}
+
+// lifecycleMin returns the minimum version allowed in the current branch for the given chart;
+// it is the min of the branch version as many branch versions before the current one as the retention.
+// An empty string means there is no minimum.
+func (v *VersionRules) lifecycleMin(chart string) string {
+	branches, err := v.sortedBranchVersions()
+	if err != nil {
+		return ""
+	}
+
+	for i, branch := range branches {
+		if branch != v.BranchVersion {
+			continue
+		}
+		if oldest := i - v.retention(chart); oldest >= 0 {
+			return v.Rules[branches[oldest]].Min
+		}
+		return ""
+	}
+	return ""
+}
+
 // Current lifecycle rules are:
 //
-//	Branch can only hold until 2 previous versions of the current branch version.
+//	Branch can only hold until <retention> previous versions of the current branch version (2 by default).
 //	Branch cannot hold versions from newer branches, only older ones.
 //
-// See CheckChartVersionForLifecycle() for more details.
+// MinVersion and MaxVersion hold the major versions of the default window, per-chart overrides are applied by
+// CheckChartVersionForLifecycle(). See CheckChartVersionForLifecycle() for more details.
 func (v *VersionRules) getMinMaxVersionInts() error {
-	// e.g: 2.9 - 0.2 = 2.7
-	minVersionStr := v.Rules[(branchVersionMinorSum(v.BranchVersion, -2))].Min
+	// e.g: 2.9 with retention 2 -> min of 2.7
+	minVersionStr := v.lifecycleMin("")
 	maxVersionStr := v.Rules[v.BranchVersion].Max
 
 	var err error
@@ -141,52 +355,97 @@ func ExtractBranchVersion(branch string) string {
 }
 
 // CheckChartVersionForLifecycle will
-// Check if the chart version is within the range of the current version:
+// Check if the chart version is within the lifecycle window of the current branch version for the given chart:
 //
 //	If the chart version is within the range, return true, otherwise return false
-func (v *VersionRules) CheckChartVersionForLifecycle(chartVersion string) bool {
-	chartVersionInt, _ := strconv.Atoi(strings.Split(chartVersion, ".")[0])
+func (v *VersionRules) CheckChartVersionForLifecycle(chart, chartVersion string) bool {
 	/**
 	Rule Example:
-	Branch version: 2.9
-	Min version: 104
-	Max version: 105
-	Therefore, the chart version must be >= (104 - 2) and < 105
-	i.e: 102 <= chartVersion < 105
+	Branch version: 2.9 ; retention: 2
+	2.7 min version: 102.0.0
+	2.9 max version: 105.0.0
+	Therefore, the chart version must be >= 102.0.0 and < 105.0.0
+	i.e: 102.0.0 <= chartVersion < 105.0.0
 	*/
-	if chartVersionInt >= v.MinVersion && chartVersionInt < v.MaxVersion {
-		return true
+	inRange, err := versionInRange(chartVersion, v.lifecycleMin(chart), v.Rules[v.BranchVersion].Max)
+	if err != nil {
+		return false
 	}
-	return false
+	return inRange
 }
 
-// CheckChartVersionToRelease will return if the current versyion being analyzed is the one to be released or not
+// CheckChartVersionToRelease will return if the current versyion being analyzed is the one to be released or not,
+// i.e. if it is within the range of the current branch version.
 func (v *VersionRules) CheckChartVersionToRelease(ctx context.Context, chartVersion string) (bool, error) {
-	chartVersionInt, err := strconv.Atoi(strings.Split(chartVersion, ".")[0])
+	rule := v.Rules[v.BranchVersion]
+	toRelease, err := versionInRange(chartVersion, rule.Min, rule.Max)
 	if err != nil {
 		logger.Log(ctx, slog.LevelError, "failed to check version to release for chartVersion", slog.String("chartVersion", chartVersion), logger.Err(err))
 		return false, err
 	}
-	return chartVersionInt == (v.MaxVersion - 1), nil
+	return toRelease, nil
 }
 
-// CheckForRCVersion checks if the chart version contains the "-rc" string indicating a release candidate version.
-func (v *VersionRules) CheckForRCVersion(chartVersion string) bool {
-	return strings.Contains(strings.ToLower(chartVersion), "-rc")
+// CheckVersionInBranchRange returns an error if the version is not within the range of the current branch version
+func (v *VersionRules) CheckVersionInBranchRange(version string) error {
+	rule := v.Rules[v.BranchVersion]
+	inRange, err := versionInRange(version, rule.Min, rule.Max)
+	if err != nil {
+		return err
+	}
+	if !inRange {
+		return fmt.Errorf("version %s is outside of the %s range: min %s, max %s", version, v.BranchVersion, rule.Min, rule.Max)
+	}
+	return nil
 }
 
-func branchVersionMinorSum(branchVersion string, number int) string {
-	parts := strings.Split(branchVersion, ".")
-	major, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return ""
+// versionInRange checks if min <= version < max, ignoring pre-release and build metadata;
+// an empty min or max means there is no limit.
+func versionInRange(version, min, max string) (bool, error) {
+	if min != "" {
+		cmp, err := compareVersions(version, min)
+		if err != nil {
+			return false, err
+		}
+		if cmp < 0 {
+			return false, nil
+		}
 	}
+	if max != "" {
+		cmp, err := compareVersions(version, max)
+		if err != nil {
+			return false, err
+		}
+		if cmp >= 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
-	minor, err := strconv.Atoi(parts[1])
+// compareVersions compares the major.minor.patch of two versions, returning -1, 0 or 1
+func compareVersions(a, b string) (int, error) {
+	va, err := semver.NewVersion(a)
 	if err != nil {
-		return ""
+		return 0, err
+	}
+	vb, err := semver.NewVersion(b)
+	if err != nil {
+		return 0, err
 	}
 
-	minor += number
-	return fmt.Sprintf("%d.%d", major, minor)
+	for _, pair := range [][2]int64{{va.Major(), vb.Major()}, {va.Minor(), vb.Minor()}, {va.Patch(), vb.Patch()}} {
+		if pair[0] < pair[1] {
+			return -1, nil
+		}
+		if pair[0] > pair[1] {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// CheckForRCVersion checks if the chart version is a pre-release (rc, alpha, beta...) according to the pre-release policy.
+func (v *VersionRules) CheckForRCVersion(chartVersion string) bool {
+	return prerelease.IsPrerelease(chartVersion)
 }
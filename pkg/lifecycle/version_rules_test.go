@@ -2,6 +2,7 @@ package lifecycle
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/go-git/go-billy/v5"
@@ -9,20 +10,9 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_branchVersionMinorSum(t *testing.T) {
-	t.Run("branchVersion is 2.9 and sum is -2", func(t *testing.T) {
-		result := branchVersionMinorSum("2.9", -2)
-		assert.Equal(t, "2.7", result, "Expected 2.7")
-	})
-
-	t.Run("branchVersion is 2.8 and sum is 2", func(t *testing.T) {
-		result := branchVersionMinorSum("2.8", 2)
-		assert.Equal(t, "2.10", result, "Expected 2.10")
-	})
-}
-
 func Test_rules(t *testing.T) {
 	fs := memfs.New()
+	three := 3
 
 	type input struct {
 		fs            billy.Filesystem
@@ -213,6 +203,63 @@ func Test_rules(t *testing.T) {
 				err: nil,
 			},
 		},
+		{
+			name: "#7 - branchVersion defined in rules [custom retention]",
+			i: input{
+				fs:            fs,
+				branchVersion: "2.10",
+				mockLoad: func(ctx context.Context, fs billy.Filesystem) (*VersionRules, error) {
+					return &VersionRules{
+						Rules: map[string]Version{
+							"2.10": {Min: "105.0.0", Max: "106.0.0", Retention: &three},
+							"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+							"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+							"2.7":  {Min: "101.0.0", Max: "103.0.0"},
+						},
+						DevBranchPrefix:  "dev-v",
+						ProdBranchPrefix: "release-v",
+					}, nil
+				},
+			},
+			ex: expected{
+				vr: &VersionRules{
+					Rules: map[string]Version{
+						"2.10": {Min: "105.0.0", Max: "106.0.0", Retention: &three},
+						"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+						"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+						"2.7":  {Min: "101.0.0", Max: "103.0.0"},
+					},
+					BranchVersion:    "2.10",
+					DevBranchPrefix:  "dev-v",
+					DevBranch:        "dev-v2.10",
+					ProdBranchPrefix: "release-v",
+					ProdBranch:       "release-v2.10",
+					MinVersion:       101,
+					MaxVersion:       106,
+				},
+				err: nil,
+			},
+		},
+		{
+			name: "#8 - inconsistent rules",
+			i: input{
+				fs:            fs,
+				branchVersion: "2.10",
+				mockLoad: func(ctx context.Context, fs billy.Filesystem) (*VersionRules, error) {
+					return &VersionRules{
+						Rules: map[string]Version{
+							"2.10": {Min: "105.0.0", Max: "106.0.0"},
+							"2.9":  {Min: "103.0.0", Max: "105.0.0"},
+							"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+						},
+					}, nil
+				},
+			},
+			ex: expected{
+				vr:  nil,
+				err: errors.New("inconsistent version rules: 2.8 (max 104.0.0) overlaps 2.9 (min 103.0.0)"),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -222,10 +269,282 @@ func Test_rules(t *testing.T) {
 			if tt.ex.err == nil {
 				assert.Nil(t, err, "Expected nil error")
 			} else {
-				assert.Equal(t, tt.ex.err, err, "Expected error")
+				assert.EqualError(t, err, tt.ex.err.Error(), "Expected error")
 			}
 
 			assert.Equal(t, tt.ex.vr, vr, "Expected VersionRules")
 		})
 	}
 }
+
+func Test_Validate(t *testing.T) {
+	negative := -1
+
+	tests := []struct {
+		name  string
+		rules *VersionRules
+		err   string
+	}{
+		{
+			name: "#1 - consistent rules",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.10": {Min: "105.0.0", Max: "106.0.0"},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+				"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+				"2.7":  {Min: "101.0.0", Max: "103.0.0"},
+				"2.5":  {Min: "", Max: "101.0.0"},
+			}},
+		},
+		{
+			name: "#2 - overlapping ranges",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.10": {Min: "104.0.0", Max: "106.0.0"},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			}},
+			err: "inconsistent version rules: 2.9 (max 105.0.0) overlaps 2.10 (min 104.0.0)",
+		},
+		{
+			name: "#3 - gapped ranges",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.10": {Min: "106.0.0", Max: "107.0.0"},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			}},
+			err: "inconsistent version rules: gap between 2.9 (max 105.0.0) and 2.10 (min 106.0.0)",
+		},
+		{
+			name: "#4 - min not lower than max",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.9": {Min: "105.0.0", Max: "105.0.0"},
+			}},
+			err: "inconsistent version rules: 2.9: min 105.0.0 must be lower than max 105.0.0",
+		},
+		{
+			name: "#5 - missing min in a newer branch",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.10": {Max: "106.0.0"},
+				"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			}},
+			err: "inconsistent version rules: 2.9 and 2.10: only the oldest branch can have no min and only the newest branch can have no max",
+		},
+		{
+			name: "#6 - invalid branch version",
+			rules: &VersionRules{Rules: map[string]Version{
+				"main": {Min: "104.0.0", Max: "105.0.0"},
+			}},
+			err: `inconsistent version rules: invalid branch version "main"`,
+		},
+		{
+			name: "#7 - negative retention",
+			rules: &VersionRules{Rules: map[string]Version{
+				"2.9": {Min: "104.0.0", Max: "105.0.0", Retention: &negative},
+			}},
+			err: "inconsistent version rules: 2.9: negative retention -1",
+		},
+		{
+			name: "#8 - negative chart retention",
+			rules: &VersionRules{
+				Rules:  map[string]Version{"2.9": {Min: "104.0.0", Max: "105.0.0"}},
+				Charts: map[string]int{"fleet": -1},
+			},
+			err: "inconsistent version rules: default: negative retention -1 for chart fleet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, errorInconsistentRules)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_CheckChartVersionForLifecycle(t *testing.T) {
+	three := 3
+	zero := 0
+
+	rules := map[string]Version{
+		"3.0":  {Min: "107.0.0", Max: "108.0.0"},
+		"2.11": {Min: "106.0.0", Max: "107.0.0"},
+		"2.10": {Min: "105.0.0", Max: "106.0.0"},
+		"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+		"2.8":  {Min: "103.0.0", Max: "104.0.0"},
+	}
+
+	type input struct {
+		vr           *VersionRules
+		chart        string
+		chartVersion string
+	}
+
+	tests := []struct {
+		name     string
+		i        input
+		expected bool
+	}{
+		{
+			name:     "#1 - default retention keeps 2 previous branch versions",
+			i:        input{vr: &VersionRules{Rules: rules, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "105.0.0+up0.11.0"},
+			expected: true,
+		},
+		{
+			name:     "#2 - default retention drops older branch versions",
+			i:        input{vr: &VersionRules{Rules: rules, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "104.2.0+up0.10.2"},
+			expected: false,
+		},
+		{
+			name:     "#3 - newer branch versions are never in the lifecycle",
+			i:        input{vr: &VersionRules{Rules: rules, BranchVersion: "2.10"}, chart: "fleet", chartVersion: "106.0.0+up0.12.0"},
+			expected: false,
+		},
+		{
+			name:     "#4 - pre-release of the next branch version is not in the lifecycle",
+			i:        input{vr: &VersionRules{Rules: rules, BranchVersion: "2.10"}, chart: "fleet", chartVersion: "106.0.0-rc.1"},
+			expected: false,
+		},
+		{
+			name:     "#5 - branch retention",
+			i:        input{vr: &VersionRules{Rules: map[string]Version{"3.0": {Min: "107.0.0", Max: "108.0.0", Retention: &three}, "2.11": rules["2.11"], "2.10": rules["2.10"], "2.9": rules["2.9"]}, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "104.2.0+up0.10.2"},
+			expected: true,
+		},
+		{
+			name:     "#6 - default retention",
+			i:        input{vr: &VersionRules{Rules: rules, Retention: &zero, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "106.0.0+up0.12.0"},
+			expected: false,
+		},
+		{
+			name:     "#7 - default chart override",
+			i:        input{vr: &VersionRules{Rules: rules, Charts: map[string]int{"rancher-webhook": 4}, BranchVersion: "3.0"}, chart: "rancher-webhook", chartVersion: "103.0.0+up0.4.0"},
+			expected: true,
+		},
+		{
+			name:     "#8 - default chart override does not apply to other charts",
+			i:        input{vr: &VersionRules{Rules: rules, Charts: map[string]int{"rancher-webhook": 4}, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "103.0.0+up0.9.0"},
+			expected: false,
+		},
+		{
+			name:     "#9 - branch chart override wins over the default chart override",
+			i:        input{vr: &VersionRules{Rules: map[string]Version{"3.0": {Min: "107.0.0", Max: "108.0.0", Charts: map[string]int{"rancher-webhook": 0}}, "2.11": rules["2.11"]}, Charts: map[string]int{"rancher-webhook": 4}, BranchVersion: "3.0"}, chart: "rancher-webhook", chartVersion: "106.0.0+up0.6.0"},
+			expected: false,
+		},
+		{
+			name:     "#10 - invalid chart version",
+			i:        input{vr: &VersionRules{Rules: rules, BranchVersion: "3.0"}, chart: "fleet", chartVersion: "invalid"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.i.vr.CheckChartVersionForLifecycle(tt.i.chart, tt.i.chartVersion))
+		})
+	}
+}
+
+func Test_CheckChartVersionToRelease(t *testing.T) {
+	vr := &VersionRules{
+		Rules: map[string]Version{
+			"2.9": {Min: "104.0.0", Max: "105.0.0"},
+			"2.8": {Min: "103.0.0", Max: "104.0.0"},
+			"2.7": {Min: "101.0.0", Max: "103.0.0"},
+		},
+	}
+
+	tests := []struct {
+		branchVersion string
+		chartVersion  string
+		expected      bool
+	}{
+		{"2.9", "104.1.0+up1.0.0", true},
+		{"2.9", "103.1.0+up1.0.0", false},
+		{"2.9", "105.0.0+up1.0.0", false},
+		{"2.7", "101.1.0+up1.0.0", true},
+		{"2.7", "102.1.0+up1.0.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branchVersion+"/"+tt.chartVersion, func(t *testing.T) {
+			vr.BranchVersion = tt.branchVersion
+			toRelease, err := vr.CheckChartVersionToRelease(context.Background(), tt.chartVersion)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, toRelease)
+			assert.Equal(t, tt.expected, vr.CheckVersionInBranchRange(tt.chartVersion) == nil)
+		})
+	}
+
+	_, err := vr.CheckChartVersionToRelease(context.Background(), "invalid")
+	assert.Error(t, err)
+}
+
+func Test_KeepOutOfLifecycle(t *testing.T) {
+	vr := &VersionRules{
+		Rules: map[string]Version{
+			"2.10": {Min: "105.0.0", Max: "106.0.0", Keep: map[string][]string{
+				"rancher-istio":      {"103.0.0+up1.19.6"},
+				"rancher-monitoring": {},
+			}},
+			"2.9": {Min: "104.0.0", Max: "105.0.0", Keep: map[string][]string{"fleet": {}}},
+		},
+		BranchVersion: "2.10",
+	}
+
+	tests := []struct {
+		name     string
+		chart    string
+		version  string
+		expected bool
+	}{
+		{name: "#1 - allowlisted version", chart: "rancher-istio", version: "103.0.0+up1.19.6", expected: true},
+		{name: "#2 - other version of an allowlisted chart", chart: "rancher-istio", version: "102.0.0+up1.18.2", expected: false},
+		{name: "#3 - every version of the chart is kept", chart: "rancher-monitoring", version: "100.0.0+up16.6.0", expected: true},
+		{name: "#4 - allowlist of another branch", chart: "fleet", version: "102.0.0+up0.8.0", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, vr.KeepOutOfLifecycle(tt.chart, tt.version))
+		})
+	}
+}
+
+func Test_BranchOf(t *testing.T) {
+	vr := &VersionRules{
+		Rules: map[string]Version{
+			"2.10": {Min: "105.0.0", Max: ""},
+			"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+			"2.8":  {Min: "", Max: "104.0.0"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		branch  string
+		newer   []string
+	}{
+		{name: "#1 - oldest branch without min", version: "102.0.3+up1.2.3", branch: "2.8", newer: []string{"2.9", "2.10"}},
+		{name: "#2 - min of a branch", version: "104.0.0+up0.10.0", branch: "2.9", newer: []string{"2.10"}},
+		{name: "#3 - newest branch without max", version: "106.1.0+up0.12.0", branch: "2.10", newer: []string{}},
+		{name: "#4 - invalid version", version: "not-a-version", branch: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.branch, vr.BranchOf(tt.version))
+			if tt.branch != "" {
+				assert.Equal(t, tt.newer, vr.NewerBranches(tt.branch))
+			}
+		})
+	}
+}
+
+func Test_LoadVersionRules(t *testing.T) {
+	fs := memfs.New()
+	vr, err := LoadVersionRules(context.Background(), fs)
+	assert.NoError(t, err)
+	assert.Nil(t, vr)
+}
@@ -25,6 +25,12 @@ type PackageOptions struct {
 	DoNotRelease bool `yaml:"doNotRelease,omitempty"`
 	// Auto represent the trigger for auto chart bumps
 	Auto bool `yaml:"auto,omitempty"`
+	// RemovedValues acknowledges, per chart name, the values.yaml keys removed on purpose since the previously released version
+	// of the chart; a key also acknowledges the keys nested under it. e.g., fleet: [legacy.enabled]
+	RemovedValues map[string][]string `yaml:"removedValues,omitempty"`
+	// CRDIncompatibilities acknowledges, per chart name, the incompatible CRD changes made on purpose since the previously released
+	// version of the chart, as a CRD, a CRD and version or a CRD, version and schema path. e.g., fleet-crd: [gitrepos.fleet.cattle.io v1alpha1]
+	CRDIncompatibilities map[string][]string `yaml:"crdIncompatibilities,omitempty"`
 }
 
 // ChartOptions represent the options presented to users to be able to configure the way a main chart is built using these scripts
@@ -37,6 +43,9 @@ type ChartOptions struct {
 	IgnoreDependencies []string `yaml:"ignoreDependencies"`
 	// ReplacePaths marks paths as those that should be replaced instead of patches. Consequently, these paths will exist in both generated-changes/excludes and generated-changes/overlay
 	ReplacePaths []string `yaml:"replacePaths"`
+	// GenerateValuesSchema infers a values.schema.json from the values.yaml of the chart on make charts,
+	// merged with the values.schema.json of the chart, e.g. one added in generated-changes/overlay
+	GenerateValuesSchema bool `yaml:"generateValuesSchema,omitempty"`
 }
 
 // UpstreamOptions represents the options presented to users to define where the upstream Helm chart is located
@@ -67,8 +76,11 @@ type AdditionalChartOptions struct {
 
 // CRDChartOptions represent any options that are configurable for CRD charts
 type CRDChartOptions struct {
-	// The directory within packages/<package-name>/templates/ that will contain the template for your CRD chart
+	// The directory within packages/<package-name>/templates/ that will contain the template for your CRD chart. Mutually exclusive with GenerateFromMainChart
 	TemplateDirectory string `yaml:"templateDirectory"`
+	// GenerateFromMainChart indicates whether to generate the CRD chart (Chart.yaml, values.yaml and README.md) from the main chart's metadata
+	// and to also move the CRDs found in the main chart's templates/ into the CRD chart. Mutually exclusive with TemplateDirectory
+	GenerateFromMainChart bool `yaml:"generateFromMainChart"`
 	// The directory in which to place your crds within the chart generated from TemplateDirectory. Mutually exclusive with UseTarArchive
 	CRDDirectory string `yaml:"crdDirectory" default:"templates"`
 	// UseTarArchive indicates whether to bundle and compress CRD files into a tgz file. Mutually exclusive with CRDDirectory
@@ -88,6 +100,47 @@ type ChartsScriptOptions struct {
 	// OmitBuildMetadataOnExport instructs the scripts to not add in a +up build metadata flag for forked charts
 	// If false, any forked chart whose version differs from the original source version will have the version VERSION+upORIGINAL_VERSION
 	OmitBuildMetadataOnExport bool `yaml:"omitBuildMetadataOnExport"`
+	// GithubOptions represents the GitHub repository that the automation commands interact with
+	GithubOptions *GithubOptions `yaml:"github,omitempty"`
+	// ChartGroups declares the charts bumped, forward-ported and released together with a main chart,
+	// overriding the groups derived from package.yaml. e.g., fleet: [fleet, fleet-crd, fleet-agent]
+	ChartGroups map[string][]string `yaml:"chartGroups,omitempty"`
+	// RenderCheckOptions configures the offline rendering of the charts by render-check; validate renders the charts as well when set
+	RenderCheckOptions *RenderCheckOptions `yaml:"renderCheck,omitempty"`
+}
+
+// RenderCheckOptions represents the matrix of values files and Kubernetes versions that each chart is rendered against
+type RenderCheckOptions struct {
+	// KubeVersions are the Kubernetes versions to render against (e.g. v1.30.0); a chart is only rendered against
+	// those within its catalog.cattle.io/kube-version range. If none is set, the default Helm capabilities are used
+	KubeVersions []string `yaml:"kubeVersions,omitempty"`
+	// ValuesFiles lists, per chart name, the values files rendered on top of the chart's default values.
+	// The paths are relative to the repository root (e.g. fleet: [test/values/fleet-ha.yaml])
+	ValuesFiles map[string][]string `yaml:"valuesFiles,omitempty"`
+}
+
+// GithubOptions represents the GitHub repository and API endpoint used by the automation commands (forward-port, release, PR validation)
+type GithubOptions struct {
+	// Owner is the organization or user that owns the charts repository (e.g. rancher)
+	Owner string `yaml:"owner,omitempty"`
+	// Repo is the name of the charts repository (e.g. charts)
+	Repo string `yaml:"repo,omitempty"`
+	// UpstreamURL is the URL of the charts repository as configured in your local git remotes
+	UpstreamURL string `yaml:"upstreamURL,omitempty"`
+	// APIURL is the base URL of the GitHub API; only needed for GitHub Enterprise installations
+	APIURL string `yaml:"apiURL,omitempty"`
+	// PullRequestOptions represents the labels and reviewers applied to the pull requests opened by the automation commands
+	PullRequestOptions PullRequestOptions `yaml:"pullRequest,omitempty"`
+}
+
+// PullRequestOptions represents the labels and reviewers applied to the pull requests opened by the automation commands
+type PullRequestOptions struct {
+	// Labels to add to every opened pull request
+	Labels []string `yaml:"labels,omitempty"`
+	// Reviewers are the GitHub users requested to review every opened pull request
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	// TeamReviewers are the GitHub team slugs requested to review every opened pull request
+	TeamReviewers []string `yaml:"teamReviewers,omitempty"`
 }
 
 // HelmRepoConfiguration represents the configuration of the Helm Repository that exposes your charts
@@ -48,6 +48,9 @@ const (
 	// ChartCRDDir represents the directory that we expect to contain CRDs within the chart
 	ChartCRDDir = "crds"
 
+	// ChartTemplatesDir represents the directory that contains the templates within the chart
+	ChartTemplatesDir = "templates"
+
 	// ChartExtraFileDir represents the directory that contains non-YAML files
 	ChartExtraFileDir = "files"
 
@@ -59,6 +62,8 @@ const (
 
 	// DefaultCachePath represents the default place to put a cache on pulled values
 	DefaultCachePath = ".charts-build-scripts/.cache"
+	// IndexCacheFile caches the size, modification time and digest of the archives in assets/ to only load the changed ones when generating the index.yaml
+	IndexCacheFile = ".charts-build-scripts/index-cache.json"
 
 	// RepositoryLogosDir is a directory on your Staging/Live branch that contains the files with the logos of each chart
 	RepositoryLogosDir = "assets/logos"
@@ -78,6 +83,15 @@ const (
 	// BumpVersionFile is a file to hold the version that was bumped
 	BumpVersionFile = "config/bump_version.json"
 
+	// BumpChangelogFile is a file to hold the changelog of the chart bump
+	BumpChangelogFile = "config/bump_changelog.md"
+
+	// BumpBatchFile is a file to hold the result of every package bumped by chart-bump-batch
+	BumpBatchFile = "config/bump_batch.json"
+
+	// RenderCheckDir is a directory that contains the manifests rendered by render-check
+	RenderCheckDir = "logs/render-check"
+
 	// ConfigurationYamlFile is the file that contains the configuration for the charts-build-scripts
 	ConfigurationYamlFile = "config/configuration.yaml"
 
@@ -0,0 +1,131 @@
+package prerelease
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// DefaultIdentifiers are the pre-release identifiers used when none are configured
+var DefaultIdentifiers = []string{"rc", "alpha", "beta", "dev"}
+
+// Policy decides which versions are pre-releases by the first identifier of their semver pre-release,
+// e.g. rc for 1.0.0-rc.1. Chart versions keep the upstream version in the build metadata (108.0.0+up0.9.0-rc.1),
+// so the upstream version after "+up" is checked as well.
+type Policy struct {
+	identifiers []string
+}
+
+// Release is a parsed pre-release version
+type Release struct {
+	Base       string // the version without the pre-release, e.g. 108.0.0+up0.9.0 for 108.0.0+up0.9.0-rc.1
+	Identifier string // the matched pre-release identifier, e.g. rc
+	Number     int    // the pre-release number, e.g. 1 for rc.1 or rc1; 0 if there is none
+}
+
+var _default = NewPolicy()
+
+// NewPolicy creates a policy for the given pre-release identifiers, or the DefaultIdentifiers if none is given
+func NewPolicy(identifiers ...string) *Policy {
+	p := &Policy{}
+	for _, identifier := range identifiers {
+		if identifier = strings.ToLower(strings.TrimSpace(identifier)); identifier != "" {
+			p.identifiers = append(p.identifiers, identifier)
+		}
+	}
+	if len(p.identifiers) == 0 {
+		p.identifiers = DefaultIdentifiers
+	}
+	return p
+}
+
+// Default returns the policy used by the lifecycle, chart-bump, check-rc and the registries
+func Default() *Policy {
+	return _default
+}
+
+// SetDefault replaces the default policy
+func SetDefault(p *Policy) {
+	_default = p
+}
+
+// Parse returns the pre-release of the version and true if it is a pre-release according to the policy.
+// Versions that are not valid semver only match "-<identifier>" in the version string.
+func (p *Policy) Parse(version string) (Release, bool) {
+	pre, ok := p.prerelease(version)
+	if !ok {
+		return Release{}, false
+	}
+
+	firstIdentifier, _, _ := strings.Cut(pre, ".")
+	for _, identifier := range p.identifiers {
+		number, ok := strings.CutPrefix(strings.ToLower(firstIdentifier), identifier)
+		if !ok {
+			continue
+		}
+		release := Release{
+			Base:       strings.Replace(version, "-"+pre, "", 1),
+			Identifier: identifier,
+		}
+		if number == "" {
+			// rc.1
+			if _, rest, found := strings.Cut(pre, "."); found {
+				number, _, _ = strings.Cut(rest, ".")
+			}
+		}
+		release.Number = leadingNumber(strings.TrimPrefix(number, "-"))
+		return release, true
+	}
+
+	return Release{}, false
+}
+
+// prerelease returns the semver pre-release of the version or of its upstream version in the build metadata
+func (p *Policy) prerelease(version string) (string, bool) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		// not semver, e.g. an image tag like 1.0-rc1-linux-amd64
+		for _, identifier := range p.identifiers {
+			if i := strings.Index(strings.ToLower(version), "-"+identifier); i >= 0 {
+				return version[i+1:], true
+			}
+		}
+		return "", false
+	}
+
+	if v.Prerelease() != "" {
+		return v.Prerelease(), true
+	}
+
+	upstream, found := strings.CutPrefix(v.Metadata(), "up")
+	if !found {
+		return "", false
+	}
+	u, err := semver.NewVersion(upstream)
+	if err != nil || u.Prerelease() == "" {
+		return "", false
+	}
+	return u.Prerelease(), true
+}
+
+// leadingNumber returns the number at the start of s, e.g. 1 for 1-linux-amd64, or 0 if there is none
+func leadingNumber(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// IsPrerelease checks if the version is a pre-release according to the policy
+func (p *Policy) IsPrerelease(version string) bool {
+	_, ok := p.Parse(version)
+	return ok
+}
+
+// IsPrerelease checks if the version is a pre-release according to the default policy
+func IsPrerelease(version string) bool {
+	return Default().IsPrerelease(version)
+}
@@ -0,0 +1,48 @@
+package prerelease
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		version  string
+		expected Release
+		ok       bool
+	}{
+		{name: "#1 stable chart version", policy: NewPolicy(), version: "108.0.0+up0.9.0", ok: false},
+		{name: "#2 rc in the +up build metadata", policy: NewPolicy(), version: "108.0.0+up0.9.0-rc.1", expected: Release{Base: "108.0.0+up0.9.0", Identifier: "rc", Number: 1}, ok: true},
+		{name: "#3 rc in the repository prefix", policy: NewPolicy(), version: "108.0.0-rc.2+up0.9.0", expected: Release{Base: "108.0.0+up0.9.0", Identifier: "rc", Number: 2}, ok: true},
+		{name: "#4 alpha without dot", policy: NewPolicy(), version: "108.0.0+up0.9.0-alpha3", expected: Release{Base: "108.0.0+up0.9.0", Identifier: "alpha", Number: 3}, ok: true},
+		{name: "#5 beta without number", policy: NewPolicy(), version: "1.2.3-beta", expected: Release{Base: "1.2.3", Identifier: "beta"}, ok: true},
+		{name: "#6 image tag with platform suffix", policy: NewPolicy(), version: "v0.12.0-rc.1-linux-amd64", expected: Release{Base: "v0.12.0", Identifier: "rc", Number: 1}, ok: true},
+		{name: "#7 non pre-release identifier", policy: NewPolicy(), version: "v1.28.5-rancher1", ok: false},
+		{name: "#8 build metadata that is not an upstream version", policy: NewPolicy(), version: "1.0.0+build-rc.1", ok: false},
+		{name: "#9 identifier not in the policy", policy: NewPolicy("rc"), version: "108.0.0+up0.9.0-beta.1", ok: false},
+		{name: "#10 configured identifier", policy: NewPolicy(" Preview "), version: "108.0.0+up0.9.0-preview.4", expected: Release{Base: "108.0.0+up0.9.0", Identifier: "preview", Number: 4}, ok: true},
+		{name: "#11 invalid semver tag", policy: NewPolicy(), version: "nightly-rc1", expected: Release{Base: "nightly", Identifier: "rc", Number: 1}, ok: true},
+		{name: "#12 uppercase identifier", policy: NewPolicy(), version: "1.0.0-RC.1", expected: Release{Base: "1.0.0", Identifier: "rc", Number: 1}, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release, ok := tt.policy.Parse(tt.version)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, release)
+			assert.Equal(t, tt.ok, tt.policy.IsPrerelease(tt.version))
+		})
+	}
+}
+
+func Test_SetDefault(t *testing.T) {
+	defer SetDefault(Default())
+
+	assert.True(t, IsPrerelease("108.0.0+up0.9.0-beta.1"))
+	SetDefault(NewPolicy("rc"))
+	assert.False(t, IsPrerelease("108.0.0+up0.9.0-beta.1"))
+	assert.True(t, IsPrerelease("108.0.0+up0.9.0-rc.1"))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/rancher/charts-build-scripts/pkg/git"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/path"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 )
 
 // chartsToIgnoreTags defines the charts and system charts in which a specified
@@ -128,10 +129,7 @@ func sanitizeTags(imageTagMap map[string][]string) map[string][]string {
 
 	for repo, tags := range imageTagMap {
 		for _, tag := range tags {
-			if strings.Contains(tag, "-rc") ||
-				strings.Contains(tag, "-beta") ||
-				strings.Contains(tag, "-alpha") ||
-				strings.HasPrefix(tag, "sha256-") {
+			if prerelease.IsPrerelease(tag) || strings.HasPrefix(tag, "sha256-") {
 				continue
 			}
 			result[repo] = append(result[repo], tag)
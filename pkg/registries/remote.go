@@ -14,6 +14,7 @@ import (
 	"github.com/rancher/charts-build-scripts/pkg/filesystem"
 	"github.com/rancher/charts-build-scripts/pkg/logger"
 	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/prerelease"
 
 	authn "github.com/google/go-containerregistry/pkg/authn"
 	name "github.com/google/go-containerregistry/pkg/name"
@@ -385,10 +386,10 @@ func DockerCheckRCTags(ctx context.Context, repoRoot string) map[string][]string
 
 	logger.Log(ctx, slog.LevelInfo, "checking for RC tags in all collected images")
 
-	// Grab all images that contain RC tags
+	// Grab all images that contain pre-release tags
 	for image := range imageTagMap {
 		for _, tag := range imageTagMap[image] {
-			if strings.Contains(tag, "-rc") {
+			if prerelease.IsPrerelease(tag) {
 				rcImageTagMap[image] = append(rcImageTagMap[image], tag)
 			}
 		}
@@ -0,0 +1,14 @@
+package util
+
+import "strings"
+
+// IsAcknowledged returns whether an entry of acknowledged equals the key or is one of its parents,
+// i.e. the key starts with the entry followed by separator
+func IsAcknowledged(key string, acknowledged []string, separator string) bool {
+	for _, a := range acknowledged {
+		if key == a || strings.HasPrefix(key, a+separator) {
+			return true
+		}
+	}
+	return false
+}
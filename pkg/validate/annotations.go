@@ -0,0 +1,242 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	semverv3 "github.com/Masterminds/semver/v3"
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Rancher catalog annotations of the Chart.yaml
+const (
+	autoInstallAnnotation    = "catalog.cattle.io/auto-install"
+	certifiedAnnotation      = "catalog.cattle.io/certified"
+	displayNameAnnotation    = "catalog.cattle.io/display-name"
+	hiddenAnnotation         = "catalog.cattle.io/hidden"
+	namespaceAnnotation      = "catalog.cattle.io/namespace"
+	osAnnotation             = "catalog.cattle.io/os"
+	permitsOSAnnotation      = "catalog.cattle.io/permits-os"
+	rancherVersionAnnotation = "catalog.cattle.io/rancher-version"
+	releaseNameAnnotation    = "catalog.cattle.io/release-name"
+)
+
+// autoInstallMatch is the auto-install version that installs the same version of the referenced chart
+const autoInstallMatch = "match"
+
+var (
+	certifiedValues = []string{"rancher", "partner"}
+	osValues        = []string{"linux", "windows"}
+)
+
+// AnnotationViolation is a missing or invalid catalog.cattle.io annotation of a chart version in charts/
+type AnnotationViolation struct {
+	Chart      string
+	Version    string
+	Annotation string
+	Message    string
+}
+
+// ValidateAnnotations checks the catalog.cattle.io annotations of every chart version in charts/, or only of currentChart if set,
+// which can also be <chart>/<version>. Every chart must declare certified and release-name, and charts that are not hidden
+// must also declare display-name, rancher-version and kube-version (unless the Chart.yaml has a kubeVersion).
+// Ranges must parse, auto-install must reference a chart version in charts/, namespace must be a valid namespace,
+// and os and permits-os only list linux and windows. If versionRules is set, the rancher-version range must admit the
+// branch version holding the chart version and no newer branch version.
+func ValidateAnnotations(ctx context.Context, repoFs billy.Filesystem, versionRules *lifecycle.VersionRules, currentChart string) ([]AnnotationViolation, error) {
+	violations := []AnnotationViolation{}
+
+	chartFilter, versionFilter, _ := strings.Cut(currentChart, "/")
+	charts, err := repoFs.ReadDir(path.RepositoryChartsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path.RepositoryChartsDir, err)
+	}
+	for _, chartDir := range charts {
+		if !chartDir.IsDir() || (chartFilter != "" && chartDir.Name() != chartFilter) {
+			continue
+		}
+		versions, err := repoFs.ReadDir(filepath.Join(path.RepositoryChartsDir, chartDir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", chartDir.Name(), err)
+		}
+		for _, versionDir := range versions {
+			if !versionDir.IsDir() || (versionFilter != "" && versionDir.Name() != versionFilter) {
+				continue
+			}
+			chartYaml := filepath.Join(path.RepositoryChartsDir, chartDir.Name(), versionDir.Name(), "Chart.yaml")
+			exists, err := filesystem.PathExists(ctx, repoFs, chartYaml)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				logger.Log(ctx, slog.LevelDebug, "skipping directory without Chart.yaml", slog.String("path", filepath.Dir(chartYaml)))
+				continue
+			}
+			metadata, err := chartutil.LoadChartfile(filesystem.GetAbsPath(repoFs, chartYaml))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", chartYaml, err)
+			}
+
+			for annotation, message := range checkAnnotations(ctx, repoFs, metadata, versionRules) {
+				violations = append(violations, AnnotationViolation{
+					Chart:      chartDir.Name(),
+					Version:    versionDir.Name(),
+					Annotation: annotation,
+					Message:    message,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Chart != violations[j].Chart {
+			return violations[i].Chart < violations[j].Chart
+		}
+		if violations[i].Version != violations[j].Version {
+			return violations[i].Version < violations[j].Version
+		}
+		return violations[i].Annotation < violations[j].Annotation
+	})
+	return violations, nil
+}
+
+// checkAnnotations returns the violation of each invalid annotation of a Chart.yaml
+func checkAnnotations(ctx context.Context, repoFs billy.Filesystem, metadata *chart.Metadata, versionRules *lifecycle.VersionRules) map[string]string {
+	violations := map[string]string{}
+	annotations := metadata.Annotations
+
+	required := []string{certifiedAnnotation, releaseNameAnnotation}
+	hidden := annotations[hiddenAnnotation]
+	if hidden != "true" {
+		required = append(required, displayNameAnnotation, rancherVersionAnnotation)
+		if metadata.KubeVersion == "" {
+			required = append(required, kubeVersionAnnotation)
+		}
+	}
+	for _, annotation := range required {
+		if strings.TrimSpace(annotations[annotation]) == "" {
+			violations[annotation] = "missing"
+		}
+	}
+
+	for annotation, value := range annotations {
+		if _, ok := violations[annotation]; ok {
+			continue
+		}
+		var message string
+		switch annotation {
+		case certifiedAnnotation:
+			message = checkOneOf(value, certifiedValues)
+		case hiddenAnnotation:
+			message = checkOneOf(value, []string{"true", "false"})
+		case releaseNameAnnotation:
+			if err := chartutil.ValidateReleaseName(value); err != nil {
+				message = err.Error()
+			}
+		case namespaceAnnotation:
+			if errs := validation.IsDNS1123Label(value); len(errs) > 0 {
+				message = strings.Join(errs, ", ")
+			}
+		case osAnnotation, permitsOSAnnotation:
+			for _, osName := range strings.Split(value, ",") {
+				if message = checkOneOf(strings.TrimSpace(osName), osValues); message != "" {
+					break
+				}
+			}
+		case kubeVersionAnnotation:
+			if _, err := semverv3.NewConstraint(value); err != nil {
+				message = fmt.Sprintf("invalid range %q: %s", value, err)
+			}
+		case rancherVersionAnnotation:
+			message = checkRancherVersion(value, metadata.Version, versionRules)
+		case autoInstallAnnotation:
+			message = checkAutoInstall(ctx, repoFs, value, metadata.Version)
+		}
+		if message != "" {
+			violations[annotation] = message
+		}
+	}
+	return violations
+}
+
+// checkOneOf returns a violation if value is not one of the allowed values
+func checkOneOf(value string, allowed []string) string {
+	for _, a := range allowed {
+		if value == a {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%q must be one of %s", value, strings.Join(allowed, ", "))
+}
+
+// checkRancherVersion returns a violation if the range does not parse or, with version rules, if it does not admit the
+// Rancher version of the branch holding the chart version (e.g. 2.9 for 104.x) or admits a newer branch version
+func checkRancherVersion(value, chartVersion string, versionRules *lifecycle.VersionRules) string {
+	constraint, err := semverv3.NewConstraint(value)
+	if err != nil {
+		return fmt.Sprintf("invalid range %q: %s", value, err)
+	}
+	if versionRules == nil {
+		return ""
+	}
+	branch := versionRules.BranchOf(chartVersion)
+	if branch == "" {
+		// out of every branch range, reported by the lifecycle checks
+		return ""
+	}
+
+	if !admitsBranch(constraint, branch) {
+		return fmt.Sprintf("range %q does not admit Rancher %s, the branch version of %s", value, branch, chartVersion)
+	}
+	for _, newer := range versionRules.NewerBranches(branch) {
+		if admitsBranch(constraint, newer) {
+			return fmt.Sprintf("range %q admits Rancher %s, but %s belongs to Rancher %s", value, newer, chartVersion, branch)
+		}
+	}
+	return ""
+}
+
+// admitsBranch returns whether the range admits the first or a late patch of a Rancher <major>.<minor> branch version,
+// so ranges starting at a patch version, e.g. >= 2.9.3-0 < 2.10.0-0, admit the branch version as well
+func admitsBranch(constraint *semverv3.Constraints, branch string) bool {
+	for _, patch := range []string{"0", "99"} {
+		v, err := semverv3.NewVersion(branch + "." + patch)
+		if err == nil && constraint.Check(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAutoInstall returns a violation if the value is not <chart>=<version> or <chart>=match,
+// or if that version of the chart, e.g. the CRD chart, is not in charts/
+func checkAutoInstall(ctx context.Context, repoFs billy.Filesystem, value, chartVersion string) string {
+	name, version, ok := strings.Cut(value, "=")
+	if !ok || name == "" || version == "" {
+		return fmt.Sprintf("%q must be <chart>=<version> or <chart>=%s", value, autoInstallMatch)
+	}
+	if version == autoInstallMatch {
+		version = chartVersion
+	}
+
+	chartPath := filepath.Join(path.RepositoryChartsDir, name, version)
+	exists, err := filesystem.PathExists(ctx, repoFs, chartPath)
+	if err != nil {
+		return err.Error()
+	}
+	if !exists {
+		return fmt.Sprintf("references %s %s, which is not in %s", name, version, path.RepositoryChartsDir)
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/lifecycle"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateAnnotations(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+
+	files := map[string]string{
+		"charts/fleet/104.1.0+up0.10.1/Chart.yaml": `apiVersion: v2
+name: fleet
+version: 104.1.0+up0.10.1
+annotations:
+  catalog.cattle.io/auto-install: fleet-crd=match
+  catalog.cattle.io/certified: rancher
+  catalog.cattle.io/display-name: Fleet
+  catalog.cattle.io/kube-version: '>= 1.18.0-0'
+  catalog.cattle.io/namespace: cattle-fleet-system
+  catalog.cattle.io/os: linux
+  catalog.cattle.io/permits-os: linux,windows
+  catalog.cattle.io/rancher-version: '>= 2.9.0-0 < 2.10.0-0'
+  catalog.cattle.io/release-name: fleet
+`,
+		"charts/fleet/105.0.0+up0.11.0/Chart.yaml": `apiVersion: v2
+name: fleet
+version: 105.0.0+up0.11.0
+annotations:
+  catalog.cattle.io/auto-install: fleet-crd=105.0.1+up0.11.0
+  catalog.cattle.io/certified: community
+  catalog.cattle.io/kube-version: '>= 1.18.0-0 <<'
+  catalog.cattle.io/namespace: Cattle_Fleet
+  catalog.cattle.io/os: linux,darwin
+  catalog.cattle.io/rancher-version: '>= 2.9.0-0'
+  catalog.cattle.io/release-name: fleet
+`,
+		"charts/fleet-crd/104.1.0+up0.10.1/Chart.yaml": `apiVersion: v2
+name: fleet-crd
+version: 104.1.0+up0.10.1
+annotations:
+  catalog.cattle.io/certified: rancher
+  catalog.cattle.io/hidden: "true"
+  catalog.cattle.io/release-name: fleet-crd
+`,
+		"charts/rancher-webhook/104.0.0+up0.5.0/Chart.yaml": `apiVersion: v2
+name: rancher-webhook
+version: 104.0.0+up0.5.0
+kubeVersion: '>= 1.23.0-0'
+annotations:
+  catalog.cattle.io/auto-install: rancher-webhook-crd
+  catalog.cattle.io/certified: rancher
+  catalog.cattle.io/display-name: Rancher Webhook
+  catalog.cattle.io/hidden: "yes"
+  catalog.cattle.io/rancher-version: '>= 2.8.0-0 < 2.9.0-0'
+`,
+		"charts/rancher-webhook/README.md": "not a chart version\n",
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoRoot, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, file), []byte(content), 0644))
+	}
+
+	versionRules := &lifecycle.VersionRules{Rules: map[string]lifecycle.Version{
+		"2.10": {Min: "105.0.0", Max: "106.0.0"},
+		"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+	}}
+
+	violations, err := ValidateAnnotations(context.Background(), filesystem.GetFilesystem(repoRoot), versionRules, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []AnnotationViolation{
+		{"fleet", "105.0.0+up0.11.0", autoInstallAnnotation, "references fleet-crd 105.0.1+up0.11.0, which is not in charts"},
+		{"fleet", "105.0.0+up0.11.0", certifiedAnnotation, `"community" must be one of rancher, partner`},
+		{"fleet", "105.0.0+up0.11.0", displayNameAnnotation, "missing"},
+		{"fleet", "105.0.0+up0.11.0", kubeVersionAnnotation, `invalid range ">= 1.18.0-0 <<": improper constraint: >= 1.18.0-0 <<`},
+		{"fleet", "105.0.0+up0.11.0", namespaceAnnotation, "a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')"},
+		{"fleet", "105.0.0+up0.11.0", osAnnotation, `"darwin" must be one of linux, windows`},
+		{"rancher-webhook", "104.0.0+up0.5.0", autoInstallAnnotation, `"rancher-webhook-crd" must be <chart>=<version> or <chart>=match`},
+		{"rancher-webhook", "104.0.0+up0.5.0", hiddenAnnotation, `"yes" must be one of true, false`},
+		{"rancher-webhook", "104.0.0+up0.5.0", rancherVersionAnnotation, `range ">= 2.8.0-0 < 2.9.0-0" does not admit Rancher 2.9, the branch version of 104.0.0+up0.5.0`},
+		{"rancher-webhook", "104.0.0+up0.5.0", releaseNameAnnotation, "missing"},
+	}, violations)
+
+	violations, err = ValidateAnnotations(context.Background(), filesystem.GetFilesystem(repoRoot), versionRules, "fleet/104.1.0+up0.10.1")
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func Test_checkRancherVersion(t *testing.T) {
+	versionRules := &lifecycle.VersionRules{Rules: map[string]lifecycle.Version{
+		"2.10": {Min: "105.0.0", Max: ""},
+		"2.9":  {Min: "104.0.0", Max: "105.0.0"},
+		"2.8":  {Min: "", Max: "104.0.0"},
+	}}
+
+	tests := []struct {
+		name         string
+		value        string
+		chartVersion string
+		rules        *lifecycle.VersionRules
+		expected     string
+	}{
+		{"#1 branch range", ">= 2.9.0-0 < 2.10.0-0", "104.0.0+up0.10.0", versionRules, ""},
+		{"#2 range starting at a patch", ">= 2.9.3-0 < 2.10.0-0", "104.0.0+up0.10.0", versionRules, ""},
+		{"#3 older branch", ">= 2.8.0-0 < 2.9.0-0", "104.0.0+up0.10.0", versionRules, `range ">= 2.8.0-0 < 2.9.0-0" does not admit Rancher 2.9, the branch version of 104.0.0+up0.10.0`},
+		{"#4 open range", ">= 2.9.0-0", "104.0.0+up0.10.0", versionRules, `range ">= 2.9.0-0" admits Rancher 2.10, but 104.0.0+up0.10.0 belongs to Rancher 2.9`},
+		{"#5 open range of the newest branch", ">= 2.10.0-0", "105.0.0+up0.11.0", versionRules, ""},
+		{"#6 without version rules", ">= 2.9.0-0", "104.0.0+up0.10.0", nil, ""},
+		{"#7 invalid range", "2.9.x <", "104.0.0+up0.10.0", nil, `invalid range "2.9.x <": improper constraint: 2.9.x <`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, checkRancherVersion(tt.value, tt.chartVersion, tt.rules))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// CRDCompatibility is the result of comparing the CRDs of a released chart version with the previous version
+type CRDCompatibility struct {
+	Chart             string
+	Version           string
+	PreviousVersion   string
+	Incompatibilities []crds.Incompatibility
+	// Unacknowledged are the Incompatibilities that are not acknowledged under crdIncompatibilities in package.yaml
+	Unacknowledged []crds.Incompatibility
+}
+
+// CheckCRDCompatibility compares the CRDs of every chart version in release.yaml with the latest version
+// of the chart in index.yaml released before it, and returns the versions with incompatible CRD changes.
+// Versions removed from assets/ are skipped. acknowledged lists, per chart name, the incompatibilities that are intended.
+func CheckCRDCompatibility(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions, acknowledged map[string][]string) ([]CRDCompatibility, error) {
+	released, err := releasedVersions(ctx, repoFs, releaseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []CRDCompatibility{}
+	for _, r := range released {
+		logger.Log(ctx, slog.LevelDebug, "checking CRD compatibility", slog.String("chart", r.Chart), slog.String("version", r.Version), slog.String("previous", r.Previous))
+
+		incompatibilities, err := compareAssetCRDs(repoFs, r.Chart, r.Previous, r.Version)
+		if err != nil {
+			return nil, err
+		}
+		if len(incompatibilities) > 0 {
+			results = append(results, CRDCompatibility{
+				Chart:             r.Chart,
+				Version:           r.Version,
+				PreviousVersion:   r.Previous,
+				Incompatibilities: incompatibilities,
+				Unacknowledged:    crds.Unacknowledged(incompatibilities, acknowledged[r.Chart]),
+			})
+		}
+	}
+	return results, nil
+}
+
+// compareAssetCRDs loads the CRDs of both assets/<chart>/<chart>-<version>.tgz and compares them
+func compareAssetCRDs(repoFs billy.Filesystem, chart, oldVersion, newVersion string) ([]crds.Incompatibility, error) {
+	oldCRDs, err := assetCRDs(repoFs, chart, oldVersion)
+	if err != nil {
+		return nil, err
+	}
+	newCRDs, err := assetCRDs(repoFs, chart, newVersion)
+	if err != nil {
+		return nil, err
+	}
+	return crds.Compare(oldCRDs, newCRDs), nil
+}
+
+// assetCRDs returns the CRDs of assets/<chart>/<chart>-<version>.tgz
+func assetCRDs(repoFs billy.Filesystem, chart, version string) ([]crds.CRD, error) {
+	asset := assetPath(chart, version)
+	c, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, asset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", asset, err)
+	}
+	return crds.FromChart(c)
+}
+
+// LoadCRDIncompatibilities merges the crdIncompatibilities acknowledged in the package.yaml of every package in packages/
+func LoadCRDIncompatibilities(ctx context.Context, repoRoot string) (map[string][]string, error) {
+	return loadAcknowledged(ctx, repoRoot, func(pkgOpts options.PackageOptions) map[string][]string {
+		return pkgOpts.CRDIncompatibilities
+	})
+}
@@ -0,0 +1,92 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/crds"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+func Test_CheckCRDCompatibility(t *testing.T) {
+	repoRoot := t.TempDir()
+	crd := func(versions string) []*chart.File {
+		return []*chart.File{{Name: "crds/crd.yaml", Data: []byte("apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: gitrepos.fleet.cattle.io\nspec:\n  versions:\n" + versions)}}
+	}
+	save := func(name, version string, files []*chart.File) {
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version},
+			Files:    files,
+		}
+		_, err := chartutil.Save(c, filepath.Join(repoRoot, "assets", name))
+		assert.NoError(t, err)
+	}
+	save("fleet-crd", "105.0.0+up0.11.0", crd("  - name: v1alpha1\n    served: true\n    storage: true\n"))
+	save("fleet-crd", "105.1.0+up0.12.0", crd("  - name: v1\n    served: true\n    storage: true\n"))
+	save("fleet", "105.0.0+up0.11.0", nil)
+	save("fleet", "105.1.0+up0.12.0", nil)
+
+	index, err := helmRepo.IndexDirectory(filepath.Join(repoRoot, "assets"), "assets")
+	assert.NoError(t, err)
+	assert.NoError(t, index.WriteFile(filepath.Join(repoRoot, "index.yaml"), os.ModePerm))
+
+	incompatibilities := []crds.Incompatibility{
+		{CRD: "gitrepos.fleet.cattle.io", Version: "v1alpha1", Rule: crds.RemovedVersion, Message: "served version v1alpha1 is removed or no longer served"},
+		{CRD: "gitrepos.fleet.cattle.io", Version: "v1", Rule: crds.StorageVersion, Message: "storage version changed from v1alpha1 to v1"},
+	}
+	// the RC of the new version was released before and is removed from assets/ in this release
+	releaseOptions := options.ReleaseOptions{
+		"fleet":     {"105.1.0+up0.12.0-rc.1", "105.1.0+up0.12.0"},
+		"fleet-crd": {"105.1.0+up0.12.0-rc.1", "105.1.0+up0.12.0"},
+	}
+
+	tests := []struct {
+		name           string
+		acknowledged   map[string][]string
+		unacknowledged []crds.Incompatibility
+	}{
+		{"#1 unacknowledged", nil, incompatibilities},
+		{"#2 acknowledged CRD version", map[string][]string{"fleet-crd": {"gitrepos.fleet.cattle.io v1alpha1"}}, incompatibilities[1:]},
+		{"#3 acknowledged CRD", map[string][]string{"fleet-crd": {"gitrepos.fleet.cattle.io"}}, []crds.Incompatibility{}},
+		{"#4 acknowledged for another chart", map[string][]string{"fleet": {"gitrepos.fleet.cattle.io"}}, incompatibilities},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := CheckCRDCompatibility(context.Background(), filesystem.GetFilesystem(repoRoot), releaseOptions, tt.acknowledged)
+			assert.NoError(t, err)
+			assert.Equal(t, []CRDCompatibility{
+				{
+					Chart:             "fleet-crd",
+					Version:           "105.1.0+up0.12.0",
+					PreviousVersion:   "105.0.0+up0.11.0",
+					Incompatibilities: incompatibilities,
+					Unacknowledged:    tt.unacknowledged,
+				},
+			}, results)
+		})
+	}
+}
+
+func Test_LoadCRDIncompatibilities(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+	write := func(pkg, content string) {
+		dir := filepath.Join(repoRoot, "packages", pkg)
+		assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.yaml"), []byte(content), 0644))
+	}
+	write("fleet", "url: local\ncrdIncompatibilities:\n  fleet-crd: [gitrepos.fleet.cattle.io v1alpha1]\n")
+	write("rancher-webhook", "url: local\n")
+
+	crdIncompatibilities, err := LoadCRDIncompatibilities(context.Background(), repoRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"fleet-crd": {"gitrepos.fleet.cattle.io v1alpha1"}}, crdIncompatibilities)
+}
@@ -0,0 +1,177 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"helm.sh/helm/v3/pkg/chart"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+// IndexViolation is an entry of index.yaml that does not match assets/, or an archive of assets/ missing from index.yaml
+type IndexViolation struct {
+	Chart   string
+	Version string
+	// URL is the archive of the entry, or the archive missing from index.yaml
+	URL     string
+	Message string
+}
+
+// CheckIndex verifies the index.yaml against the archives of assets/: the URL of every entry must be an archive of assets/
+// with the entry digest and the name, version, appVersion, annotations and dependencies of its Chart.yaml,
+// the versions of each chart must be unique and sorted like helm.SortVersions does, and every chart archive must be indexed.
+// Only index.yaml and assets/ are read, so it can run on any branch.
+func CheckIndex(ctx context.Context, repoFs billy.Filesystem) ([]IndexViolation, error) {
+	// helmRepo.LoadIndexFile sorts the entries and drops the invalid ones, so index.yaml is read as-is
+	index, err := helm.LoadIndexFileAsIs(filesystem.GetAbsPath(repoFs, path.RepositoryHelmIndexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []IndexViolation{}
+	indexed := map[string]bool{}
+
+	charts := make([]string, 0, len(index.Entries))
+	for name := range index.Entries {
+		charts = append(charts, name)
+	}
+	sort.Strings(charts)
+
+	for _, name := range charts {
+		duplicates, sorted := helm.CheckVersionsOrder(index.Entries[name])
+		for _, version := range duplicates {
+			violations = append(violations, IndexViolation{Chart: name, Version: version, Message: "duplicate version"})
+		}
+		if !sorted {
+			violations = append(violations, IndexViolation{Chart: name, Message: fmt.Sprintf("versions are not sorted, expected %s", strings.Join(sortedVersions(index.Entries[name]), ", "))})
+		}
+
+		for _, chartVersion := range index.Entries[name] {
+			if chartVersion == nil || chartVersion.Metadata == nil {
+				violations = append(violations, IndexViolation{Chart: name, Message: "empty entry"})
+				continue
+			}
+			if len(chartVersion.URLs) == 0 {
+				violations = append(violations, IndexViolation{Chart: name, Version: chartVersion.Version, Message: "no url"})
+				continue
+			}
+			for _, url := range chartVersion.URLs {
+				indexed[url] = true
+				for _, message := range checkIndexEntry(ctx, repoFs, name, chartVersion, url) {
+					violations = append(violations, IndexViolation{Chart: name, Version: chartVersion.Version, URL: url, Message: message})
+				}
+			}
+		}
+	}
+
+	missing, err := unindexedArchives(ctx, repoFs, indexed)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, missing...), nil
+}
+
+// checkIndexEntry returns the violations of an entry against the archive at url
+func checkIndexEntry(ctx context.Context, repoFs billy.Filesystem, name string, chartVersion *helmRepo.ChartVersion, url string) []string {
+	if strings.Contains(url, "://") || !strings.HasPrefix(url, path.RepositoryAssetsDir+"/") {
+		return []string{fmt.Sprintf("url is not an archive of %s", path.RepositoryAssetsDir)}
+	}
+	exists, err := filesystem.PathExists(ctx, repoFs, url)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if !exists {
+		return []string{"archive does not exist"}
+	}
+
+	absPath := filesystem.GetAbsPath(repoFs, url)
+	var messages []string
+	digest, err := provenance.DigestFile(absPath)
+	if err != nil {
+		return []string{fmt.Sprintf("failed to compute digest: %s", err)}
+	}
+	if digest != chartVersion.Digest {
+		messages = append(messages, fmt.Sprintf("digest %s does not match the archive digest %s", chartVersion.Digest, digest))
+	}
+
+	c, err := helmLoader.Load(absPath)
+	if err != nil {
+		return append(messages, fmt.Sprintf("failed to load archive: %s", err))
+	}
+	return append(messages, compareIndexMetadata(name, chartVersion.Metadata, c.Metadata)...)
+}
+
+// compareIndexMetadata returns a message for each field of the entry metadata that differs from the archive Chart.yaml
+func compareIndexMetadata(name string, entry, archive *chart.Metadata) []string {
+	var messages []string
+	if name != archive.Name || entry.Name != archive.Name {
+		messages = append(messages, fmt.Sprintf("name %s does not match the archive name %s", entry.Name, archive.Name))
+	}
+	if entry.Version != archive.Version {
+		messages = append(messages, fmt.Sprintf("version does not match the archive version %s", archive.Version))
+	}
+	if entry.AppVersion != archive.AppVersion {
+		messages = append(messages, fmt.Sprintf("appVersion %s does not match the archive appVersion %s", entry.AppVersion, archive.AppVersion))
+	}
+	if !helm.SameIndexValue(entry.Annotations, archive.Annotations) {
+		messages = append(messages, "annotations do not match the archive annotations")
+	}
+	if !helm.SameIndexValue(entry.Dependencies, archive.Dependencies) {
+		messages = append(messages, "dependencies do not match the archive dependencies")
+	}
+	return messages
+}
+
+// versionsOf returns the versions of the entries in order
+func versionsOf(chartVersions helmRepo.ChartVersions) []string {
+	versions := make([]string, 0, len(chartVersions))
+	for _, chartVersion := range chartVersions {
+		versions = append(versions, chartVersion.Version)
+	}
+	return versions
+}
+
+// sortedVersions returns the versions of the non-empty entries in the order helm.SortVersions sorts them
+func sortedVersions(chartVersions helmRepo.ChartVersions) []string {
+	sorted := helmRepo.NewIndexFile()
+	for _, chartVersion := range chartVersions {
+		if chartVersion != nil && chartVersion.Metadata != nil {
+			sorted.Entries["chart"] = append(sorted.Entries["chart"], chartVersion)
+		}
+	}
+	helm.SortVersions(sorted)
+	return versionsOf(sorted.Entries["chart"])
+}
+
+// unindexedArchives returns a violation for each chart archive of assets/ that no entry of index.yaml points to
+func unindexedArchives(ctx context.Context, repoFs billy.Filesystem, indexed map[string]bool) ([]IndexViolation, error) {
+	archives, err := helm.AssetArchives(repoFs)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []IndexViolation
+	for _, url := range archives {
+		if indexed[url] {
+			continue
+		}
+		c, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, url))
+		if err != nil {
+			// like helmRepo.IndexDirectory, assume this is not a chart
+			logger.Log(ctx, slog.LevelDebug, "skipping archive that is not a chart", slog.String("url", url), logger.Err(err))
+			continue
+		}
+		violations = append(violations, IndexViolation{Chart: c.Metadata.Name, Version: c.Metadata.Version, URL: url, Message: "archive is not in index.yaml"})
+	}
+	return violations, nil
+}
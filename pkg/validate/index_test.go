@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+func Test_CheckIndex(t *testing.T) {
+	util.InitSoftErrorMode()
+	ctx := context.Background()
+	repoRoot := t.TempDir()
+	rootFs := filesystem.GetFilesystem(repoRoot)
+
+	save := func(name, version string, annotations map[string]string) {
+		c := &chart.Chart{Metadata: &chart.Metadata{
+			APIVersion:  chart.APIVersionV2,
+			Name:        name,
+			Version:     version,
+			AppVersion:  "0.11.0",
+			Annotations: annotations,
+		}}
+		_, err := chartutil.Save(c, filepath.Join(repoRoot, "assets", name))
+		assert.NoError(t, err)
+	}
+	save("fleet", "105.0.0+up0.11.0", map[string]string{"catalog.cattle.io/certified": "rancher"})
+	save("fleet", "105.0.1+up0.11.1-rc.1", nil)
+	save("fleet", "105.0.1+up0.11.1", nil)
+	save("fleet-crd", "105.0.0+up0.11.0", nil)
+	assert.NoError(t, helm.CreateOrUpdateHelmIndex(ctx, rootFs))
+
+	violations, err := CheckIndex(ctx, rootFs)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	indexPath := filepath.Join(repoRoot, "index.yaml")
+	index, err := helmRepo.LoadIndexFile(indexPath)
+	assert.NoError(t, err)
+	fleet := index.Entries["fleet"]
+	assert.Equal(t, []string{"105.0.1+up0.11.1", "105.0.1+up0.11.1-rc.1", "105.0.0+up0.11.0"}, []string{fleet[0].Version, fleet[1].Version, fleet[2].Version})
+
+	// corrupt the index: unsorted versions, a duplicate, a wrong digest, edited metadata, a missing archive and an unindexed one
+	fleet[0], fleet[1] = fleet[1], fleet[0]
+	fleet[2].Digest = "0000"
+	fleet[2].AppVersion = "0.10.0"
+	fleet[2].Annotations = nil
+	duplicate := *fleet[0]
+	missing := *fleet[0]
+	missing.Metadata = &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "fleet", Version: "104.0.0+up0.10.0"}
+	missing.URLs = []string{"assets/fleet/fleet-104.0.0+up0.10.0.tgz"}
+	index.Entries["fleet"] = append(fleet, &duplicate, &missing)
+	delete(index.Entries, "fleet-crd")
+	assert.NoError(t, index.WriteFile(indexPath, 0644))
+
+	violations, err = CheckIndex(ctx, rootFs)
+	assert.NoError(t, err)
+	messages := map[string]bool{}
+	for _, violation := range violations {
+		messages[violation.Chart+" "+violation.Version+": "+violation.Message] = true
+	}
+	for _, expected := range []string{
+		"fleet 105.0.1+up0.11.1-rc.1: duplicate version",
+		"fleet 105.0.0+up0.11.0: appVersion 0.10.0 does not match the archive appVersion 0.11.0",
+		"fleet 105.0.0+up0.11.0: annotations do not match the archive annotations",
+		"fleet 104.0.0+up0.10.0: archive does not exist",
+		"fleet-crd 105.0.0+up0.11.0: archive is not in index.yaml",
+	} {
+		assert.True(t, messages[expected], "missing violation %q in %v", expected, messages)
+	}
+	assert.Contains(t, messages, "fleet : versions are not sorted, expected 105.0.1+up0.11.1, 105.0.1+up0.11.1-rc.1, 105.0.1+up0.11.1-rc.1, 105.0.0+up0.11.0, 104.0.0+up0.10.0")
+	assert.Contains(t, messages, "fleet 105.0.0+up0.11.0: digest 0000 does not match the archive digest "+digestOf(t, filepath.Join(repoRoot, "assets", "fleet", "fleet-105.0.0+up0.11.0.tgz")))
+
+	// a rebuild fixes every violation
+	assert.NoError(t, helm.RebuildHelmIndex(ctx, rootFs))
+	violations, err = CheckIndex(ctx, rootFs)
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func digestOf(t *testing.T, archive string) string {
+	t.Helper()
+	index, err := helmRepo.IndexDirectory(filepath.Dir(archive), "")
+	assert.NoError(t, err)
+	for _, chartVersions := range index.Entries {
+		for _, chartVersion := range chartVersions {
+			if chartVersion.URLs[0] == filepath.Base(archive) {
+				return chartVersion.Digest
+			}
+		}
+	}
+	return ""
+}
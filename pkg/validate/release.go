@@ -0,0 +1,132 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/charts"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+// releasedVersion is a chart version of release.yaml and the latest version of the chart in index.yaml released before it
+type releasedVersion struct {
+	Chart    string
+	Version  string
+	Previous string
+}
+
+// releasedVersions returns, sorted by chart, the versions of release.yaml to compare with their previous released version.
+// Versions without a previous version in index.yaml, and versions not in assets/, i.e. removed in this release, are skipped.
+func releasedVersions(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions) ([]releasedVersion, error) {
+	released := []releasedVersion{}
+	if len(releaseOptions) == 0 {
+		return released, nil
+	}
+
+	index, err := helmRepo.LoadIndexFile(filesystem.GetAbsPath(repoFs, path.RepositoryHelmIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", path.RepositoryHelmIndexFile, err)
+	}
+
+	chartNames := make([]string, 0, len(releaseOptions))
+	for chart := range releaseOptions {
+		chartNames = append(chartNames, chart)
+	}
+	sort.Strings(chartNames)
+
+	for _, chart := range chartNames {
+		var indexVersions []string
+		for _, chartVersion := range index.Entries[chart] {
+			indexVersions = append(indexVersions, chartVersion.Version)
+		}
+
+		for _, version := range releaseOptions[chart] {
+			previous := previousReleasedVersion(version, indexVersions, releaseOptions[chart])
+			if previous == "" {
+				continue
+			}
+			exists, err := assetExists(ctx, repoFs, chart, version)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				// removed in this release
+				logger.Log(ctx, slog.LevelDebug, "skipping chart version not found in assets", slog.String("chart", chart), slog.String("version", version))
+				continue
+			}
+			released = append(released, releasedVersion{Chart: chart, Version: version, Previous: previous})
+		}
+	}
+	return released, nil
+}
+
+// previousReleasedVersion returns the newest of the versions lower than version that is not being released, or "" if there is none
+func previousReleasedVersion(version string, versions, releasing []string) string {
+	target, err := semver.NewVersion(version)
+	if err != nil {
+		return ""
+	}
+
+	var previous *semver.Version
+	for _, v := range versions {
+		if slices.Contains(releasing, v) {
+			continue
+		}
+		candidate, err := semver.NewVersion(v)
+		if err != nil || !candidate.LessThan(target) {
+			continue
+		}
+		if previous == nil || candidate.GreaterThan(previous) {
+			previous = candidate
+		}
+	}
+	if previous == nil {
+		return ""
+	}
+	return previous.Original()
+}
+
+// assetPath returns assets/<chart>/<chart>-<version>.tgz
+func assetPath(chart, version string) string {
+	return fmt.Sprintf("%s/%s/%s-%s.tgz", path.RepositoryAssetsDir, chart, chart, version)
+}
+
+// assetExists returns whether assets/<chart>/<chart>-<version>.tgz exists
+func assetExists(ctx context.Context, repoFs billy.Filesystem, chart, version string) (bool, error) {
+	return filesystem.PathExists(ctx, repoFs, assetPath(chart, version))
+}
+
+// loadAcknowledged merges, per chart name, the acknowledgements returned by field for the package.yaml of every package in packages/
+func loadAcknowledged(ctx context.Context, repoRoot string, field func(options.PackageOptions) map[string][]string) (map[string][]string, error) {
+	packages, err := charts.ListPackages(ctx, repoRoot, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rootFs := filesystem.GetFilesystem(repoRoot)
+	acknowledged := make(map[string][]string)
+	for _, pkg := range packages {
+		pkgFs, err := rootFs.Chroot(filepath.Join(path.RepositoryPackagesDir, pkg))
+		if err != nil {
+			return nil, err
+		}
+		pkgOpts, err := options.LoadPackageOptionsFromFile(ctx, pkgFs, path.PackageOptionsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the package options of %s: %w", pkg, err)
+		}
+		for chart, entries := range field(pkgOpts) {
+			acknowledged[chart] = append(acknowledged[chart], entries...)
+		}
+	}
+	return acknowledged, nil
+}
@@ -0,0 +1,29 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_previousReleasedVersion(t *testing.T) {
+	versions := []string{"104.0.0+up1.0.0", "105.0.0+up1.1.0", "105.1.0+up1.2.0-rc.1", "105.1.0+up1.2.0"}
+
+	tests := []struct {
+		name      string
+		version   string
+		releasing []string
+		expected  string
+	}{
+		{"#1 newest lower version", "105.1.0+up1.2.0", []string{"105.1.0+up1.2.0"}, "105.0.0+up1.1.0"},
+		{"#2 versions being released are skipped", "105.1.0+up1.2.0", []string{"105.1.0+up1.2.0", "105.0.0+up1.1.0"}, "104.0.0+up1.0.0"},
+		{"#3 no previous version", "104.0.0+up1.0.0", []string{"104.0.0+up1.0.0"}, ""},
+		{"#4 invalid version", "latest", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, previousReleasedVersion(tt.version, versions, tt.releasing))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	"helm.sh/helm/v3/pkg/chart"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// kubeVersionAnnotation is the range of Kubernetes versions supported by a Rancher chart
+const kubeVersionAnnotation = "catalog.cattle.io/kube-version"
+
+// defaultValues names the render of a chart with only its default values
+const defaultValues = "default"
+
+// RenderCheck is the result of rendering a chart version with a values file against a Kubernetes version
+type RenderCheck struct {
+	Chart   string
+	Version string
+	// KubeVersion is empty when the default Helm capabilities are used
+	KubeVersion string
+	// Values is the values file rendered on top of the default values, or "default"
+	Values string
+	// Manifests is the file the rendered manifests were written to, if any
+	Manifests string
+	Error     string
+}
+
+// RenderCharts renders offline every chart version of release.yaml found in charts/, with its default values and
+// the values files of renderOptions, against each Kubernetes version of renderOptions within the chart's catalog.cattle.io/kube-version range.
+// If outputDir is set, the manifests of each render are written to <outputDir>/<chart>/<version>/<kube version>_<values>.yaml.
+// Template errors are reported in the results; an error is only returned if the charts or the values files cannot be read.
+func RenderCharts(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions, renderOptions *options.RenderCheckOptions, outputDir string) ([]RenderCheck, error) {
+	if renderOptions == nil {
+		renderOptions = &options.RenderCheckOptions{}
+	}
+
+	targets, err := renderTargets(ctx, repoFs, releaseOptions, renderOptions.ValuesFiles, renderOptions.KubeVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []RenderCheck{}
+	for _, target := range targets {
+		result := RenderCheck{Chart: target.chart, Version: target.version, KubeVersion: target.kubeVersion, Values: target.valuesFile, Error: target.err}
+		if target.err != "" {
+			results = append(results, result)
+			continue
+		}
+		logger.Log(ctx, slog.LevelDebug, "rendering chart", slog.String("chart", target.chart), slog.String("version", target.version),
+			slog.String("kubeVersion", target.kubeVersion), slog.String("values", target.valuesFile))
+
+		rendered, err := renderChart(repoFs, target)
+		if err != nil {
+			return nil, err
+		}
+		if rendered.err != nil {
+			result.Error = rendered.err.Error()
+		} else if outputDir != "" {
+			if result.Manifests, err = writeManifests(repoFs, target, rendered.templates, outputDir); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// renderTarget is a chart version to render with a values file against a Kubernetes version
+type renderTarget struct {
+	chart       string
+	version     string
+	chartPath   string
+	kubeVersion string
+	valuesFile  string
+	// err is set instead of the Kubernetes version if none of them is within the range of the chart
+	err string
+}
+
+// renderTargets returns the renders of every chart version of release.yaml found in charts/, with its default values and its valuesFiles,
+// against each of kubeVersions within the chart range, sorted by chart
+func renderTargets(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions, valuesFiles map[string][]string, kubeVersions []string) ([]renderTarget, error) {
+	charts := make([]string, 0, len(releaseOptions))
+	for chart := range releaseOptions {
+		charts = append(charts, chart)
+	}
+	sort.Strings(charts)
+
+	var targets []renderTarget
+	for _, chartName := range charts {
+		chartValuesFiles := append([]string{defaultValues}, valuesFiles[chartName]...)
+
+		for _, version := range releaseOptions[chartName] {
+			chartPath := filepath.Join(path.RepositoryChartsDir, chartName, version)
+			exists, err := filesystem.PathExists(ctx, repoFs, chartPath)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				// removed in this release
+				logger.Log(ctx, slog.LevelDebug, "skipping chart version not found in charts", slog.String("path", chartPath))
+				continue
+			}
+
+			c, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, chartPath))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", chartPath, err)
+			}
+			matching, constraint := chartKubeVersions(c.Metadata, kubeVersions)
+			if len(matching) == 0 {
+				targets = append(targets, renderTarget{
+					chart:      chartName,
+					version:    version,
+					chartPath:  chartPath,
+					valuesFile: defaultValues,
+					err:        fmt.Sprintf("none of the Kubernetes versions %v is within the range %s", kubeVersions, constraint),
+				})
+				continue
+			}
+
+			for _, kubeVersion := range matching {
+				for _, valuesFile := range chartValuesFiles {
+					targets = append(targets, renderTarget{chart: chartName, version: version, chartPath: chartPath, kubeVersion: kubeVersion, valuesFile: valuesFile})
+				}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// chartKubeVersions returns the Kubernetes versions within the range supported by the chart and the range.
+// The range is taken from the catalog.cattle.io/kube-version annotation, or the kubeVersion of the Chart.yaml.
+// If no Kubernetes version is configured, a single "" is returned to render with the default capabilities.
+func chartKubeVersions(metadata *chart.Metadata, kubeVersions []string) ([]string, string) {
+	constraint := metadata.Annotations[kubeVersionAnnotation]
+	if constraint == "" {
+		constraint = metadata.KubeVersion
+	}
+	if len(kubeVersions) == 0 {
+		return []string{""}, constraint
+	}
+
+	var matching []string
+	for _, kubeVersion := range kubeVersions {
+		if constraint == "" || chartutil.IsCompatibleRange(constraint, kubeVersion) {
+			matching = append(matching, kubeVersion)
+		}
+	}
+	return matching, constraint
+}
+
+// renderedChart holds the rendered templates of a chart, or the error of the Helm engine
+type renderedChart struct {
+	chart     *chart.Chart
+	templates map[string]string
+	err       error
+}
+
+// renderChart renders a target; an error is only returned if the chart or the values file cannot be read.
+// The chart is loaded again for every render since processing its dependencies depends on the values.
+func renderChart(repoFs billy.Filesystem, target renderTarget) (renderedChart, error) {
+	values := map[string]interface{}{}
+	if target.valuesFile != defaultValues {
+		v, err := chartutil.ReadValuesFile(filesystem.GetAbsPath(repoFs, target.valuesFile))
+		if err != nil {
+			return renderedChart{}, fmt.Errorf("failed to read values file %s: %w", target.valuesFile, err)
+		}
+		values = v
+	}
+
+	c, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, target.chartPath))
+	if err != nil {
+		return renderedChart{}, fmt.Errorf("failed to load %s: %w", target.chartPath, err)
+	}
+	templates, err := helm.RenderChartForKubeVersion(c, values, target.kubeVersion)
+	return renderedChart{chart: c, templates: templates, err: err}, nil
+}
+
+// writeManifests writes the rendered manifests of a target to <outputDir>/<chart>/<version>/<kube version>_<values>.yaml and returns the file
+func writeManifests(repoFs billy.Filesystem, target renderTarget, templates map[string]string, outputDir string) (string, error) {
+	kubeLabel := target.kubeVersion
+	if kubeLabel == "" {
+		kubeLabel = "default"
+	}
+	valuesLabel := strings.TrimSuffix(filepath.Base(target.valuesFile), filepath.Ext(target.valuesFile))
+	file := filepath.Join(outputDir, target.chart, target.version, fmt.Sprintf("%s_%s.yaml", kubeLabel, valuesLabel))
+
+	if err := os.MkdirAll(filepath.Dir(filesystem.GetAbsPath(repoFs, file)), os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filesystem.GetAbsPath(repoFs, file), []byte(manifests(templates)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return file, nil
+}
+
+// manifests joins the rendered templates like helm template: sorted by name, each with its source and without NOTES.txt
+func manifests(rendered map[string]string) string {
+	names := make([]string, 0, len(rendered))
+	for name, content := range rendered {
+		if strings.HasSuffix(name, "NOTES.txt") || strings.TrimSpace(content) == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", name, strings.TrimSpace(rendered[name]))
+	}
+	return b.String()
+}
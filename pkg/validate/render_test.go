@@ -0,0 +1,104 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func Test_chartKubeVersions(t *testing.T) {
+	kubeVersions := []string{"v1.28.0", "v1.30.0", "v1.32.0"}
+
+	tests := []struct {
+		name         string
+		metadata     *chart.Metadata
+		kubeVersions []string
+		expected     []string
+	}{
+		{"#1 annotation range", &chart.Metadata{Annotations: map[string]string{kubeVersionAnnotation: ">= 1.29.0-0 < 1.33.0-0"}}, kubeVersions, []string{"v1.30.0", "v1.32.0"}},
+		{"#2 kubeVersion of the Chart.yaml", &chart.Metadata{KubeVersion: "< 1.29.0-0"}, kubeVersions, []string{"v1.28.0"}},
+		{"#3 no range", &chart.Metadata{}, kubeVersions, kubeVersions},
+		{"#4 no Kubernetes version configured", &chart.Metadata{KubeVersion: "< 1.29.0-0"}, nil, []string{""}},
+		{"#5 no Kubernetes version within the range", &chart.Metadata{KubeVersion: "> 1.40.0-0"}, kubeVersions, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matching, _ := chartKubeVersions(tt.metadata, tt.kubeVersions)
+			assert.Equal(t, tt.expected, matching)
+		})
+	}
+}
+
+func Test_RenderCharts(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+
+	files := map[string]string{
+		"charts/fleet/105.0.0+up0.11.0/Chart.yaml":                  "apiVersion: v2\nname: fleet\nversion: 105.0.0+up0.11.0\nannotations:\n  catalog.cattle.io/kube-version: '>= 1.29.0-0'\n",
+		"charts/fleet/105.0.0+up0.11.0/values.yaml":                 "replicas: 1\n",
+		"charts/fleet/105.0.0+up0.11.0/templates/deployment.yaml":   "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\nspec:\n  replicas: {{ .Values.replicas }}\n{{- if semverCompare \"< 1.31.0-0\" .Capabilities.KubeVersion.Version }}\n  minReadySeconds: 10\n{{- end }}\n",
+		"charts/fleet/105.0.0+up0.11.0/templates/NOTES.txt":         "fleet installed\n",
+		"charts/fleet/105.0.0+up0.11.0/templates/_helpers.tpl":      "{{- define \"fleet.name\" -}}fleet{{- end }}\n",
+		"charts/fleet-crd/105.0.0+up0.11.0/Chart.yaml":              "apiVersion: v2\nname: fleet-crd\nversion: 105.0.0+up0.11.0\n",
+		"charts/fleet-crd/105.0.0+up0.11.0/templates/required.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ required \"name is required\" .Values.name }}\n",
+		"test/values/fleet-ha.yaml":                                 "replicas: 3\n",
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoRoot, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, file), []byte(content), 0644))
+	}
+
+	releaseOptions := options.ReleaseOptions{
+		"fleet":     {"105.0.0+up0.11.0", "104.0.0+up0.10.0"},
+		"fleet-crd": {"105.0.0+up0.11.0"},
+	}
+	renderOptions := &options.RenderCheckOptions{
+		KubeVersions: []string{"v1.28.0", "v1.30.0", "v1.32.0"},
+		ValuesFiles:  map[string][]string{"fleet": {"test/values/fleet-ha.yaml"}},
+	}
+
+	results, err := RenderCharts(context.Background(), filesystem.GetFilesystem(repoRoot), releaseOptions, renderOptions, "logs/render-check")
+	assert.NoError(t, err)
+
+	var failed []RenderCheck
+	for _, result := range results {
+		if result.Error != "" {
+			failed = append(failed, result)
+		}
+	}
+	assert.Len(t, results, 7)
+	assert.Len(t, failed, 3)
+	for _, result := range failed {
+		assert.Equal(t, "fleet-crd", result.Chart)
+		assert.Contains(t, result.Error, "name is required")
+	}
+
+	read := func(file string) string {
+		content, err := os.ReadFile(filepath.Join(repoRoot, "logs/render-check/fleet/105.0.0+up0.11.0", file))
+		assert.NoError(t, err)
+		return string(content)
+	}
+	assert.Equal(t, "---\n# Source: fleet/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\nspec:\n  replicas: 1\n  minReadySeconds: 10\n", read("v1.30.0_default.yaml"))
+	assert.Equal(t, "---\n# Source: fleet/templates/deployment.yaml\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\nspec:\n  replicas: 3\n", read("v1.32.0_fleet-ha.yaml"))
+	assert.NoFileExists(t, filepath.Join(repoRoot, "logs/render-check/fleet/105.0.0+up0.11.0/v1.28.0_default.yaml"))
+}
+
+func Test_RenderCharts_missingValuesFile(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+	chartDir := filepath.Join(repoRoot, "charts", "fleet", "105.0.0+up0.11.0")
+	assert.NoError(t, os.MkdirAll(chartDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: fleet\nversion: 105.0.0+up0.11.0\n"), 0644))
+
+	_, err := RenderCharts(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{"fleet": {"105.0.0+up0.11.0"}},
+		&options.RenderCheckOptions{ValuesFiles: map[string][]string{"fleet": {"missing.yaml"}}}, "")
+	assert.ErrorContains(t, err, "failed to read values file missing.yaml")
+}
@@ -0,0 +1,292 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/crds"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/helm"
+	"github.com/rancher/charts-build-scripts/pkg/kubeapi"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/path"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+)
+
+// SchemaViolation is an object of a rendered chart version that is invalid, or uses a deprecated API, in some Kubernetes versions
+type SchemaViolation struct {
+	Chart   string
+	Version string
+	Values  string
+	// Template is the template the object was rendered from, e.g. fleet/templates/deployment.yaml
+	Template string
+	// Object is <apiVersion>/<kind>/<name>, empty if the template is not valid yaml
+	Object  string
+	Message string
+	// Level is slog.LevelWarn for APIs that are deprecated but still served, slog.LevelError otherwise
+	Level        slog.Level
+	KubeVersions []string
+}
+
+// CheckSchemas renders the charts like RenderCharts and validates the rendered objects against the vendored schemas of pkg/kubeapi
+// for each Kubernetes version within the chart range, or against the CRDs of the chart and of its -crd chart for custom resources.
+// Every renderCheck Kubernetes version must have a vendored schema; without any, the vendored versions are checked and the charts
+// whose range holds none of them are reported as warnings. APIs removed in a Kubernetes version are reported as errors, deprecated
+// ones as warnings. Objects of unknown kinds are skipped, and so are renders that fail, since RenderCharts reports them.
+func CheckSchemas(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions, renderOptions *options.RenderCheckOptions) ([]SchemaViolation, error) {
+	if renderOptions == nil {
+		renderOptions = &options.RenderCheckOptions{}
+	}
+	kubeVersions := renderOptions.KubeVersions
+	outOfRangeLevel := slog.LevelError
+	if len(kubeVersions) == 0 {
+		for _, vendored := range kubeapi.VendoredVersions() {
+			kubeVersions = append(kubeVersions, vendored+".0")
+		}
+		if len(kubeVersions) == 0 {
+			return nil, fmt.Errorf("no vendored Kubernetes schema")
+		}
+		outOfRangeLevel = slog.LevelWarn
+	}
+	var missing []string
+	for _, kubeVersion := range kubeVersions {
+		vendored, err := kubeapi.HasSchema(kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		if !vendored {
+			missing = append(missing, kubeVersion)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no vendored schema for the Kubernetes versions %v, vendor them in pkg/kubeapi/schemas with pkg/kubeapi/generate or remove them from renderCheck.kubeVersions", missing)
+	}
+
+	targets, err := renderTargets(ctx, repoFs, releaseOptions, renderOptions.ValuesFiles, kubeVersions)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []SchemaViolation
+	index := map[string]int{}
+	report := func(target renderTarget, template, object, message string, level slog.Level) {
+		key := strings.Join([]string{target.chart, target.version, target.valuesFile, template, object, message, level.String()}, "\x00")
+		if i, ok := index[key]; ok {
+			violations[i].KubeVersions = append(violations[i].KubeVersions, target.kubeVersion)
+			return
+		}
+		index[key] = len(violations)
+		violations = append(violations, SchemaViolation{
+			Chart:        target.chart,
+			Version:      target.version,
+			Values:       target.valuesFile,
+			Template:     template,
+			Object:       object,
+			Message:      message,
+			Level:        level,
+			KubeVersions: []string{target.kubeVersion},
+		})
+	}
+
+	customResources := map[string][]crds.CRD{}
+	for _, target := range targets {
+		if target.err != "" {
+			report(target, "", "", target.err, outOfRangeLevel)
+			continue
+		}
+		logger.Log(ctx, slog.LevelDebug, "checking schemas", slog.String("chart", target.chart), slog.String("version", target.version),
+			slog.String("kubeVersion", target.kubeVersion), slog.String("values", target.valuesFile))
+
+		rendered, err := renderChart(repoFs, target)
+		if err != nil {
+			return nil, err
+		}
+		if rendered.err != nil {
+			logger.Log(ctx, slog.LevelDebug, "skipping chart that failed to render", slog.String("chart", target.chart), slog.String("version", target.version),
+				slog.String("error", rendered.err.Error()))
+			continue
+		}
+
+		chartKey := target.chart + "/" + target.version
+		if _, ok := customResources[chartKey]; !ok {
+			if customResources[chartKey], err = chartCRDs(ctx, repoFs, target, rendered); err != nil {
+				return nil, err
+			}
+		}
+		document, err := kubeapi.LoadDocument(target.kubeVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		templates := make([]string, 0, len(rendered.templates))
+		for template := range rendered.templates {
+			templates = append(templates, template)
+		}
+		sort.Strings(templates)
+
+		for _, template := range templates {
+			if ext := filepath.Ext(template); ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			for _, manifest := range helm.SplitManifests(rendered.templates[template]) {
+				object := map[string]interface{}{}
+				if err := yaml.Unmarshal([]byte(manifest), &object); err != nil {
+					report(target, template, "", fmt.Sprintf("invalid yaml: %s", err), slog.LevelError)
+					continue
+				}
+				if len(object) == 0 {
+					continue
+				}
+				for _, v := range checkObject(ctx, document, customResources[chartKey], target.kubeVersion, object) {
+					report(target, template, objectName(object), v.message, v.level)
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// chartCRDs returns the CRDs of the rendered chart and of charts/<chart>-crd/<version>, if it exists
+func chartCRDs(ctx context.Context, repoFs billy.Filesystem, target renderTarget, rendered renderedChart) ([]crds.CRD, error) {
+	chartCRDs, err := crds.FromChart(rendered.chart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CRDs of %s: %w", target.chartPath, err)
+	}
+
+	crdChartPath := filepath.Join(path.RepositoryChartsDir, target.chart+"-crd", target.version)
+	exists, err := filesystem.PathExists(ctx, repoFs, crdChartPath)
+	if err != nil || !exists {
+		return chartCRDs, err
+	}
+	crdChart, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, crdChartPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", crdChartPath, err)
+	}
+	crdChartCRDs, err := crds.FromChart(crdChart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CRDs of %s: %w", crdChartPath, err)
+	}
+	return append(chartCRDs, crdChartCRDs...), nil
+}
+
+// objectViolation is a violation of an object in a single Kubernetes version
+type objectViolation struct {
+	message string
+	level   slog.Level
+}
+
+// checkObject returns the violations of an object in kubeVersion: whether its API is deprecated or removed,
+// and whether it is valid against its built-in schema in document, or the schema of its CRD
+func checkObject(ctx context.Context, document *kubeapi.Document, customResources []crds.CRD, kubeVersion string, object map[string]interface{}) []objectViolation {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		return []objectViolation{{"missing apiVersion or kind", slog.LevelError}}
+	}
+
+	var violations []objectViolation
+	deprecation, err := kubeapi.Deprecation(apiVersion, kind, kubeVersion)
+	if err != nil {
+		return []objectViolation{{err.Error(), slog.LevelError}}
+	}
+	if deprecation != nil {
+		if deprecation.Removed(kubeVersion) {
+			return []objectViolation{{deprecation.String(), slog.LevelError}}
+		}
+		violations = append(violations, objectViolation{deprecation.String(), slog.LevelWarn})
+	}
+
+	group, version := "", apiVersion
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group, version = apiVersion[:i], apiVersion[i+1:]
+	}
+	gvk := kubeapi.GroupVersionKind{Group: group, Version: version, Kind: kind}
+	if schema := document.Lookup(gvk.String()); schema != nil {
+		for _, message := range document.Validate(schema, object) {
+			violations = append(violations, objectViolation{message, slog.LevelError})
+		}
+		return violations
+	}
+
+	for _, crd := range customResources {
+		if crd.Group != group || crd.Kind != kind {
+			continue
+		}
+		crdVersion, ok := crd.Version(version)
+		if !ok || !crdVersion.Served {
+			return append(violations, objectViolation{fmt.Sprintf("version %s of %s is not served", version, crd.Name), slog.LevelError})
+		}
+		// the schema of a CRD is self-contained, resolving its refs against document is a no-op
+		for _, message := range document.Validate(customResourceSchema(crdVersion.Schema), object) {
+			violations = append(violations, objectViolation{message, slog.LevelError})
+		}
+		return violations
+	}
+
+	logger.Log(ctx, slog.LevelDebug, "skipping object of unknown kind", slog.String("apiVersion", apiVersion), slog.String("kind", kind))
+	return violations
+}
+
+// customResourceSchema converts the OpenAPI v3 schema of a CRD version. Like the API server,
+// apiVersion, kind and metadata are always allowed at the root of a custom resource.
+func customResourceSchema(schema *crds.Schema) *kubeapi.Schema {
+	converted := convertSchema(schema)
+	if converted == nil || len(converted.Properties) == 0 {
+		return converted
+	}
+	for _, field := range []string{"apiVersion", "kind"} {
+		if _, ok := converted.Properties[field]; !ok {
+			converted.Properties[field] = &kubeapi.Schema{Type: "string"}
+		}
+	}
+	if _, ok := converted.Properties["metadata"]; !ok {
+		converted.Properties["metadata"] = &kubeapi.Schema{Type: "object"}
+	}
+	return converted
+}
+
+// convertSchema converts a CRD schema to a kubeapi schema; additionalProperties: true preserves unknown fields
+func convertSchema(schema *crds.Schema) *kubeapi.Schema {
+	if schema == nil {
+		return nil
+	}
+	converted := &kubeapi.Schema{
+		Type:                  schema.Type,
+		Items:                 convertSchema(schema.Items),
+		Required:              schema.Required,
+		IntOrString:           schema.IntOrString,
+		PreserveUnknownFields: schema.PreserveUnknownFields,
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = map[string]*kubeapi.Schema{}
+		for name, property := range schema.Properties {
+			converted.Properties[name] = convertSchema(property)
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		if schema.AdditionalProperties.Schema != nil {
+			converted.AdditionalProperties = convertSchema(schema.AdditionalProperties.Schema)
+		} else if schema.AdditionalProperties.Allows {
+			converted.PreserveUnknownFields = true
+		}
+	}
+	return converted
+}
+
+// objectName returns <apiVersion>/<kind>/<name> of an object, e.g. apps/v1/Deployment/fleet
+func objectName(object map[string]interface{}) string {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	name := ""
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		name, _ = metadata["name"].(string)
+	}
+	return apiVersion + "/" + kind + "/" + name
+}
@@ -0,0 +1,131 @@
+package validate
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGitRepoCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gitrepos.fleet.cattle.io
+spec:
+  group: fleet.cattle.io
+  names:
+    kind: GitRepo
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [repo]
+            properties:
+              repo:
+                type: string
+              paths:
+                type: array
+                items:
+                  type: string
+              helm:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+              labels:
+                type: object
+                additionalProperties:
+                  type: string
+  - name: v0
+    served: false
+    storage: false
+`
+
+func Test_CheckSchemas(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+
+	chartDir := "charts/fleet/105.0.0+up0.11.0/"
+	files := map[string]string{
+		chartDir + "Chart.yaml":                                 "apiVersion: v2\nname: fleet\nversion: 105.0.0+up0.11.0\nannotations:\n  catalog.cattle.io/kube-version: '>= 1.23.0-0 < 1.32.0-0'\n",
+		chartDir + "values.yaml":                                "replicas: 1\n",
+		chartDir + "templates/deployment.yaml":                  "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: fleet\nspec:\n  replicas: {{ .Values.replicas }}\n  selector:\n    matchLabels:\n      app: fleet\n  template:\n    spec:\n      containers:\n      - name: fleet\n        image: rancher/fleet:v0.11.0\n",
+		chartDir + "templates/pdb.yaml":                         "apiVersion: policy/v1beta1\nkind: PodDisruptionBudget\nmetadata:\n  name: fleet\nspec:\n  minAvailable: 1\n",
+		chartDir + "templates/gitrepo.yaml":                     "apiVersion: fleet.cattle.io/v1alpha1\nkind: GitRepo\nmetadata:\n  name: local\nspec:\n  repo: https://github.com/rancher/fleet-examples\n  paths: [simple]\n  helm:\n    anything: true\n  labels:\n    env: 1\n---\napiVersion: fleet.cattle.io/v0\nkind: GitRepo\nmetadata:\n  name: old\nspec: {}\n---\napiVersion: example.com/v1\nkind: Unknown\nmetadata:\n  name: unknown\nspec:\n  anything: true\n",
+		chartDir + "templates/NOTES.txt":                        "fleet installed\n",
+		"charts/fleet-crd/105.0.0+up0.11.0/Chart.yaml":          "apiVersion: v2\nname: fleet-crd\nversion: 105.0.0+up0.11.0\n",
+		"charts/fleet-crd/105.0.0+up0.11.0/templates/crds.yaml": testGitRepoCRD,
+		"test/values/fleet-string-replicas.yaml":                "replicas: '\"3\"'\n",
+	}
+	for file, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repoRoot, file)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(repoRoot, file), []byte(content), 0644))
+	}
+
+	// without Kubernetes versions, only the vendored v1.31 is checked
+	violations, err := CheckSchemas(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{"fleet": {"105.0.0+up0.11.0"}},
+		&options.RenderCheckOptions{ValuesFiles: map[string][]string{"fleet": {"test/values/fleet-string-replicas.yaml"}}})
+	assert.NoError(t, err)
+
+	type violation struct {
+		values, template, object, message string
+		level                             slog.Level
+		kubeVersions                      []string
+	}
+	var actual []violation
+	for _, v := range violations {
+		assert.Equal(t, "fleet", v.Chart)
+		assert.Equal(t, "105.0.0+up0.11.0", v.Version)
+		actual = append(actual, violation{v.Values, v.Template, v.Object, v.Message, v.Level, v.KubeVersions})
+	}
+
+	vendored := []string{"v1.31.0"}
+	pdbRemoved := "policy/v1beta1 PodDisruptionBudget is deprecated in v1.21 and removed in v1.25, use policy/v1"
+	expected := []violation{
+		{defaultValues, "fleet/templates/gitrepo.yaml", "fleet.cattle.io/v1alpha1/GitRepo/local", ".spec.labels.env: expected string, got number", slog.LevelError, vendored},
+		{defaultValues, "fleet/templates/gitrepo.yaml", "fleet.cattle.io/v0/GitRepo/old", "version v0 of gitrepos.fleet.cattle.io is not served", slog.LevelError, vendored},
+		{defaultValues, "fleet/templates/pdb.yaml", "policy/v1beta1/PodDisruptionBudget/fleet", pdbRemoved, slog.LevelError, vendored},
+		{"test/values/fleet-string-replicas.yaml", "fleet/templates/deployment.yaml", "apps/v1/Deployment/fleet", ".spec.replicas: expected integer, got string", slog.LevelError, vendored},
+		{"test/values/fleet-string-replicas.yaml", "fleet/templates/gitrepo.yaml", "fleet.cattle.io/v1alpha1/GitRepo/local", ".spec.labels.env: expected string, got number", slog.LevelError, vendored},
+		{"test/values/fleet-string-replicas.yaml", "fleet/templates/gitrepo.yaml", "fleet.cattle.io/v0/GitRepo/old", "version v0 of gitrepos.fleet.cattle.io is not served", slog.LevelError, vendored},
+		{"test/values/fleet-string-replicas.yaml", "fleet/templates/pdb.yaml", "policy/v1beta1/PodDisruptionBudget/fleet", pdbRemoved, slog.LevelError, vendored},
+	}
+	assert.ElementsMatch(t, expected, actual)
+
+	// a Kubernetes version without a vendored schema is not skipped
+	_, err = CheckSchemas(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{"fleet": {"105.0.0+up0.11.0"}},
+		&options.RenderCheckOptions{KubeVersions: []string{"v1.28.0", "v1.31.0"}})
+	assert.ErrorContains(t, err, "no vendored schema for the Kubernetes versions [v1.28.0]")
+}
+
+func Test_CheckSchemas_outOfRange(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+	chartDir := filepath.Join(repoRoot, "charts", "fleet", "105.0.0+up0.11.0")
+	assert.NoError(t, os.MkdirAll(chartDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: fleet\nversion: 105.0.0+up0.11.0\nkubeVersion: '< 1.16.0-0'\n"), 0644))
+
+	// a chart outside of the vendored versions is only reported when no Kubernetes version is configured
+	violations, err := CheckSchemas(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{"fleet": {"105.0.0+up0.11.0"}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Message, "is within the range < 1.16.0-0")
+	assert.Equal(t, slog.LevelWarn, violations[0].Level)
+
+	violations, err = CheckSchemas(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{"fleet": {"105.0.0+up0.11.0"}},
+		&options.RenderCheckOptions{KubeVersions: []string{"v1.31.0"}})
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+	assert.Equal(t, slog.LevelError, violations[0].Level)
+}
@@ -80,6 +80,9 @@ func CompareGeneratedAssets(ctx context.Context, repoRoot string, repoFs billy.F
 		logger.Log(ctx, slog.LevelError, "failed to initialize lifecycle dependencies", logger.Err(err))
 		return response, err
 	}
+	if err := lifeCycleDep.CheckCleanTree(ctx); err != nil {
+		return response, err
+	}
 
 	// Pull repository
 	releasedChartsRepoBranch, err := puller.GetGithubRepository(u, &branch)
@@ -128,7 +131,7 @@ func CompareGeneratedAssets(ctx context.Context, repoRoot string, repoFs billy.F
 		// Chart exists in local and is not tracked by release.yaml
 		logger.Log(ctx, slog.LevelWarn, "chart is untracked", slog.String("name", chart.Metadata.Name), slog.String("version", chart.Metadata.Version))
 		// If the chart exists in local and not on the upstream it may have been removed by the lifecycle rules
-		isVersionInLifecycle := lifeCycleDep.VR.CheckChartVersionForLifecycle(chart.Metadata.Version)
+		isVersionInLifecycle := lifeCycleDep.VR.CheckChartVersionForLifecycle(chart.Metadata.Name, chart.Metadata.Version)
 		if isVersionInLifecycle {
 			// this chart should not be removed
 			response.UntrackedInRelease = response.UntrackedInRelease.Append(chart.Metadata.Name, chart.Metadata.Version)
@@ -0,0 +1,77 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/rancher/charts-build-scripts/pkg/chartvalues"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/logger"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	helmLoader "helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// ValuesCompatibility is the result of comparing the values of a released chart version with the previous version
+type ValuesCompatibility struct {
+	Chart           string
+	Version         string
+	PreviousVersion string
+	Changes         []chartvalues.Change
+	// Unacknowledged are the removals of Changes that are not acknowledged under removedValues in package.yaml
+	Unacknowledged []chartvalues.Change
+}
+
+// CheckValuesCompatibility compares the values.yaml and values.schema.json of every chart version in release.yaml with the latest
+// version of the chart in index.yaml released before it, and returns the versions whose values changed. Versions of release.yaml
+// that are not in assets/, i.e. removed in this release, are skipped.
+// acknowledged lists, per chart name, the keys whose removal is intended.
+func CheckValuesCompatibility(ctx context.Context, repoFs billy.Filesystem, releaseOptions options.ReleaseOptions, acknowledged map[string][]string) ([]ValuesCompatibility, error) {
+	released, err := releasedVersions(ctx, repoFs, releaseOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []ValuesCompatibility{}
+	for _, r := range released {
+		logger.Log(ctx, slog.LevelDebug, "checking values compatibility", slog.String("chart", r.Chart), slog.String("version", r.Version), slog.String("previous", r.Previous))
+
+		changes, err := compareAssetValues(repoFs, r.Chart, r.Previous, r.Version)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			results = append(results, ValuesCompatibility{
+				Chart:           r.Chart,
+				Version:         r.Version,
+				PreviousVersion: r.Previous,
+				Changes:         changes,
+				Unacknowledged:  chartvalues.Unacknowledged(changes, acknowledged[r.Chart]),
+			})
+		}
+	}
+	return results, nil
+}
+
+// compareAssetValues loads both assets/<chart>/<chart>-<version>.tgz and compares their values
+func compareAssetValues(repoFs billy.Filesystem, chart, oldVersion, newVersion string) ([]chartvalues.Change, error) {
+	oldAsset := assetPath(chart, oldVersion)
+	oldChart, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, oldAsset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", oldAsset, err)
+	}
+	newAsset := assetPath(chart, newVersion)
+	newChart, err := helmLoader.Load(filesystem.GetAbsPath(repoFs, newAsset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", newAsset, err)
+	}
+	return chartvalues.Diff(oldChart, newChart)
+}
+
+// LoadRemovedValues merges the removedValues acknowledged in the package.yaml of every package in packages/
+func LoadRemovedValues(ctx context.Context, repoRoot string) (map[string][]string, error) {
+	return loadAcknowledged(ctx, repoRoot, func(pkgOpts options.PackageOptions) map[string][]string {
+		return pkgOpts.RemovedValues
+	})
+}
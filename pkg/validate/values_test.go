@@ -0,0 +1,81 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/charts-build-scripts/pkg/chartvalues"
+	"github.com/rancher/charts-build-scripts/pkg/filesystem"
+	"github.com/rancher/charts-build-scripts/pkg/options"
+	"github.com/rancher/charts-build-scripts/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmRepo "helm.sh/helm/v3/pkg/repo"
+)
+
+func Test_CheckValuesCompatibility(t *testing.T) {
+	repoRoot := t.TempDir()
+	save := func(name, version, values string) {
+		c := &chart.Chart{
+			Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version},
+			Raw:      []*chart.File{{Name: chartutil.ValuesfileName, Data: []byte(values)}},
+		}
+		_, err := chartutil.Save(c, filepath.Join(repoRoot, "assets", name))
+		assert.NoError(t, err)
+	}
+	save("fleet", "105.0.0+up0.11.0", "debug: false\nlegacy:\n  enabled: false\noldOption: \"\"\n")
+	save("fleet", "105.1.0+up0.12.0", "debug: true\n")
+	save("fleet-crd", "105.0.0+up0.11.0", "")
+	save("fleet-crd", "105.1.0+up0.12.0", "")
+
+	index, err := helmRepo.IndexDirectory(filepath.Join(repoRoot, "assets"), "assets")
+	assert.NoError(t, err)
+	assert.NoError(t, index.WriteFile(filepath.Join(repoRoot, "index.yaml"), os.ModePerm))
+
+	// 105.1.0+up0.12.0-rc.1 was removed from assets/ in this release
+	results, err := CheckValuesCompatibility(context.Background(), filesystem.GetFilesystem(repoRoot), options.ReleaseOptions{
+		"fleet":     {"105.1.0+up0.12.0-rc.1", "105.1.0+up0.12.0"},
+		"fleet-crd": {"105.1.0+up0.12.0"},
+	}, map[string][]string{"fleet": {"legacy"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []ValuesCompatibility{
+		{
+			Chart:           "fleet",
+			Version:         "105.1.0+up0.12.0",
+			PreviousVersion: "105.0.0+up0.11.0",
+			Changes: []chartvalues.Change{
+				{Key: "debug", Type: chartvalues.DefaultChanged, Old: "false", New: "true"},
+				{Key: "legacy.enabled", Type: chartvalues.Removed, Old: "false"},
+				{Key: "oldOption", Type: chartvalues.Removed, Old: `""`},
+			},
+			Unacknowledged: []chartvalues.Change{
+				{Key: "oldOption", Type: chartvalues.Removed, Old: `""`},
+			},
+		},
+	}, results)
+}
+
+func Test_LoadRemovedValues(t *testing.T) {
+	util.InitSoftErrorMode()
+	repoRoot := t.TempDir()
+	write := func(pkg, content string) {
+		dir := filepath.Join(repoRoot, "packages", pkg)
+		assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "package.yaml"), []byte(content), 0644))
+	}
+	write("fleet", "url: local\nremovedValues:\n  fleet: [legacy]\n  fleet-agent: [oldOption]\n")
+	write("rancher-istio/1.22/rancher-istio", "url: local\nremovedValues:\n  rancher-istio: [kiali]\n")
+	write("rancher-istio/1.23/rancher-istio", "url: local\nremovedValues:\n  rancher-istio: [tracing]\n")
+	write("rancher-webhook", "url: local\n")
+
+	removedValues, err := LoadRemovedValues(context.Background(), repoRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"fleet":         {"legacy"},
+		"fleet-agent":   {"oldOption"},
+		"rancher-istio": {"kiali", "tracing"},
+	}, removedValues)
+}